@@ -0,0 +1,258 @@
+package iam
+
+import (
+	"context"
+
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/service/federations/saml"
+	"github.com/selectel/iam-go/v2/service/federations/saml/certificates"
+	"github.com/selectel/iam-go/v2/service/federations/saml/groupmappings"
+	"github.com/selectel/iam-go/v2/service/groups"
+	"github.com/selectel/iam-go/v2/service/principals"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/s3credentials"
+	"github.com/selectel/iam-go/v2/service/serviceusers"
+	"github.com/selectel/iam-go/v2/service/users"
+)
+
+// The interfaces below describe the services of the Client, so the code using them can accept
+// test doubles, e.g. from the iammock package. Mocks are regenerated by go generate after changing them.
+//
+//go:generate go run ./internal/cmd/mockgen -source api.go -out iammock/mocks_gen.go
+
+// UsersAPI is implemented by *users.Service and manages Panel Users.
+type UsersAPI interface {
+	List(ctx context.Context, opts ...iamrequest.Option) (*users.ListResponse, error)
+	ForEach(ctx context.Context, fn func(users.User) error, opts ...iamrequest.Option) error
+	Get(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) (*users.GetResponse, error)
+	Create(ctx context.Context, input users.CreateRequest, opts ...iamrequest.Option) (*users.CreateResponse, error)
+	Ensure(ctx context.Context, input users.CreateRequest, opts ...iamrequest.Option) (*users.EnsureResponse, error)
+	Delete(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) error
+	ResendInvite(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) error
+	AssignRoles(ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option) error
+	UnassignRoles(ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option) error
+	SetRoles(
+		ctx context.Context, userID iamid.UserID, desired []roles.Role, opts ...iamrequest.Option,
+	) (*roles.Change, error)
+}
+
+// ServiceUsersAPI is implemented by *serviceusers.Service and manages Service Users.
+type ServiceUsersAPI interface {
+	List(ctx context.Context, opts ...iamrequest.Option) (*serviceusers.ListResponse, error)
+	ForEach(ctx context.Context, fn func(serviceusers.ServiceUser) error, opts ...iamrequest.Option) error
+	Get(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) (*serviceusers.GetResponse, error)
+	Create(
+		ctx context.Context, input serviceusers.CreateRequest, opts ...iamrequest.Option,
+	) (*serviceusers.CreateResponse, error)
+	Ensure(
+		ctx context.Context, input serviceusers.EnsureRequest, opts ...iamrequest.Option,
+	) (*serviceusers.EnsureResponse, error)
+	Update(
+		ctx context.Context, userID iamid.UserID, input serviceusers.UpdateRequest, opts ...iamrequest.Option,
+	) (*serviceusers.UpdateResponse, error)
+	Patch(
+		ctx context.Context, userID iamid.UserID, input serviceusers.PatchRequest, opts ...iamrequest.Option,
+	) (*serviceusers.UpdateResponse, error)
+	Modify(
+		ctx context.Context,
+		userID iamid.UserID,
+		mutate func(*serviceusers.ServiceUser) error,
+		opts ...iamrequest.Option,
+	) (*serviceusers.UpdateResponse, error)
+	Delete(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) error
+	AssignRoles(ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option) error
+	UnassignRoles(ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option) error
+	SetRoles(
+		ctx context.Context, userID iamid.UserID, desired []roles.Role, opts ...iamrequest.Option,
+	) (*roles.Change, error)
+}
+
+// GroupsAPI is implemented by *groups.Service and manages Groups of users.
+type GroupsAPI interface {
+	List(ctx context.Context, opts ...iamrequest.Option) (*groups.ListResponse, error)
+	ForEach(ctx context.Context, fn func(groups.Group) error, opts ...iamrequest.Option) error
+	Get(ctx context.Context, groupID iamid.GroupID, opts ...iamrequest.Option) (*groups.GetResponse, error)
+	Create(ctx context.Context, input groups.CreateRequest, opts ...iamrequest.Option) (*groups.CreateResponse, error)
+	Ensure(ctx context.Context, input groups.CreateRequest, opts ...iamrequest.Option) (*groups.EnsureResponse, error)
+	Update(
+		ctx context.Context, groupID iamid.GroupID, input groups.UpdateRequest, opts ...iamrequest.Option,
+	) (*groups.UpdateResponse, error)
+	Patch(
+		ctx context.Context, groupID iamid.GroupID, input groups.PatchRequest, opts ...iamrequest.Option,
+	) (*groups.UpdateResponse, error)
+	Modify(
+		ctx context.Context, groupID iamid.GroupID, mutate func(*groups.Group) error, opts ...iamrequest.Option,
+	) (*groups.UpdateResponse, error)
+	Delete(ctx context.Context, groupID iamid.GroupID, opts ...iamrequest.Option) error
+	AssignRoles(ctx context.Context, groupID iamid.GroupID, roles []roles.Role, opts ...iamrequest.Option) error
+	UnassignRoles(ctx context.Context, groupID iamid.GroupID, roles []roles.Role, opts ...iamrequest.Option) error
+	SetRoles(
+		ctx context.Context, groupID iamid.GroupID, desired []roles.Role, opts ...iamrequest.Option,
+	) (*roles.Change, error)
+	AddUsers(
+		ctx context.Context, groupID iamid.GroupID, usersKeystoneIDs []iamid.KeystoneID, opts ...iamrequest.Option,
+	) error
+	DeleteUsers(
+		ctx context.Context, groupID iamid.GroupID, usersKeystoneIDs []iamid.KeystoneID, opts ...iamrequest.Option,
+	) error
+	AddMembers(ctx context.Context, groupID iamid.GroupID, members []principals.Ref, opts ...iamrequest.Option) error
+	DeleteMembers(ctx context.Context, groupID iamid.GroupID, members []principals.Ref, opts ...iamrequest.Option) error
+	SetMembers(
+		ctx context.Context, groupID iamid.GroupID, usersKeystoneIDs []iamid.KeystoneID, opts ...iamrequest.Option,
+	) (*groups.MembersChange, error)
+}
+
+// RolesAPI is implemented by *roles.Service and lists available roles.
+type RolesAPI interface {
+	List(ctx context.Context, opts ...iamrequest.Option) (*roles.ListResponse, error)
+	ForEach(ctx context.Context, fn func(roles.AvailableRole) error, opts ...iamrequest.Option) error
+}
+
+// S3CredentialsAPI is implemented by *s3credentials.Service and manages S3 Credentials of Service Users.
+type S3CredentialsAPI interface {
+	List(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) (*s3credentials.ListResponse, error)
+	ForEach(
+		ctx context.Context, userID iamid.UserID, fn func(s3credentials.Credential) error, opts ...iamrequest.Option,
+	) error
+	Create(
+		ctx context.Context, userID iamid.UserID, name string, projectID iamid.ProjectID, opts ...iamrequest.Option,
+	) (*s3credentials.CreateResponse, error)
+	Delete(ctx context.Context, userID iamid.UserID, accessKey iamid.AccessKey, opts ...iamrequest.Option) error
+}
+
+// SAMLFederationsAPI is implemented by *saml.Service and manages SAML Federations.
+// Certificates and group mappings of Federations are managed by CertificatesAPI and GroupMappingsAPI.
+type SAMLFederationsAPI interface {
+	List(ctx context.Context, opts ...iamrequest.Option) (*saml.ListResponse, error)
+	ForEach(ctx context.Context, fn func(saml.Federation) error, opts ...iamrequest.Option) error
+	Get(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) (*saml.GetResponse, error)
+	Exists(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) (bool, error)
+	Preview(
+		ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option,
+	) (*saml.FederationPreview, error)
+	Create(ctx context.Context, input saml.CreateRequest, opts ...iamrequest.Option) (*saml.CreateResponse, error)
+	Ensure(ctx context.Context, input saml.CreateRequest, opts ...iamrequest.Option) (*saml.EnsureResponse, error)
+	Update(
+		ctx context.Context, federationID iamid.FederationID, input saml.UpdateRequest, opts ...iamrequest.Option,
+	) error
+	Patch(
+		ctx context.Context, federationID iamid.FederationID, input saml.PatchRequest, opts ...iamrequest.Option,
+	) error
+	Modify(
+		ctx context.Context,
+		federationID iamid.FederationID,
+		mutate func(*saml.Federation) error,
+		opts ...iamrequest.Option,
+	) error
+	Delete(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) error
+}
+
+// CertificatesAPI is implemented by *certificates.Service and manages Certificates of SAML Federations.
+type CertificatesAPI interface {
+	List(
+		ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option,
+	) (*certificates.ListResponse, error)
+	ForEach(
+		ctx context.Context,
+		federationID iamid.FederationID,
+		fn func(certificates.Certificate) error,
+		opts ...iamrequest.Option,
+	) error
+	Get(
+		ctx context.Context,
+		federationID iamid.FederationID,
+		certificateID iamid.CertificateID,
+		opts ...iamrequest.Option,
+	) (*certificates.GetResponse, error)
+	Create(
+		ctx context.Context,
+		federationID iamid.FederationID,
+		input certificates.CreateRequest,
+		opts ...iamrequest.Option,
+	) (*certificates.CreateResponse, error)
+	Ensure(
+		ctx context.Context,
+		federationID iamid.FederationID,
+		input certificates.CreateRequest,
+		opts ...iamrequest.Option,
+	) (*certificates.EnsureResponse, error)
+	Update(
+		ctx context.Context,
+		federationID iamid.FederationID,
+		certificateID iamid.CertificateID,
+		input certificates.UpdateRequest,
+		opts ...iamrequest.Option,
+	) (*certificates.UpdateResponse, error)
+	Patch(
+		ctx context.Context,
+		federationID iamid.FederationID,
+		certificateID iamid.CertificateID,
+		input certificates.PatchRequest,
+		opts ...iamrequest.Option,
+	) (*certificates.UpdateResponse, error)
+	Modify(
+		ctx context.Context,
+		federationID iamid.FederationID,
+		certificateID iamid.CertificateID,
+		mutate func(*certificates.Certificate) error,
+		opts ...iamrequest.Option,
+	) (*certificates.UpdateResponse, error)
+	Delete(
+		ctx context.Context,
+		federationID iamid.FederationID,
+		certificateID iamid.CertificateID,
+		opts ...iamrequest.Option,
+	) error
+}
+
+// GroupMappingsAPI is implemented by *groupmappings.Service and manages group mappings of SAML Federations.
+type GroupMappingsAPI interface {
+	List(
+		ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option,
+	) (*groupmappings.GroupMappingsResponse, error)
+	ForEach(
+		ctx context.Context,
+		federationID iamid.FederationID,
+		fn func(groupmappings.GroupMapping) error,
+		opts ...iamrequest.Option,
+	) error
+	Update(
+		ctx context.Context,
+		federationID iamid.FederationID,
+		input groupmappings.GroupMappingsRequest,
+		opts ...iamrequest.Option,
+	) error
+	Add(
+		ctx context.Context,
+		federationID iamid.FederationID,
+		groupID iamid.GroupID,
+		externalGroupID string,
+		opts ...iamrequest.Option,
+	) error
+	Delete(
+		ctx context.Context,
+		federationID iamid.FederationID,
+		groupID iamid.GroupID,
+		externalGroupID string,
+		opts ...iamrequest.Option,
+	) error
+	Exists(
+		ctx context.Context,
+		federationID iamid.FederationID,
+		groupID iamid.GroupID,
+		externalGroupID string,
+		opts ...iamrequest.Option,
+	) (bool, error)
+}
+
+var (
+	_ UsersAPI           = (*users.Service)(nil)
+	_ ServiceUsersAPI    = (*serviceusers.Service)(nil)
+	_ GroupsAPI          = (*groups.Service)(nil)
+	_ RolesAPI           = (*roles.Service)(nil)
+	_ S3CredentialsAPI   = (*s3credentials.Service)(nil)
+	_ SAMLFederationsAPI = (*saml.Service)(nil)
+	_ CertificatesAPI    = (*certificates.Service)(nil)
+	_ GroupMappingsAPI   = (*groupmappings.Service)(nil)
+)
@@ -0,0 +1,293 @@
+package iam
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+)
+
+const (
+	// defaultConfigProfile represents a profile name used when no profile was requested explicitly.
+	defaultConfigProfile = "default"
+
+	envAPIUrl            = "SEL_IAM_API_URL"
+	envUserAgentPrefix   = "SEL_IAM_USER_AGENT_PREFIX"
+	envTimeout           = "SEL_IAM_TIMEOUT"
+	envProxy             = "SEL_IAM_PROXY"
+	envCABundle          = "SEL_IAM_CA_BUNDLE"
+	envTokenFile         = "SEL_IAM_TOKEN_FILE"
+	envToken             = "OS_TOKEN"
+	envAuthURL           = "OS_AUTH_URL"
+	envUserName          = "OS_USERNAME"
+	envPassword          = "OS_PASSWORD"
+	envUserDomainName    = "OS_USER_DOMAIN_NAME"
+	envProjectDomainName = "OS_PROJECT_DOMAIN_NAME"
+	envProjectName       = "OS_PROJECT_NAME"
+)
+
+// Config represents settings of a Client, which can be loaded from environment variables or a config file.
+type Config struct {
+	// APIUrl is optional and represents an IAM API URL.
+	APIUrl string `yaml:"api_url"`
+
+	// UserAgentPrefix is optional and represents a custom prefix to be added to User-Agent.
+	UserAgentPrefix string `yaml:"user_agent_prefix"`
+
+	// Timeout is optional and represents a timeout for HTTP requests.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// Proxy is optional and represents a URL of the proxy used for HTTP requests.
+	Proxy string `yaml:"proxy"`
+
+	// CABundle is optional and represents a path to the PEM file with additional trusted CA certificates.
+	CABundle string `yaml:"ca_bundle"`
+
+	// Auth contains data to authenticate against Selectel IAM API.
+	Auth *AuthOpts `yaml:"auth"`
+}
+
+// LogValue implements slog.LogValuer and redacts secrets of Auth.
+func (c Config) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("api_url", c.APIUrl),
+		slog.String("user_agent_prefix", c.UserAgentPrefix),
+		slog.Duration("timeout", c.Timeout),
+		slog.String("proxy", c.Proxy),
+		slog.String("ca_bundle", c.CABundle),
+	}
+	if c.Auth != nil {
+		attrs = append(attrs, slog.Any("auth", *c.Auth))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// ConfigFile represents a config file with multiple named profiles.
+//
+//	default_profile: production
+//	profiles:
+//	  production:
+//	    user_agent_prefix: provisioner
+//	    timeout: 30s
+//	    auth:
+//	      user_name: service-user
+//	      password: Qazwsxedc123
+//	      account_id: "123456"
+//	  staging:
+//	    api_url: https://api.staging.example.org
+//	    auth:
+//	      keystone_token_file: /var/run/secrets/iam/token
+type ConfigFile struct {
+	// DefaultProfile is optional and represents a profile used when no profile was requested explicitly.
+	// If it is empty, the profile named "default" is used.
+	DefaultProfile string `yaml:"default_profile"`
+
+	// Profiles contains configurations by names.
+	Profiles map[string]Config `yaml:"profiles"`
+}
+
+// LogValue implements slog.LogValuer and redacts secrets of the profiles.
+func (f ConfigFile) LogValue() slog.Value {
+	profiles := make([]slog.Attr, 0, len(f.Profiles))
+	for name, config := range f.Profiles {
+		profiles = append(profiles, slog.Any(name, config))
+	}
+	return slog.GroupValue(
+		slog.String("default_profile", f.DefaultProfile),
+		slog.Attr{Key: "profiles", Value: slog.GroupValue(profiles...)},
+	)
+}
+
+// NewFromEnv returns a new instance of Client configured by environment variables.
+//
+// Supported variables are: SEL_IAM_API_URL, SEL_IAM_USER_AGENT_PREFIX, SEL_IAM_TIMEOUT, SEL_IAM_PROXY,
+// SEL_IAM_CA_BUNDLE, SEL_IAM_TOKEN_FILE, OS_TOKEN, OS_AUTH_URL, OS_USERNAME, OS_PASSWORD,
+// OS_USER_DOMAIN_NAME (or OS_PROJECT_DOMAIN_NAME) and OS_PROJECT_NAME.
+//
+// Options passed explicitly are applied after the environment ones.
+func NewFromEnv(opts ...Option) (*Client, error) {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return config.newClient(opts)
+}
+
+// NewFromConfig returns a new instance of Client configured by the profile from the config file.
+// If profile is empty, the default profile of the file is used.
+//
+// Options passed explicitly are applied after the config ones.
+func NewFromConfig(path, profile string, opts ...Option) (*Client, error) {
+	config, err := LoadConfig(path, profile)
+	if err != nil {
+		return nil, err
+	}
+	return config.newClient(opts)
+}
+
+// ConfigFromEnv returns a Config filled from environment variables. See NewFromEnv for the list of variables.
+func ConfigFromEnv() (*Config, error) {
+	config := &Config{
+		APIUrl:          os.Getenv(envAPIUrl),
+		UserAgentPrefix: os.Getenv(envUserAgentPrefix),
+		Proxy:           os.Getenv(envProxy),
+		CABundle:        os.Getenv(envCABundle),
+	}
+
+	if timeout := os.Getenv(envTimeout); timeout != "" {
+		duration, err := time.ParseDuration(timeout)
+		if err != nil {
+			return nil, iamerrors.Error{
+				Err:  iamerrors.ErrClientConfigInvalid,
+				Desc: fmt.Sprintf("%s is not a valid duration: %s", envTimeout, err.Error()),
+			}
+		}
+		config.Timeout = duration
+	}
+
+	accountID := os.Getenv(envUserDomainName)
+	if projectDomain := os.Getenv(envProjectDomainName); projectDomain != "" {
+		if accountID != "" && accountID != projectDomain {
+			return nil, iamerrors.Error{
+				Err:  iamerrors.ErrClientConfigInvalid,
+				Desc: fmt.Sprintf("%s and %s point to different accounts", envUserDomainName, envProjectDomainName),
+			}
+		}
+		accountID = projectDomain
+	}
+
+	auth := AuthOpts{
+		KeystoneToken:     os.Getenv(envToken),
+		KeystoneTokenFile: os.Getenv(envTokenFile),
+		UserName:          os.Getenv(envUserName),
+		Password:          os.Getenv(envPassword),
+		AccountID:         accountID,
+		ProjectName:       os.Getenv(envProjectName),
+		KeystoneURL:       os.Getenv(envAuthURL),
+	}
+	if auth.KeystoneToken != "" || auth.KeystoneTokenFile != "" || auth.UserName != "" {
+		config.Auth = &auth
+	}
+
+	return config, nil
+}
+
+// LoadConfig returns a Config of the profile from the config file.
+// If profile is empty, the default profile of the file is used.
+func LoadConfig(path, profile string) (*Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, iamerrors.Error{Err: iamerrors.ErrClientConfigInvalid, Desc: err.Error()}
+	}
+	defer file.Close()
+
+	var configFile ConfigFile
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&configFile); err != nil && !errors.Is(err, io.EOF) {
+		return nil, iamerrors.Error{
+			Err:  iamerrors.ErrClientConfigInvalid,
+			Desc: fmt.Sprintf("Failed to parse %s: %s", path, err.Error()),
+		}
+	}
+
+	if profile == "" {
+		profile = configFile.DefaultProfile
+	}
+	if profile == "" {
+		profile = defaultConfigProfile
+	}
+
+	config, ok := configFile.Profiles[profile]
+	if !ok {
+		return nil, iamerrors.Error{
+			Err:  iamerrors.ErrClientConfigProfileNotFound,
+			Desc: fmt.Sprintf("No profile %q was found in %s", profile, path),
+		}
+	}
+
+	return &config, nil
+}
+
+// Options returns functional parameters for Client, which apply the Config.
+func (c *Config) Options() ([]Option, error) {
+	var opts []Option
+
+	if c.APIUrl != "" {
+		opts = append(opts, WithAPIUrl(c.APIUrl))
+	}
+	if c.UserAgentPrefix != "" {
+		opts = append(opts, WithUserAgentPrefix(c.UserAgentPrefix))
+	}
+	if c.Auth != nil {
+		opts = append(opts, WithAuthOpts(c.Auth))
+	}
+
+	if c.Timeout != 0 || c.Proxy != "" || c.CABundle != "" {
+		httpClient, err := c.newHTTPClient()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithCustomHTTPClient(httpClient))
+	}
+
+	return opts, nil
+}
+
+func (c *Config) newClient(opts []Option) (*Client, error) {
+	configOpts, err := c.Options()
+	if err != nil {
+		return nil, err
+	}
+	return New(append(configOpts, opts...)...)
+}
+
+func (c *Config) newHTTPClient() (*http.Client, error) {
+	transport := newHTTPTransport()
+
+	if c.Proxy != "" {
+		proxyURL, err := url.Parse(c.Proxy)
+		if err != nil {
+			return nil, iamerrors.Error{
+				Err:  iamerrors.ErrClientConfigInvalid,
+				Desc: fmt.Sprintf("Proxy is not a valid URL: %s", err.Error()),
+			}
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if c.CABundle != "" {
+		bundle, err := os.ReadFile(c.CABundle)
+		if err != nil {
+			return nil, iamerrors.Error{Err: iamerrors.ErrClientConfigInvalid, Desc: err.Error()}
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, iamerrors.Error{
+				Err:  iamerrors.ErrClientConfigInvalid,
+				Desc: fmt.Sprintf("No certificates were found in %s", c.CABundle),
+			}
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = defaultHTTPTimeout * time.Second
+	}
+
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
@@ -0,0 +1,238 @@
+package iam
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	baseclient "github.com/selectel/iam-go/v2/internal/client"
+)
+
+const testConfigFile = `
+default_profile: production
+profiles:
+  production:
+    user_agent_prefix: provisioner
+    timeout: 30s
+    proxy: http://proxy.example.org:3128
+    auth:
+      user_name: service-user
+      password: test-password
+      account_id: "123456"
+  staging:
+    api_url: http://example.org/
+    auth:
+      keystone_token_file: /var/run/secrets/iam/token
+  conflicting:
+    auth:
+      keystone_token: test-token
+      keystone_token_file: /var/run/secrets/iam/token
+  empty: {}
+`
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestNewFromEnv(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           map[string]string
+		check         func(*assert.Assertions, *Client)
+		expectedError error
+	}{
+		{
+			name: "Test NewFromEnv with OS_TOKEN",
+			env: map[string]string{
+				envToken:           testToken,
+				envAPIUrl:          testURL,
+				envUserAgentPrefix: "provisioner",
+			},
+			check: func(assert *assert.Assertions, c *Client) {
+				assert.Equal(testURL, c.baseClient.APIUrl)
+				assert.Equal(&baseclient.KeystoneTokenAuth{KeystoneToken: testToken}, c.baseClient.AuthMethod)
+				assert.Equal("provisioner "+appName+"/"+findModuleVersion(), c.baseClient.UserAgent)
+			},
+		},
+		{
+			name: "Test NewFromEnv with Service User",
+			env: map[string]string{
+				envUserName:          testUserName,
+				envPassword:          testPassword,
+				envProjectDomainName: testAccountID,
+				envUserDomainName:    testAccountID,
+				envProjectName:       testProjectName,
+				envAuthURL:           "http://keystone.example.org/v3",
+				envTimeout:           "15s",
+			},
+			check: func(assert *assert.Assertions, c *Client) {
+				assert.Equal(defaultIAMApiURL, c.baseClient.APIUrl)
+				assert.Equal(15*time.Second, c.baseClient.HTTPClient.Timeout)
+				assert.Equal(baseclient.NewServiceUserAuth(
+					c.baseClient.HTTPClient,
+					"http://keystone.example.org/v3",
+					testUserName,
+					testPassword,
+					testAccountID,
+					testProjectName,
+				), c.baseClient.AuthMethod)
+			},
+		},
+		{
+			name:          "Test NewFromEnv without auth variables",
+			env:           map[string]string{envAPIUrl: testURL},
+			expectedError: iamerrors.ErrClientNoAuthOpts,
+		},
+		{
+			name:          "Test NewFromEnv with both OS_TOKEN and SEL_IAM_TOKEN_FILE",
+			env:           map[string]string{envToken: testToken, envTokenFile: testTokenFile},
+			expectedError: iamerrors.ErrClientConflictingAuthOpts,
+		},
+		{
+			name: "Test NewFromEnv with different domains",
+			env: map[string]string{
+				envUserName:          testUserName,
+				envPassword:          testPassword,
+				envUserDomainName:    testAccountID,
+				envProjectDomainName: "654321",
+			},
+			expectedError: iamerrors.ErrClientConfigInvalid,
+		},
+		{
+			name:          "Test NewFromEnv with invalid timeout",
+			env:           map[string]string{envToken: testToken, envTimeout: "15"},
+			expectedError: iamerrors.ErrClientConfigInvalid,
+		},
+		{
+			name:          "Test NewFromEnv with missing CA bundle",
+			env:           map[string]string{envToken: testToken, envCABundle: "/nonexistent/ca.pem"},
+			expectedError: iamerrors.ErrClientConfigInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			for _, key := range []string{
+				envAPIUrl, envUserAgentPrefix, envTimeout, envProxy, envCABundle, envTokenFile, envToken,
+				envAuthURL, envUserName, envPassword, envUserDomainName, envProjectDomainName, envProjectName,
+			} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			actual, err := NewFromEnv()
+
+			require.ErrorIs(err, tt.expectedError)
+			if tt.check != nil {
+				require.NotNil(actual)
+				tt.check(assert, actual)
+			}
+		})
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	configPath := writeTestFile(t, "config.yaml", testConfigFile)
+
+	tests := []struct {
+		name          string
+		path          string
+		profile       string
+		opts          []Option
+		check         func(*assert.Assertions, *Client)
+		expectedError error
+	}{
+		{
+			name:    "Test NewFromConfig with the default profile",
+			path:    configPath,
+			profile: "",
+			check: func(assert *assert.Assertions, c *Client) {
+				assert.Equal(defaultIAMApiURL, c.baseClient.APIUrl)
+				assert.Equal("provisioner", c.baseClient.UserAgentPrefix)
+				assert.Equal(30*time.Second, c.baseClient.HTTPClient.Timeout)
+				assert.IsType(&baseclient.ServiceUserAuth{}, c.baseClient.AuthMethod)
+
+				transport, ok := c.baseClient.HTTPClient.Transport.(*http.Transport)
+				assert.True(ok)
+				proxy, err := transport.Proxy(&http.Request{})
+				assert.NoError(err)
+				assert.Equal("http://proxy.example.org:3128", proxy.String())
+			},
+		},
+		{
+			name:    "Test NewFromConfig with the named profile",
+			path:    configPath,
+			profile: "staging",
+			check: func(assert *assert.Assertions, c *Client) {
+				assert.Equal(testURL, c.baseClient.APIUrl)
+				assert.Equal(baseclient.NewFileTokenAuth(testTokenFile, 0), c.baseClient.AuthMethod)
+				assert.Equal(defaultHTTPTimeout*time.Second, c.baseClient.HTTPClient.Timeout)
+			},
+		},
+		{
+			name:    "Test NewFromConfig with overriding options",
+			path:    configPath,
+			profile: "staging",
+			opts:    []Option{WithAPIUrl("http://example.com/")},
+			check: func(assert *assert.Assertions, c *Client) {
+				assert.Equal("http://example.com/", c.baseClient.APIUrl)
+			},
+		},
+		{
+			name:          "Test NewFromConfig with conflicting auth",
+			path:          configPath,
+			profile:       "conflicting",
+			expectedError: iamerrors.ErrClientConflictingAuthOpts,
+		},
+		{
+			name:          "Test NewFromConfig without auth",
+			path:          configPath,
+			profile:       "empty",
+			expectedError: iamerrors.ErrClientNoAuthOpts,
+		},
+		{
+			name:          "Test NewFromConfig with unknown profile",
+			path:          configPath,
+			profile:       "unknown",
+			expectedError: iamerrors.ErrClientConfigProfileNotFound,
+		},
+		{
+			name:          "Test NewFromConfig with unknown field",
+			path:          writeTestFile(t, "unknown.yaml", "profiles:\n  default:\n    token: test-token\n"),
+			expectedError: iamerrors.ErrClientConfigInvalid,
+		},
+		{
+			name:          "Test NewFromConfig with missing file",
+			path:          filepath.Join(t.TempDir(), "missing.yaml"),
+			expectedError: iamerrors.ErrClientConfigInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			actual, err := NewFromConfig(tt.path, tt.profile, tt.opts...)
+
+			require.ErrorIs(err, tt.expectedError)
+			if tt.check != nil {
+				require.NotNil(actual)
+				tt.check(assert, actual)
+			}
+		})
+	}
+}
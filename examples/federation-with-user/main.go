@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/selectel/iam-go"
-	"github.com/selectel/iam-go/service/federations/saml"
-	"github.com/selectel/iam-go/service/federations/saml/certificates"
-	"github.com/selectel/iam-go/service/roles"
-	"github.com/selectel/iam-go/service/users"
+	"github.com/selectel/iam-go/v2"
+	"github.com/selectel/iam-go/v2/service/federations/saml"
+	"github.com/selectel/iam-go/v2/service/federations/saml/certificates"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/users"
 )
 
 const (
@@ -5,10 +5,11 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/selectel/iam-go"
-	"github.com/selectel/iam-go/service/groups"
-	"github.com/selectel/iam-go/service/roles"
-	"github.com/selectel/iam-go/service/users"
+	"github.com/selectel/iam-go/v2"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/service/groups"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/users"
 )
 
 const (
@@ -64,7 +65,7 @@ func main() {
 		Email:      email,
 		Federation: nil,
 		Roles:      []roles.Role{{Scope: AccountScope, RoleName: Reader}},
-		GroupIDs:   []string{group.ID},
+		GroupIDs:   []iamid.GroupID{group.ID},
 	})
 	if err != nil {
 		fmt.Println(err)
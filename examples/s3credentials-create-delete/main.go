@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 
-	iam "github.com/selectel/iam-go"
+	iam "github.com/selectel/iam-go/v2"
+
+	"github.com/selectel/iam-go/v2/iamid"
 )
 
 var (
@@ -16,13 +18,13 @@ var (
 	prefix = "iam-go"
 
 	// ID of the User to create S3 Credentials for.
-	userID = "a1b2c3..."
+	userID = iamid.UserID("a1b2c3...")
 
 	// Name of the S3 Credentials to create.
 	name = "my-s3-credentials"
 
 	// Project ID to create the S3 Credentials for.
-	projectID = "a1b2c3..."
+	projectID = iamid.ProjectID("a1b2c3...")
 )
 
 func main() {
@@ -4,9 +4,10 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/selectel/iam-go"
-	"github.com/selectel/iam-go/service/roles"
-	"github.com/selectel/iam-go/service/users"
+	"github.com/selectel/iam-go/v2"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/users"
 )
 
 const (
@@ -25,7 +26,7 @@ func main() {
 	prefix := "iam-go"
 
 	// ID of the User to assign role to.
-	userID := "654321_65432"
+	userID := iamid.UserID("654321_65432")
 
 	// Create a new IAM client.
 	iamClient, err := iam.New(
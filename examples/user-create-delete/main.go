@@ -4,9 +4,9 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/selectel/iam-go"
-	"github.com/selectel/iam-go/service/roles"
-	"github.com/selectel/iam-go/service/users"
+	"github.com/selectel/iam-go/v2"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/users"
 )
 
 const (
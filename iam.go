@@ -1,24 +1,35 @@
 package iam
 
 import (
+	"context"
+	"log/slog"
 	"net/http"
 	"runtime/debug"
 	"time"
 
-	"github.com/selectel/iam-go/iamerrors"
-	baseclient "github.com/selectel/iam-go/internal/client"
-	"github.com/selectel/iam-go/service/federations/saml"
-	"github.com/selectel/iam-go/service/groups"
-	"github.com/selectel/iam-go/service/roles"
-	"github.com/selectel/iam-go/service/s3credentials"
-	"github.com/selectel/iam-go/service/serviceusers"
-	"github.com/selectel/iam-go/service/users"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iammetrics"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	baseclient "github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/federations/saml"
+	"github.com/selectel/iam-go/v2/service/groups"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/s3credentials"
+	"github.com/selectel/iam-go/v2/service/serviceusers"
+	"github.com/selectel/iam-go/v2/service/users"
 )
 
 const (
 	// appName represents an application name.
 	appName = "iam-go"
 
+	// modulePath represents the path of the iam-go module.
+	modulePath = "github.com/selectel/iam-go/v2"
+
 	// defaultIAMApiURL represents a default Selectel IAM API URL.
 	defaultIAMApiURL = "https://api.selectel.ru"
 
@@ -38,6 +49,15 @@ const (
 	// defaultExpectContinueTimeout represents the default amount of time to wait for a server's first
 	// response headers.
 	defaultExpectContinueTimeout = 1
+
+	// defaultRetryMaxAttempts represents the maximum number of attempts in DefaultRetryPolicy.
+	defaultRetryMaxAttempts = 3
+
+	// defaultRetryMinBackoff represents the delay before the first retry in DefaultRetryPolicy.
+	defaultRetryMinBackoff = 500 * time.Millisecond
+
+	// defaultRetryMaxBackoff represents the upper bound of the delay between retries in DefaultRetryPolicy.
+	defaultRetryMaxBackoff = 10 * time.Second
 )
 
 // Client stores the configuration, which is needed to make requests to the IAM API.
@@ -45,6 +65,9 @@ type Client struct {
 	// authOpts contains data to authenticate against Selectel IAM API.
 	authOpts *AuthOpts
 
+	// tokenProvider contains a custom source of Keystone tokens.
+	tokenProvider TokenProvider
+
 	// baseClient contains the configuration of the Client.
 	baseClient *baseclient.BaseClient
 
@@ -68,8 +91,138 @@ type Client struct {
 	SAMLFederations *saml.Service
 }
 
+// AuthOpts contains data to authenticate against Selectel IAM API.
+//
+// Exactly one of KeystoneToken, KeystoneTokenFile, Exec or Service User credentials
+// (UserName, Password and AccountID) should be set.
 type AuthOpts struct {
-	KeystoneToken string
+	// KeystoneToken represents a pre-issued Keystone token. It is sent as is with every request.
+	KeystoneToken string `yaml:"keystone_token"`
+
+	// KeystoneTokenFile represents a path to the file with a Keystone token.
+	// The file is checked for changes, so the token can be rotated without re-creating the Client.
+	KeystoneTokenFile string `yaml:"keystone_token_file"`
+
+	// KeystoneTokenFilePollInterval is optional and represents how often KeystoneTokenFile is checked for changes.
+	KeystoneTokenFilePollInterval time.Duration `yaml:"keystone_token_file_poll_interval"`
+
+	// UserName represents a name of the Service User to issue Keystone tokens for.
+	UserName string `yaml:"user_name"`
+
+	// Password represents a password of the Service User.
+	Password string `yaml:"password"`
+
+	// AccountID represents an ID of the account the Service User belongs to.
+	AccountID string `yaml:"account_id"`
+
+	// ProjectName is optional and represents a name of the project to scope issued tokens to.
+	// If it is empty, tokens are scoped to the account.
+	ProjectName string `yaml:"project_name"`
+
+	// KeystoneURL is optional and represents a Keystone API URL used to issue tokens.
+	// If it is empty, the default Selectel Keystone URL is used.
+	KeystoneURL string `yaml:"keystone_url"`
+
+	// Exec represents an external command (a credential plugin), which prints a Keystone token.
+	Exec *ExecAuthOpts `yaml:"exec"`
+}
+
+// LogValue implements slog.LogValuer and redacts KeystoneToken and Password.
+func (o AuthOpts) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("keystone_token", baseclient.Redacted),
+		slog.String("keystone_token_file", o.KeystoneTokenFile),
+		slog.Duration("keystone_token_file_poll_interval", o.KeystoneTokenFilePollInterval),
+		slog.String("user_name", o.UserName),
+		slog.String("password", baseclient.Redacted),
+		slog.String("account_id", o.AccountID),
+		slog.String("project_name", o.ProjectName),
+		slog.String("keystone_url", o.KeystoneURL),
+	}
+	if o.Exec != nil {
+		attrs = append(attrs, slog.String("exec", o.Exec.Command))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// ExecAuthOpts describes an external command used to obtain Keystone tokens, e.g. a corporate SSO helper.
+//
+// The command is invoked lazily and must print a JSON object to stdout:
+//
+//	{"token": "gAAAAA...", "expires_at": "2024-01-01T12:00:00Z"}
+//
+// The "expires_at" field is optional. The command is invoked again shortly before the expiry
+// and when the IAM API rejects the token.
+type ExecAuthOpts struct {
+	// Command represents a path to the executable or its name to look up in PATH.
+	Command string `yaml:"command"`
+
+	// Args is optional and represents arguments passed to the command.
+	Args []string `yaml:"args"`
+
+	// Env is optional and represents additional environment variables in the "KEY=value" form.
+	Env []string `yaml:"env"`
+}
+
+// TokenProvider is implemented by custom sources of Keystone tokens, e.g. Vault or a shared token cache.
+type TokenProvider interface {
+	// Token returns a valid Keystone token. An error is returned to the caller of a Service method
+	// as iamerrors.ErrAuthTokenProviderFailed.
+	Token(ctx context.Context) (string, error)
+
+	// Invalidate is called when the IAM API rejects the token returned by Token.
+	// The next call of Token is expected to return a new token, the request is repeated once with it.
+	Invalidate()
+}
+
+// RetryPolicy describes how requests failed because of a transient error are retried.
+//
+// Requests are retried on connection errors and on 429, 502, 503 and 504 responses
+// with an exponential backoff with jitter. If the response has a Retry-After header,
+// its value is used as the delay instead.
+//
+// GET, HEAD, PUT and DELETE requests are retried by default, POST and PATCH requests are retried
+// only if RetryNonIdempotent is set. The number of attempts is available in iamerrors.Error.Attempts.
+type RetryPolicy struct {
+	// MaxAttempts represents the maximum number of attempts, including the first one.
+	// Values less than 2 disable retries.
+	MaxAttempts int
+
+	// MinBackoff is optional and represents the delay before the first retry.
+	// It doubles with every next retry.
+	MinBackoff time.Duration
+
+	// MaxBackoff is optional and represents the upper bound of the delay between retries.
+	MaxBackoff time.Duration
+
+	// RetryNonIdempotent enables retries of POST and PATCH requests.
+	// Enable it only if repeating of a creation or an update is safe for you.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns the recommended RetryPolicy: up to 3 attempts of idempotent requests.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: defaultRetryMaxAttempts,
+		MinBackoff:  defaultRetryMinBackoff,
+		MaxBackoff:  defaultRetryMaxBackoff,
+	}
+}
+
+// RateLimit describes client-side limits of requests to the IAM API.
+// The limits are shared by all services of the Client.
+type RateLimit struct {
+	// RequestsPerSecond represents the average rate of requests. Zero disables the rate limit.
+	RequestsPerSecond float64
+
+	// Burst represents the number of requests, which can be sent at once above the average rate.
+	// Values less than 1 are treated as 1.
+	Burst int
+
+	// MaxInFlight represents the maximum number of requests sent concurrently. Zero disables the limit.
+	// A request is in flight until its response headers are received, so requests made while a response
+	// is streamed, e.g. in a ForEach callback, don't wait for it.
+	MaxInFlight int
 }
 
 type Option func(*Client)
@@ -95,6 +248,117 @@ func WithAuthOpts(authOpts *AuthOpts) Option {
 	}
 }
 
+// WithTokenProvider is a functional parameter for Client, used to set a custom source of Keystone tokens.
+//
+// It can't be used together with WithAuthOpts.
+func WithTokenProvider(provider TokenProvider) Option {
+	return func(c *Client) {
+		c.tokenProvider = provider
+	}
+}
+
+// WithRetryPolicy is a functional parameter for Client, used to retry requests failed because of
+// a transient error. Requests aren't retried by default.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.baseClient.RetryPolicy = baseclient.RetryPolicy{
+			MaxAttempts:        policy.MaxAttempts,
+			MinBackoff:         policy.MinBackoff,
+			MaxBackoff:         policy.MaxBackoff,
+			RetryNonIdempotent: policy.RetryNonIdempotent,
+		}
+	}
+}
+
+// WithRateLimit is a functional parameter for Client, used to limit the rate and the concurrency of requests
+// to avoid API throttling. Every attempt of a retried request is limited as well.
+//
+// Requests wait for their turn until the context passed to a Service method is done.
+func WithRateLimit(limit RateLimit) Option {
+	return func(c *Client) {
+		c.baseClient.RateLimiter = baseclient.NewRateLimiter(limit.RequestsPerSecond, limit.Burst, limit.MaxInFlight)
+	}
+}
+
+// WithMiddleware is a functional parameter for Client, used to intercept every attempt of requests
+// to the IAM API, e.g. for logging, metrics or injecting custom headers.
+//
+// Middlewares are called in the order they were passed, the first one being the outermost.
+// The option can be used multiple times, each use appends middlewares to the chain.
+func WithMiddleware(middlewares ...iammiddleware.Middleware) Option {
+	return func(c *Client) {
+		c.baseClient.Middlewares = append(c.baseClient.Middlewares, middlewares...)
+	}
+}
+
+// WithTracerProvider is a functional parameter for Client, used to enable OpenTelemetry tracing.
+//
+// Every call of a Service method produces a client span named after the operation, e.g. "users.AssignRoles",
+// with attributes of the HTTP method, the path template, the status code, the IAM error code and resource IDs.
+// Trace context headers are added to requests, see WithTextMapPropagator.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *Client) {
+		c.baseClient.Tracer = provider.Tracer(
+			modulePath,
+			trace.WithInstrumentationVersion(findModuleVersion()),
+		)
+	}
+}
+
+// WithTextMapPropagator is a functional parameter for Client, used to set a custom propagator
+// of trace context headers. By default, W3C Trace Context and Baggage are propagated.
+//
+// It takes effect only together with WithTracerProvider.
+func WithTextMapPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *Client) {
+		c.baseClient.Propagator = propagator
+	}
+}
+
+// WithMetrics is a functional parameter for Client, used to collect metrics of calls to the IAM API.
+// See iammetrics/prometheus for the Prometheus implementation of iammetrics.Recorder.
+func WithMetrics(recorder iammetrics.Recorder) Option {
+	return func(c *Client) {
+		c.baseClient.Metrics = recorder
+	}
+}
+
+// WithLogger is a functional parameter for Client, used to log calls to the IAM API.
+//
+// Every call is logged at debug level with the operation, the method, the path, the status code, the duration
+// and the request ID. Failed calls are logged at warn level. Tokens and other secrets are never logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.baseClient.Logger = logger
+	}
+}
+
+// WithBodyLogging is a functional parameter for Client, used to add request and response bodies
+// to debug logs of WithLogger. Passwords and secret keys in bodies are redacted.
+func WithBodyLogging() Option {
+	return func(c *Client) {
+		c.baseClient.LogBodies = true
+	}
+}
+
+// WithMaxResponseSize is a functional parameter for Client, used to limit the size of IAM API responses.
+// Methods return iamerrors.ErrResponseTooLarge for responses, which exceed the limit.
+func WithMaxResponseSize(bytes int64) Option {
+	return func(c *Client) {
+		c.baseClient.MaxResponseSize = bytes
+	}
+}
+
+// WithDryRun is a functional parameter for Client, used to record mutating calls (e.g. Create, Delete
+// or AssignRoles) into the plan instead of sending them. Arguments of the calls are validated as usual,
+// reads are sent to the IAM API. Planned calls return empty responses.
+// Use iamrequest.WithDryRun to plan a single call.
+func WithDryRun(plan *iamrequest.Plan) Option {
+	return func(c *Client) {
+		c.baseClient.DryRun = plan
+	}
+}
+
 // WithUserAgentPrefix is a functional parameter for Client, used to set a custom prefix.
 //
 // It is highly recommended to use this option!
@@ -112,10 +376,6 @@ func New(opts ...Option) (*Client, error) {
 		opt(c)
 	}
 
-	if !c.validateAndSetAuthMethod() {
-		return nil, iamerrors.Error{Err: iamerrors.ErrClientNoAuthOpts, Desc: "No AuthOpts was passed"}
-	}
-
 	if c.baseClient.APIUrl == "" {
 		c.baseClient.APIUrl = defaultIAMApiURL
 	}
@@ -127,6 +387,10 @@ func New(opts ...Option) (*Client, error) {
 		}
 	}
 
+	if err := c.validateAndSetAuthMethod(); err != nil {
+		return nil, err
+	}
+
 	appVersion := findModuleVersion()
 	userAgent := appName + "/" + appVersion
 	if c.baseClient.UserAgentPrefix == "" {
@@ -145,17 +409,76 @@ func New(opts ...Option) (*Client, error) {
 	return c, nil
 }
 
-func (c *Client) validateAndSetAuthMethod() bool {
+func (c *Client) validateAndSetAuthMethod() error {
+	if c.tokenProvider != nil {
+		if c.authOpts != nil {
+			return iamerrors.Error{
+				Err:  iamerrors.ErrClientConflictingAuthOpts,
+				Desc: "Both AuthOpts and TokenProvider were passed",
+			}
+		}
+		c.baseClient.AuthMethod = c.tokenProvider
+		return nil
+	}
 	if c.authOpts == nil {
-		return false
+		return iamerrors.Error{Err: iamerrors.ErrClientNoAuthOpts, Desc: "No AuthOpts was passed"}
+	}
+
+	authMethod, err := c.authOpts.newAuthMethod(c.baseClient.HTTPClient)
+	if err != nil {
+		return err
 	}
-	if c.authOpts.KeystoneToken != "" {
-		c.baseClient.AuthMethod = &baseclient.KeystoneTokenAuth{
-			KeystoneToken: c.authOpts.KeystoneToken,
+	c.baseClient.AuthMethod = authMethod
+
+	return nil
+}
+
+func (o *AuthOpts) newAuthMethod(httpClient *http.Client) (baseclient.AuthMethod, error) {
+	if o.countAuthMethods() > 1 {
+		return nil, iamerrors.Error{
+			Err:  iamerrors.ErrClientConflictingAuthOpts,
+			Desc: "Only one of KeystoneToken, KeystoneTokenFile, UserName and Exec can be set in AuthOpts",
+		}
+	}
+
+	switch {
+	case o.KeystoneToken != "":
+		return &baseclient.KeystoneTokenAuth{KeystoneToken: o.KeystoneToken}, nil
+	case o.KeystoneTokenFile != "":
+		return baseclient.NewFileTokenAuth(o.KeystoneTokenFile, o.KeystoneTokenFilePollInterval), nil
+	case o.UserName != "":
+		if o.Password == "" || o.AccountID == "" {
+			return nil, iamerrors.Error{
+				Err:  iamerrors.ErrClientNoAuthOpts,
+				Desc: "Password and AccountID are required to authenticate as a Service User",
+			}
 		}
-		return true
+		return baseclient.NewServiceUserAuth(
+			httpClient, o.KeystoneURL, o.UserName, o.Password, o.AccountID, o.ProjectName,
+		), nil
+	case o.Exec != nil:
+		if o.Exec.Command == "" {
+			return nil, iamerrors.Error{Err: iamerrors.ErrClientNoAuthOpts, Desc: "No Command was passed in Exec"}
+		}
+		return baseclient.NewExecTokenAuth(o.Exec.Command, o.Exec.Args, o.Exec.Env), nil
 	}
-	return false
+
+	return nil, iamerrors.Error{Err: iamerrors.ErrClientNoAuthOpts, Desc: "No AuthOpts was passed"}
+}
+
+func (o *AuthOpts) countAuthMethods() int {
+	methods := 0
+	for _, isSet := range []bool{
+		o.KeystoneToken != "",
+		o.KeystoneTokenFile != "",
+		o.UserName != "",
+		o.Exec != nil,
+	} {
+		if isSet {
+			methods++
+		}
+	}
+	return methods
 }
 
 func newHTTPTransport() *http.Transport {
@@ -168,12 +491,10 @@ func newHTTPTransport() *http.Transport {
 }
 
 func findModuleVersion() string {
-	moduleName := "github.com/selectel/" + appName
-
 	info, ok := debug.ReadBuildInfo()
 	if ok {
 		for _, dep := range info.Deps {
-			if dep.Path == moduleName {
+			if dep.Path == modulePath {
 				return dep.Version
 			}
 		}
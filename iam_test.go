@@ -1,6 +1,7 @@
 package iam
 
 import (
+	"context"
 	"net/http"
 	"testing"
 	"time"
@@ -8,21 +9,40 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/selectel/iam-go/iamerrors"
-	baseclient "github.com/selectel/iam-go/internal/client"
-	"github.com/selectel/iam-go/service/federations/saml"
-	"github.com/selectel/iam-go/service/groups"
-	"github.com/selectel/iam-go/service/roles"
-	"github.com/selectel/iam-go/service/s3credentials"
-	"github.com/selectel/iam-go/service/serviceusers"
-	"github.com/selectel/iam-go/service/users"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	baseclient "github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/federations/saml"
+	"github.com/selectel/iam-go/v2/service/groups"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/s3credentials"
+	"github.com/selectel/iam-go/v2/service/serviceusers"
+	"github.com/selectel/iam-go/v2/service/users"
 )
 
 const (
-	testToken = "test-token"
-	testURL   = "http://example.org/"
+	testToken       = "test-token"
+	testURL         = "http://example.org/"
+	testUserName    = "service-user"
+	testPassword    = "test-password"
+	testAccountID   = "123456"
+	testProjectName = "test-project"
+	testTokenFile   = "/var/run/secrets/iam/token"
+	testExecCommand = "sso-helper"
 )
 
+type staticTokenProvider struct {
+	token string
+}
+
+func (p *staticTokenProvider) Token(_ context.Context) (string, error) {
+	return p.token, nil
+}
+
+func (p *staticTokenProvider) Invalidate() {}
+
+//nolint:gochecknoglobals // This is a test fixture.
+var testProvider = &staticTokenProvider{token: testToken}
+
 //nolint:funlen // This is a test function.
 func TestNew(t *testing.T) {
 	type args struct {
@@ -151,6 +171,278 @@ func TestNew(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			name: "Test NewIAMClientV1 with Service User AuthOpts",
+			args: args{
+				opts: []Option{
+					WithAPIUrl(testURL),
+					WithAuthOpts(&AuthOpts{
+						UserName:    testUserName,
+						Password:    testPassword,
+						AccountID:   testAccountID,
+						ProjectName: testProjectName,
+					}),
+				},
+			},
+			expectedClient: func() *Client {
+				httpClient := &http.Client{
+					Timeout:   defaultHTTPTimeout * time.Second,
+					Transport: newHTTPTransport(),
+				}
+				baseClient := &baseclient.BaseClient{
+					HTTPClient: httpClient,
+					APIUrl:     testURL,
+					AuthMethod: baseclient.NewServiceUserAuth(
+						httpClient, "", testUserName, testPassword, testAccountID, testProjectName,
+					),
+					UserAgent: appName + "/" + findModuleVersion(),
+				}
+				return &Client{
+					authOpts: &AuthOpts{
+						UserName:    testUserName,
+						Password:    testPassword,
+						AccountID:   testAccountID,
+						ProjectName: testProjectName,
+					},
+					baseClient:      baseClient,
+					Users:           users.New(baseClient),
+					ServiceUsers:    serviceusers.New(baseClient),
+					Groups:          groups.New(baseClient),
+					Roles:           roles.New(baseClient),
+					S3Credentials:   s3credentials.New(baseClient),
+					SAMLFederations: saml.New(baseClient),
+				}
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Test NewIAMClientV1 with Service User AuthOpts without Password",
+			args: args{
+				opts: []Option{
+					WithAuthOpts(&AuthOpts{
+						UserName:  testUserName,
+						AccountID: testAccountID,
+					}),
+				},
+			},
+			expectedClient: nil,
+			expectedError:  iamerrors.ErrClientNoAuthOpts,
+		},
+		{
+			name: "Test NewIAMClientV1 with TokenProvider",
+			args: args{
+				opts: []Option{
+					WithAPIUrl(testURL),
+					WithTokenProvider(testProvider),
+				},
+			},
+			expectedClient: func() *Client {
+				baseClient := &baseclient.BaseClient{
+					HTTPClient: &http.Client{
+						Timeout:   defaultHTTPTimeout * time.Second,
+						Transport: newHTTPTransport(),
+					},
+					APIUrl:     testURL,
+					AuthMethod: testProvider,
+					UserAgent:  appName + "/" + findModuleVersion(),
+				}
+				return &Client{
+					tokenProvider:   testProvider,
+					baseClient:      baseClient,
+					Users:           users.New(baseClient),
+					ServiceUsers:    serviceusers.New(baseClient),
+					Groups:          groups.New(baseClient),
+					Roles:           roles.New(baseClient),
+					S3Credentials:   s3credentials.New(baseClient),
+					SAMLFederations: saml.New(baseClient),
+				}
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Test NewIAMClientV1 with both TokenProvider and AuthOpts",
+			args: args{
+				opts: []Option{
+					WithAuthOpts(&AuthOpts{
+						KeystoneToken: testToken,
+					}),
+					WithTokenProvider(testProvider),
+				},
+			},
+			expectedClient: nil,
+			expectedError:  iamerrors.ErrClientConflictingAuthOpts,
+		},
+		{
+			name: "Test NewIAMClientV1 with both KeystoneToken and KeystoneTokenFile",
+			args: args{
+				opts: []Option{
+					WithAuthOpts(&AuthOpts{
+						KeystoneToken:     testToken,
+						KeystoneTokenFile: testTokenFile,
+					}),
+				},
+			},
+			expectedClient: nil,
+			expectedError:  iamerrors.ErrClientConflictingAuthOpts,
+		},
+		{
+			name: "Test NewIAMClientV1 with KeystoneTokenFile",
+			args: args{
+				opts: []Option{
+					WithAPIUrl(testURL),
+					WithAuthOpts(&AuthOpts{
+						KeystoneTokenFile: testTokenFile,
+					}),
+				},
+			},
+			expectedClient: func() *Client {
+				baseClient := &baseclient.BaseClient{
+					HTTPClient: &http.Client{
+						Timeout:   defaultHTTPTimeout * time.Second,
+						Transport: newHTTPTransport(),
+					},
+					APIUrl:     testURL,
+					AuthMethod: baseclient.NewFileTokenAuth(testTokenFile, 0),
+					UserAgent:  appName + "/" + findModuleVersion(),
+				}
+				return &Client{
+					authOpts: &AuthOpts{
+						KeystoneTokenFile: testTokenFile,
+					},
+					baseClient:      baseClient,
+					Users:           users.New(baseClient),
+					ServiceUsers:    serviceusers.New(baseClient),
+					Groups:          groups.New(baseClient),
+					Roles:           roles.New(baseClient),
+					S3Credentials:   s3credentials.New(baseClient),
+					SAMLFederations: saml.New(baseClient),
+				}
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Test NewIAMClientV1 with Exec",
+			args: args{
+				opts: []Option{
+					WithAPIUrl(testURL),
+					WithAuthOpts(&AuthOpts{
+						Exec: &ExecAuthOpts{Command: testExecCommand, Args: []string{"token"}},
+					}),
+				},
+			},
+			expectedClient: func() *Client {
+				baseClient := &baseclient.BaseClient{
+					HTTPClient: &http.Client{
+						Timeout:   defaultHTTPTimeout * time.Second,
+						Transport: newHTTPTransport(),
+					},
+					APIUrl:     testURL,
+					AuthMethod: baseclient.NewExecTokenAuth(testExecCommand, []string{"token"}, nil),
+					UserAgent:  appName + "/" + findModuleVersion(),
+				}
+				return &Client{
+					authOpts: &AuthOpts{
+						Exec: &ExecAuthOpts{Command: testExecCommand, Args: []string{"token"}},
+					},
+					baseClient:      baseClient,
+					Users:           users.New(baseClient),
+					ServiceUsers:    serviceusers.New(baseClient),
+					Groups:          groups.New(baseClient),
+					Roles:           roles.New(baseClient),
+					S3Credentials:   s3credentials.New(baseClient),
+					SAMLFederations: saml.New(baseClient),
+				}
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Test NewIAMClientV1 with Exec without Command",
+			args: args{
+				opts: []Option{
+					WithAuthOpts(&AuthOpts{
+						Exec: &ExecAuthOpts{},
+					}),
+				},
+			},
+			expectedClient: nil,
+			expectedError:  iamerrors.ErrClientNoAuthOpts,
+		},
+		{
+			name: "Test NewIAMClientV1 with RetryPolicy",
+			args: args{
+				opts: []Option{
+					WithAuthOpts(&AuthOpts{
+						KeystoneToken: testToken,
+					}),
+					WithRetryPolicy(DefaultRetryPolicy()),
+				},
+			},
+			expectedClient: func() *Client {
+				baseClient := &baseclient.BaseClient{
+					HTTPClient: &http.Client{
+						Timeout:   defaultHTTPTimeout * time.Second,
+						Transport: newHTTPTransport(),
+					},
+					APIUrl:     defaultIAMApiURL,
+					AuthMethod: &baseclient.KeystoneTokenAuth{KeystoneToken: testToken},
+					UserAgent:  appName + "/" + findModuleVersion(),
+					RetryPolicy: baseclient.RetryPolicy{
+						MaxAttempts: defaultRetryMaxAttempts,
+						MinBackoff:  defaultRetryMinBackoff,
+						MaxBackoff:  defaultRetryMaxBackoff,
+					},
+				}
+				return &Client{
+					authOpts: &AuthOpts{
+						KeystoneToken: testToken,
+					},
+					baseClient:      baseClient,
+					Users:           users.New(baseClient),
+					ServiceUsers:    serviceusers.New(baseClient),
+					Groups:          groups.New(baseClient),
+					Roles:           roles.New(baseClient),
+					S3Credentials:   s3credentials.New(baseClient),
+					SAMLFederations: saml.New(baseClient),
+				}
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Test NewIAMClientV1 with RateLimit",
+			args: args{
+				opts: []Option{
+					WithAuthOpts(&AuthOpts{
+						KeystoneToken: testToken,
+					}),
+					WithRateLimit(RateLimit{RequestsPerSecond: 10, Burst: 5}),
+				},
+			},
+			expectedClient: func() *Client {
+				baseClient := &baseclient.BaseClient{
+					HTTPClient: &http.Client{
+						Timeout:   defaultHTTPTimeout * time.Second,
+						Transport: newHTTPTransport(),
+					},
+					APIUrl:      defaultIAMApiURL,
+					AuthMethod:  &baseclient.KeystoneTokenAuth{KeystoneToken: testToken},
+					UserAgent:   appName + "/" + findModuleVersion(),
+					RateLimiter: baseclient.NewRateLimiter(10, 5, 0),
+				}
+				return &Client{
+					authOpts: &AuthOpts{
+						KeystoneToken: testToken,
+					},
+					baseClient:      baseClient,
+					Users:           users.New(baseClient),
+					ServiceUsers:    serviceusers.New(baseClient),
+					Groups:          groups.New(baseClient),
+					Roles:           roles.New(baseClient),
+					S3Credentials:   s3credentials.New(baseClient),
+					SAMLFederations: saml.New(baseClient),
+				}
+			},
+			expectedError: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,310 @@
+// Package iamcassette provides an http.RoundTripper, which records interactions with the Selectel IAM API
+// to a cassette file and replays them in tests without network access.
+//
+// Record a session once against the real IAM API:
+//
+//	cassette, err := iamcassette.New("testdata/provisioning.yaml", iamcassette.ModeRecord)
+//	...
+//	iamClient, err := iam.New(iam.WithCustomHTTPClient(cassette.Client()), ...)
+//	...
+//	err = cassette.Save()
+//
+// Then replay it in CI by creating the cassette with ModeReplay. Recorded requests are matched
+// by the HTTP method, the path template of the operation (so identifiers of resources may differ)
+// and the JSON body with normalized formatting. Tokens, passwords and secret keys of S3 Credentials
+// are replaced by "[REDACTED]" in the cassette file, also in replayed responses.
+package iamcassette
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/internal/client"
+)
+
+// ErrInteractionNotFound is returned in ModeReplay, if no unused recorded interaction matches the request.
+var ErrInteractionNotFound = errors.New("no recorded interaction matches the request")
+
+// Mode represents a mode of the Cassette.
+type Mode int
+
+const (
+	// ModeReplay responds with recorded interactions and never sends requests.
+	ModeReplay Mode = iota
+
+	// ModeRecord sends requests and records interactions, which are written to the file by Save.
+	ModeRecord
+)
+
+// sensitiveHeaders contains headers, values of which are never written to the cassette file.
+//
+//nolint:gochecknoglobals // sensitiveHeaders is a constant set.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":   true,
+	"Set-Cookie":      true,
+	"X-Auth-Token":    true,
+	"X-Subject-Token": true,
+}
+
+// Option is a functional parameter of the Cassette.
+type Option func(*Cassette)
+
+// WithTransport is a functional parameter of the Cassette, used to send requests in ModeRecord.
+// http.DefaultTransport is used by default.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Cassette) {
+		c.transport = transport
+	}
+}
+
+// Cassette is an http.RoundTripper, which records or replays interactions with the IAM API.
+// It is safe for concurrent use.
+type Cassette struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []interaction
+	used         []bool
+}
+
+// cassetteFile represents the content of the cassette file.
+type cassetteFile struct {
+	Interactions []interaction `yaml:"interactions"`
+}
+
+// interaction represents a recorded request and its response.
+type interaction struct {
+	Request  recordedRequest  `yaml:"request"`
+	Response recordedResponse `yaml:"response"`
+}
+
+type recordedRequest struct {
+	Method       string `yaml:"method"`
+	PathTemplate string `yaml:"path_template"`
+	Path         string `yaml:"path"`
+	Body         string `yaml:"body,omitempty"`
+}
+
+type recordedResponse struct {
+	StatusCode int         `yaml:"status_code"`
+	Header     http.Header `yaml:"header,omitempty"`
+	Body       string      `yaml:"body,omitempty"`
+}
+
+// New returns a Cassette stored in the file at path. In ModeReplay the file is read immediately.
+func New(path string, mode Mode, opts ...Option) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode, transport: http.DefaultTransport}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the cassette: %w", err)
+		}
+		var file cassetteFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse the cassette %s: %w", path, err)
+		}
+		c.interactions = file.Interactions
+		c.used = make([]bool, len(file.Interactions))
+	}
+
+	return c, nil
+}
+
+// Client returns an HTTP client using the Cassette, to be passed to iam.WithCustomHTTPClient.
+func (c *Cassette) Client() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+// Save writes recorded interactions to the file. It does nothing in ModeReplay.
+func (c *Cassette) Save() error {
+	if c.mode != ModeRecord {
+		return nil
+	}
+
+	c.mu.Lock()
+	data, err := yaml.Marshal(cassetteFile{Interactions: c.interactions})
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode the cassette: %w", err)
+	}
+
+	if err := os.WriteFile(c.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write the cassette: %w", err)
+	}
+	return nil
+}
+
+// Unused returns the number of recorded interactions, which were not replayed yet.
+func (c *Cassette) Unused() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	unused := 0
+	for _, used := range c.used {
+		if !used {
+			unused++
+		}
+	}
+	return unused
+}
+
+// RoundTrip implements http.RoundTripper.
+// Like any http.RoundTripper, it closes the body of the request, even on errors.
+func (c *Cassette) RoundTrip(request *http.Request) (*http.Response, error) {
+	recorded, err := newRecordedRequest(request)
+	if err != nil {
+		closeRequestBody(request)
+		return nil, err
+	}
+
+	if c.mode == ModeRecord {
+		// The transport closes the body.
+		return c.record(request, recorded)
+	}
+	closeRequestBody(request)
+	return c.replay(request, recorded)
+}
+
+func closeRequestBody(request *http.Request) {
+	if request.Body != nil {
+		request.Body.Close()
+	}
+}
+
+func (c *Cassette) record(request *http.Request, recorded recordedRequest) (*http.Response, error) {
+	response, err := c.transport.RoundTrip(request)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Errors of the transport are returned as is, like without the Cassette.
+	}
+
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response: %w", err)
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := make(http.Header, len(response.Header))
+	for name, values := range response.Header {
+		// Redacted bodies may differ in length, so ContentLength of replayed responses is set by the body.
+		if http.CanonicalHeaderKey(name) == "Content-Length" {
+			continue
+		}
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			values = []string{client.Redacted}
+		}
+		header[name] = values
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, interaction{
+		Request: recorded,
+		Response: recordedResponse{
+			StatusCode: response.StatusCode,
+			Header:     header,
+			Body:       redactBody(body),
+		},
+	})
+	return response, nil
+}
+
+func (c *Cassette) replay(request *http.Request, recorded recordedRequest) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, candidate := range c.interactions {
+		if c.used[i] || candidate.Request.Method != recorded.Method ||
+			candidate.Request.PathTemplate != recorded.PathTemplate || candidate.Request.Body != recorded.Body {
+			continue
+		}
+		c.used[i] = true
+
+		statusCode := candidate.Response.StatusCode
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+			StatusCode:    statusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        candidate.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader([]byte(candidate.Response.Body))),
+			ContentLength: int64(len(candidate.Response.Body)),
+			Request:       request,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, recorded.Method, recorded.PathTemplate)
+}
+
+// newRecordedRequest returns the request as it's written to the cassette file.
+// Requests not sent by iam-go, e.g. to Keystone, are matched by the path.
+func newRecordedRequest(request *http.Request) (recordedRequest, error) {
+	recorded := recordedRequest{Method: request.Method, Path: request.URL.Path, PathTemplate: request.URL.Path}
+	if operation, ok := iammiddleware.OperationFromContext(request.Context()); ok {
+		recorded.Path = operation.Path
+		recorded.PathTemplate = operation.PathTemplate
+	}
+
+	if request.Body == nil || request.Body == http.NoBody {
+		return recorded, nil
+	}
+	// The body is read with GetBody to keep the request intact for the transport.
+	body, err := readRequestBody(request)
+	if err != nil {
+		return recordedRequest{}, err
+	}
+	recorded.Body = redactBody(body)
+
+	return recorded, nil
+}
+
+func readRequestBody(request *http.Request) ([]byte, error) {
+	reader := request.Body
+	if request.GetBody != nil {
+		var err error
+		if reader, err = request.GetBody(); err != nil {
+			return nil, fmt.Errorf("failed to read the request: %w", err)
+		}
+	}
+
+	body, err := io.ReadAll(reader)
+	if request.GetBody != nil {
+		// The copy returned by GetBody isn't used anymore, the body of the request is closed by RoundTrip.
+		reader.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the request: %w", err)
+	}
+	if request.GetBody == nil {
+		// The consumed body is replaced with a copy, so the original one is closed here.
+		request.Body.Close()
+		request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return body, nil
+}
+
+// redactBody returns the normalized JSON body without secrets. Bodies, which are not valid JSON,
+// are returned as is.
+func redactBody(body []byte) string {
+	redacted, err := client.RedactJSON(body)
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
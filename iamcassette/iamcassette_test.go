@@ -0,0 +1,202 @@
+package iamcassette
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iam "github.com/selectel/iam-go/v2"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamtest"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/serviceusers"
+)
+
+const (
+	testPassword = "secret-password"
+	testAPIURL   = "http://iam.example.com"
+)
+
+// provision creates a Service User with S3 Credentials, like a real provisioning session.
+func provision(t *testing.T, client *iam.Client) (string, error) {
+	t.Helper()
+
+	ctx := context.Background()
+	user, err := client.ServiceUsers.Create(ctx, serviceusers.CreateRequest{
+		Name:     "robot",
+		Password: testPassword,
+		Enabled:  true,
+		Roles:    []roles.Role{{Scope: "account", RoleName: "member"}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	credential, err := client.S3Credentials.Create(ctx, user.ID, "backup", "project-id")
+	if err != nil {
+		return "", err
+	}
+	return string(credential.AccessKey), nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	server := iamtest.NewServer()
+	defer server.Close()
+
+	cassette, err := New(path, ModeRecord)
+	require.NoError(err)
+	client, err := iam.New(
+		iam.WithAuthOpts(&iam.AuthOpts{KeystoneToken: iamtest.Token}),
+		iam.WithAPIUrl(server.URL),
+		iam.WithCustomHTTPClient(cassette.Client()),
+	)
+	require.NoError(err)
+
+	recordedAccessKey, err := provision(t, client)
+	require.NoError(err)
+	require.NoError(cassette.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(err)
+	assert.NotContains(string(data), testPassword)
+	assert.NotContains(string(data), iamtest.Token)
+	assert.Contains(string(data), "path_template: iam/v1/service_users/{user_id}/credentials")
+	assert.Contains(string(data), `"secret_key":"[REDACTED]"`)
+
+	// Replay with a different API URL and token, since neither is matched.
+	cassette, err = New(path, ModeReplay)
+	require.NoError(err)
+	client, err = iam.New(
+		iam.WithAuthOpts(&iam.AuthOpts{KeystoneToken: "another-token"}),
+		iam.WithAPIUrl(testAPIURL),
+		iam.WithCustomHTTPClient(cassette.Client()),
+	)
+	require.NoError(err)
+
+	replayedAccessKey, err := provision(t, client)
+	require.NoError(err)
+	assert.Equal(recordedAccessKey, replayedAccessKey)
+	assert.Zero(cassette.Unused())
+
+	_, err = provision(t, client)
+	require.ErrorIs(err, ErrInteractionNotFound)
+}
+
+func TestReplayRecordedErrors(t *testing.T) {
+	require := require.New(t)
+
+	cassette := newTestCassette(t, `interactions:
+  - request:
+      method: GET
+      path_template: iam/v1/users/{user_id}
+      path: iam/v1/users/123
+    response:
+      status_code: 404
+      header:
+        Content-Type: [application/json]
+      body: '{"code":"USER_NOT_FOUND","message":"User not found"}'
+`)
+	client, err := iam.New(
+		iam.WithAuthOpts(&iam.AuthOpts{KeystoneToken: iamtest.Token}),
+		iam.WithAPIUrl(testAPIURL),
+		iam.WithCustomHTTPClient(cassette.Client()),
+	)
+	require.NoError(err)
+
+	_, err = client.Users.Get(context.Background(), "456")
+
+	require.ErrorIs(err, iamerrors.ErrUserNotFound)
+}
+
+func TestReplayMatchesNormalizedBody(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expectedError error
+	}{
+		{
+			name: "Test Replay with the same body",
+			body: `{"name":"robot","password":"[REDACTED]"}`,
+		},
+		{
+			name: "Test Replay with reordered fields and another password",
+			body: `{ "password": "another", "name": "robot" }`,
+		},
+		{
+			name:          "Test Replay with another body",
+			body:          `{"name":"another"}`,
+			expectedError: ErrInteractionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cassette := newTestCassette(t, `interactions:
+  - request:
+      method: POST
+      path_template: /v1/users
+      path: /v1/users
+      body: '{"name":"robot","password":"[REDACTED]"}'
+    response:
+      status_code: 201
+      body: '{"id":"123"}'
+`)
+			body := &closeTrackingBody{Reader: strings.NewReader(tt.body)}
+			request, err := http.NewRequest(http.MethodPost, testAPIURL+"/v1/users", body)
+			require.NoError(err)
+
+			response, err := cassette.RoundTrip(request)
+
+			assert.True(body.closed, "the body of the request is closed")
+			if tt.expectedError != nil {
+				require.ErrorIs(err, tt.expectedError)
+				return
+			}
+			require.NoError(err)
+			defer response.Body.Close()
+			assert.Equal(http.StatusCreated, response.StatusCode)
+		})
+	}
+}
+
+func TestNewWithoutCassette(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.yaml"), ModeReplay)
+
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// closeTrackingBody is a body of a request, which records whether it's closed.
+type closeTrackingBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *closeTrackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func newTestCassette(t *testing.T, content string) *Cassette {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	cassette, err := New(path, ModeReplay)
+	require.NoError(t, err)
+
+	return cassette
+}
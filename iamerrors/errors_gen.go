@@ -0,0 +1,160 @@
+// Code generated by errorsgen from catalog.yaml. DO NOT EDIT.
+
+package iamerrors
+
+import "errors"
+
+var (
+	// ErrClientNoAuthOpts means that no authentication method was provided to the Client.
+	ErrClientNoAuthOpts = errors.New("CLIENT_NO_AUTH_METHOD")
+	// ErrClientConflictingAuthOpts means that more than one authentication method was provided to the Client.
+	ErrClientConflictingAuthOpts = errors.New("CLIENT_CONFLICTING_AUTH_METHODS")
+	// ErrClientConfigInvalid means that environment variables or the config file contain an invalid value.
+	ErrClientConfigInvalid = errors.New("CLIENT_CONFIG_INVALID")
+	// ErrClientConfigProfileNotFound means that the requested profile is missing in the config file.
+	ErrClientConfigProfileNotFound = errors.New("CLIENT_CONFIG_PROFILE_NOT_FOUND")
+	// ErrAuthTokenUnathorized means that the token was rejected by the IAM API or Keystone.
+	ErrAuthTokenUnathorized = errors.New("AUTH_TOKEN_UNAUTHORIZED")
+	// ErrAuthTokenProviderFailed means that the authentication method failed to obtain a token.
+	ErrAuthTokenProviderFailed = errors.New("AUTH_TOKEN_PROVIDER_FAILED")
+
+	// ErrUserNotFound means that the User doesn't exist.
+	ErrUserNotFound = errors.New("USER_NOT_FOUND")
+	// ErrDomainNotFound means that the account doesn't exist.
+	ErrDomainNotFound = errors.New("DOMAIN_NOT_FOUND")
+	// ErrProjectNotFound means that the project doesn't exist.
+	ErrProjectNotFound = errors.New("PROJECT_NOT_FOUND")
+	// ErrUserAlreadyExists means that a User with the same email or name already exists.
+	ErrUserAlreadyExists = errors.New("USER_ALREADY_EXISTS")
+	// ErrRequestValidationError means that the request contains invalid fields, see Error.Fields.
+	ErrRequestValidationError = errors.New("REQUEST_VALIDATION_FAILED")
+	// ErrForbidden means that the token has no rights to perform the request.
+	ErrForbidden = errors.New("REQUEST_FORBIDDEN")
+	// ErrUnauthorized means that the User is not authorized.
+	ErrUnauthorized = errors.New("USER_UNAUTHORIZED")
+	// ErrInternalServerError means that the IAM API failed to process the request.
+	ErrInternalServerError = errors.New("INTERNAL_SERVER_ERROR")
+	// ErrTooManyRequests means that the IAM API is throttling requests.
+	ErrTooManyRequests = errors.New("TOO_MANY_REQUESTS")
+	// ErrCredentialNotFound means that the S3 Credentials don't exist.
+	ErrCredentialNotFound = errors.New("CRED_NOT_FOUND")
+
+	// ErrUserIDRequired means that no userID was provided.
+	ErrUserIDRequired = errors.New("USER_ID_REQUIRED")
+	// ErrProjectIDRequired means that no projectID was provided.
+	ErrProjectIDRequired = errors.New("PROJECT_ID_REQUIRED")
+	// ErrGroupIDRequired means that no groupID was provided.
+	ErrGroupIDRequired = errors.New("GROUP_ID_REQUIRED")
+
+	// ErrGroupNameRequired means that no name of the Group was provided.
+	ErrGroupNameRequired = errors.New("GROUP_NAME_REQUIRED")
+	// ErrGroupRolesRequired means that no roles of the Group were provided.
+	ErrGroupRolesRequired = errors.New("GROUP_ROLES_REQUIRED")
+	// ErrGroupUserIDsRequired means that no users of the Group were provided.
+	ErrGroupUserIDsRequired = errors.New("GROUP_USER_IDS_REQUIRED")
+	// ErrGroupAlreadyExists means that a Group with the same name already exists.
+	ErrGroupAlreadyExists = errors.New("GROUP_ALREADY_EXISTS")
+	// ErrGroupNotFound means that the Group doesn't exist.
+	ErrGroupNotFound = errors.New("GROUP_NOT_FOUND")
+	// ErrUserOrGroupNotFound means that the User or the Group doesn't exist.
+	ErrUserOrGroupNotFound = errors.New("USER_OR_GROUP_NOT_FOUND")
+	// ErrPrincipalNotFound means that no User or Service User matches the reference to a member of the Group.
+	ErrPrincipalNotFound = errors.New("PRINCIPAL_NOT_FOUND")
+	// ErrPrincipalAmbiguous means that several Users or Service Users match the reference to a member of the Group.
+	ErrPrincipalAmbiguous = errors.New("PRINCIPAL_AMBIGUOUS")
+
+	// ErrFederationNameRequired means that no name of the Federation was provided.
+	ErrFederationNameRequired = errors.New("FEDERATION_NAME_REQUIRED")
+	// ErrFederationIDRequired means that no federationID was provided.
+	ErrFederationIDRequired = errors.New("FEDERATION_ID_REQUIRED")
+	// ErrFederationIssuerRequired means that no issuer of the Federation was provided.
+	ErrFederationIssuerRequired = errors.New("FEDERATION_ISSUER_REQUIRED")
+	// ErrFederationSSOURLRequired means that no SSO URL of the Federation was provided.
+	ErrFederationSSOURLRequired = errors.New("FEDERATION_SSO_URL_REQUIRED")
+	// ErrFederationCertificateIDRequired means that no certificateID was provided.
+	ErrFederationCertificateIDRequired = errors.New("FEDERATION_CERTIFICATE_ID_REQUIRED")
+	// ErrFederationCertificateNotFound means that the certificate of the Federation doesn't exist.
+	ErrFederationCertificateNotFound = errors.New("FEDERATION_CERTIFICATE_NOT_FOUND")
+	// ErrFederationMaxAgeHoursRequired means that no session max age of the Federation was provided.
+	ErrFederationMaxAgeHoursRequired = errors.New("FEDERATION_MAX_AGE_HOURS_REQUIRED")
+	// ErrFederationNotFound means that the Federation doesn't exist.
+	ErrFederationNotFound = errors.New("FEDERATION_NOT_FOUND")
+
+	// ErrCredentialNameRequired means that no name of the S3 Credentials was provided.
+	ErrCredentialNameRequired = errors.New("CREDENTIAL_NAME_REQUIRED")
+	// ErrCredentialAccessKeyRequired means that no access key of the S3 Credentials was provided.
+	ErrCredentialAccessKeyRequired = errors.New("CREDENTIAL_ACCESS_KEY_REQUIRED")
+
+	// ErrServiceUserNameRequired means that no name of the Service User was provided.
+	ErrServiceUserNameRequired = errors.New("SERVICE_USER_NAME_REQUIRED")
+	// ErrServiceUserPasswordRequired means that no password of the Service User was provided.
+	ErrServiceUserPasswordRequired = errors.New("SERVICE_USER_PASSWORD_REQUIRED")
+	// ErrServiceUserRolesRequired means that no roles of the Service User were provided.
+	ErrServiceUserRolesRequired = errors.New("SERVICE_USER_ROLES_REQUIRED")
+
+	// ErrUserRolesRequired means that no roles of the User were provided.
+	ErrUserRolesRequired = errors.New("USER_ROLES_REQUIRED")
+	// ErrUserEmailRequired means that no email of the User was provided.
+	ErrUserEmailRequired = errors.New("USER_EMAIL_REQUIRED")
+
+	// ErrInputDataRequired means that required input data was not provided.
+	ErrInputDataRequired = errors.New("INPUT_DATA_REQUIRED")
+	// ErrInternalAppError means that iam-go failed to send the request or to process the response.
+	ErrInternalAppError = errors.New("INTERNAL_APP_ERROR")
+	// ErrResponseTooLarge means that the response of the IAM API exceeds the limit set by WithMaxResponseSize.
+	ErrResponseTooLarge = errors.New("RESPONSE_TOO_LARGE")
+	// ErrUnknown means that the IAM API returned an error, which is unknown to iam-go. Error.Code contains the exact code.
+	ErrUnknown = errors.New("UNKNOWN_ERROR")
+)
+
+// catalogErrors contains all errors of the catalog.
+//
+//nolint:gochecknoglobals // catalogErrors is not global.
+var catalogErrors = []error{
+	ErrClientNoAuthOpts,
+	ErrClientConflictingAuthOpts,
+	ErrClientConfigInvalid,
+	ErrClientConfigProfileNotFound,
+	ErrAuthTokenUnathorized,
+	ErrAuthTokenProviderFailed,
+	ErrUserNotFound,
+	ErrDomainNotFound,
+	ErrProjectNotFound,
+	ErrUserAlreadyExists,
+	ErrRequestValidationError,
+	ErrForbidden,
+	ErrUnauthorized,
+	ErrInternalServerError,
+	ErrTooManyRequests,
+	ErrCredentialNotFound,
+	ErrUserIDRequired,
+	ErrProjectIDRequired,
+	ErrGroupIDRequired,
+	ErrGroupNameRequired,
+	ErrGroupRolesRequired,
+	ErrGroupUserIDsRequired,
+	ErrGroupAlreadyExists,
+	ErrGroupNotFound,
+	ErrUserOrGroupNotFound,
+	ErrPrincipalNotFound,
+	ErrPrincipalAmbiguous,
+	ErrFederationNameRequired,
+	ErrFederationIDRequired,
+	ErrFederationIssuerRequired,
+	ErrFederationSSOURLRequired,
+	ErrFederationCertificateIDRequired,
+	ErrFederationCertificateNotFound,
+	ErrFederationMaxAgeHoursRequired,
+	ErrFederationNotFound,
+	ErrCredentialNameRequired,
+	ErrCredentialAccessKeyRequired,
+	ErrServiceUserNameRequired,
+	ErrServiceUserPasswordRequired,
+	ErrServiceUserRolesRequired,
+	ErrUserRolesRequired,
+	ErrUserEmailRequired,
+	ErrInputDataRequired,
+	ErrInternalAppError,
+	ErrResponseTooLarge,
+	ErrUnknown,
+}
@@ -3,113 +3,121 @@ package iamerrors
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/selectel/iam-go/v2/internal/transient"
 )
 
-var (
-	ErrClientNoAuthOpts     = errors.New("CLIENT_NO_AUTH_METHOD")
-	ErrAuthTokenUnathorized = errors.New("AUTH_TOKEN_UNAUTHORIZED")
-
-	ErrUserNotFound           = errors.New("USER_NOT_FOUND")
-	ErrDomainNotFound         = errors.New("DOMAIN_NOT_FOUND")
-	ErrProjectNotFound        = errors.New("PROJECT_NOT_FOUND")
-	ErrUserAlreadyExists      = errors.New("USER_ALREADY_EXISTS")
-	ErrRequestValidationError = errors.New("REQUEST_VALIDATION_FAILED")
-	ErrForbidden              = errors.New("REQUEST_FORBIDDEN")
-	ErrUnauthorized           = errors.New("USER_UNAUTHORIZED")
-	ErrInternalServerError    = errors.New("INTERNAL_SERVER_ERROR")
-	ErrCredentialNotFound     = errors.New("CRED_NOT_FOUND")
-
-	ErrUserIDRequired    = errors.New("USER_ID_REQUIRED")
-	ErrProjectIDRequired = errors.New("PROJECT_ID_REQUIRED")
-	ErrGroupIDRequired   = errors.New("GROUP_ID_REQUIRED")
-
-	ErrGroupNameRequired    = errors.New("GROUP_NAME_REQUIRED")
-	ErrGroupRolesRequired   = errors.New("GROUP_ROLES_REQUIRED")
-	ErrGroupUserIDsRequired = errors.New("GROUP_USER_IDS_REQUIRED")
-	ErrGroupAlreadyExists   = errors.New("GROUP_ALREADY_EXISTS")
-	ErrGroupNotFound        = errors.New("GROUP_NOT_FOUND")
-	ErrUserOrGroupNotFound  = errors.New("USER_OR_GROUP_NOT_FOUND")
-
-	ErrFederationNameRequired          = errors.New("FEDERATION_NAME_REQUIRED")
-	ErrFederationIDRequired            = errors.New("FEDERATION_ID_REQUIRED")
-	ErrFederationIssuerRequired        = errors.New("FEDERATION_ISSUER_REQUIRED")
-	ErrFederationSSOURLRequired        = errors.New("FEDERATION_SSO_URL_REQUIRED")
-	ErrFederationCertificateIDRequired = errors.New("FEDERATION_CERTIFICATE_ID_REQUIRED")
-	ErrFederationCertificateNotFound   = errors.New("FEDERATION_CERTIFICATE_NOT_FOUND")
-	ErrFederationMaxAgeHoursRequired   = errors.New("FEDERATION_MAX_AGE_HOURS_REQUIRED")
-	ErrFederationNotFound              = errors.New("FEDERATION_NOT_FOUND")
-
-	ErrCredentialNameRequired      = errors.New("CREDENTIAL_NAME_REQUIRED")
-	ErrCredentialAccessKeyRequired = errors.New("CREDENTIAL_ACCESS_KEY_REQUIRED")
-
-	ErrServiceUserNameRequired     = errors.New("SERVICE_USER_NAME_REQUIRED")
-	ErrServiceUserPasswordRequired = errors.New("SERVICE_USER_PASSWORD_REQUIRED")
-	ErrServiceUserRolesRequired    = errors.New("SERVICE_USER_ROLES_REQUIRED")
-
-	ErrUserRolesRequired = errors.New("USER_ROLES_REQUIRED")
-	ErrUserEmailRequired = errors.New("USER_EMAIL_REQUIRED")
-
-	ErrInputDataRequired = errors.New("INPUT_DATA_REQUIRED")
-
-	ErrInternalAppError = errors.New("INTERNAL_APP_ERROR")
-
-	ErrUnknown = errors.New("UNKNOWN_ERROR")
-
-	//nolint:gochecknoglobals // stringToError is not global.
-	stringToError = map[string]error{
-		ErrUserNotFound.Error():                    ErrUserNotFound,
-		ErrClientNoAuthOpts.Error():                ErrClientNoAuthOpts,
-		ErrAuthTokenUnathorized.Error():            ErrAuthTokenUnathorized,
-		ErrDomainNotFound.Error():                  ErrDomainNotFound,
-		ErrCredentialNotFound.Error():              ErrCredentialNotFound,
-		ErrProjectNotFound.Error():                 ErrProjectNotFound,
-		ErrUserAlreadyExists.Error():               ErrUserAlreadyExists,
-		ErrRequestValidationError.Error():          ErrRequestValidationError,
-		ErrForbidden.Error():                       ErrForbidden,
-		ErrUnauthorized.Error():                    ErrUnauthorized,
-		ErrInternalServerError.Error():             ErrInternalServerError,
-		ErrCredentialNameRequired.Error():          ErrCredentialNameRequired,
-		ErrCredentialAccessKeyRequired.Error():     ErrCredentialAccessKeyRequired,
-		ErrUserIDRequired.Error():                  ErrUserIDRequired,
-		ErrProjectIDRequired.Error():               ErrProjectIDRequired,
-		ErrGroupIDRequired.Error():                 ErrGroupIDRequired,
-		ErrGroupUserIDsRequired.Error():            ErrGroupUserIDsRequired,
-		ErrGroupNameRequired.Error():               ErrGroupNameRequired,
-		ErrGroupRolesRequired.Error():              ErrGroupRolesRequired,
-		ErrGroupAlreadyExists.Error():              ErrGroupAlreadyExists,
-		ErrGroupNotFound.Error():                   ErrGroupNotFound,
-		ErrFederationNameRequired.Error():          ErrFederationNameRequired,
-		ErrFederationIDRequired.Error():            ErrFederationIDRequired,
-		ErrFederationIssuerRequired.Error():        ErrFederationIssuerRequired,
-		ErrFederationSSOURLRequired.Error():        ErrFederationSSOURLRequired,
-		ErrFederationCertificateIDRequired.Error(): ErrFederationCertificateIDRequired,
-		ErrFederationCertificateNotFound.Error():   ErrFederationCertificateNotFound,
-		ErrFederationNotFound.Error():              ErrFederationNotFound,
-		ErrFederationMaxAgeHoursRequired.Error():   ErrFederationMaxAgeHoursRequired,
-		ErrUserOrGroupNotFound.Error():             ErrUserOrGroupNotFound,
-		ErrServiceUserNameRequired.Error():         ErrServiceUserNameRequired,
-		ErrServiceUserPasswordRequired.Error():     ErrServiceUserPasswordRequired,
-		ErrServiceUserRolesRequired.Error():        ErrServiceUserRolesRequired,
-		ErrUserRolesRequired.Error():               ErrUserRolesRequired,
-		ErrUserEmailRequired.Error():               ErrUserEmailRequired,
-		ErrInputDataRequired.Error():               ErrInputDataRequired,
-		ErrInternalAppError.Error():                ErrInternalAppError,
-		ErrUnknown.Error():                         ErrUnknown,
+//go:generate go run ../internal/cmd/errorsgen -catalog catalog.yaml -out errors_gen.go -docs ../docs/errors.md
+
+//nolint:gochecknoglobals // Registered codes must be shared by all clients, the registry is guarded by a mutex.
+var registry = newCodeRegistry(catalogErrors)
+
+// codeRegistry maps error codes of the IAM API to sentinel errors.
+type codeRegistry struct {
+	mu    sync.RWMutex
+	codes map[string]error
+}
+
+func newCodeRegistry(errs []error) *codeRegistry {
+	r := &codeRegistry{codes: make(map[string]error, len(errs))}
+	for _, err := range errs {
+		r.codes[err.Error()] = err
 	}
-)
+	return r
+}
 
+// GetError returns a sentinel error for the error code or nil if the code is unknown.
 func GetError(errorString string) error {
-	err, ok := stringToError[errorString]
-	if !ok {
-		return nil
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	return registry.codes[errorString]
+}
+
+// Register returns a sentinel error for the error code of the IAM API, which is unknown to iam-go,
+// so it can be matched with errors.Is without waiting for a new release of iam-go:
+//
+//	var ErrGroupNameTaken = iamerrors.Register("GROUP_NAME_TAKEN")
+//
+// If the code is already known, the existing sentinel error is returned. It is safe for concurrent use.
+func Register(code string) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if err, ok := registry.codes[code]; ok {
+		return err
 	}
+	err := errors.New(code) //nolint:goerr113 // The sentinel error is created dynamically by design.
+	registry.codes[code] = err
+
 	return err
 }
 
+// Code represents an error code returned by the IAM API, which is neither known to iam-go nor registered.
+// It is used as Error.Err, so unknown codes can be matched exactly:
+//
+//	errors.Is(err, iamerrors.Code("GROUP_NAME_TAKEN"))
+//
+// Such errors match ErrUnknown as well.
+type Code string
+
+func (c Code) Error() string {
+	return string(c)
+}
+
+// Is reports whether the target is ErrUnknown.
+func (c Code) Is(target error) bool {
+	return target == ErrUnknown //nolint:errorlint // Sentinel errors are compared by identity.
+}
+
 // Error represents an error returned by the IAM API. It contains a human-readable description of the error.
 type Error struct {
 	Err  error
 	Desc string
+
+	// Cause is optional and contains an underlying error, which was returned by a user-provided component,
+	// e.g. a TokenProvider.
+	Cause error
+
+	// Attempts represents how many times the request was sent to the IAM API.
+	// It is zero for errors, which occurred before sending the request.
+	Attempts int
+
+	// StatusCode represents an HTTP status code of the IAM API response.
+	// It is zero for errors, which occurred before any response was received.
+	StatusCode int
+
+	// Code represents an error code as returned by the IAM API, e.g. "USER_NOT_FOUND".
+	// It is set even if the code is unknown to iam-go and Err is ErrUnknown.
+	Code string
+
+	// RequestID represents an identifier of the request from the X-Request-Id header of the IAM API response.
+	// Provide it to the support when reporting problems.
+	RequestID string
+
+	// Operation represents a Service method the error was returned by, e.g. "users.AssignRoles".
+	Operation string
+
+	// Method represents an HTTP method of the request.
+	Method string
+
+	// PathTemplate represents a path of the request with placeholders instead of resource identifiers,
+	// e.g. "iam/v1/users/{user_id}/roles".
+	PathTemplate string
+
+	// Fields contains details of ErrRequestValidationError about invalid fields of the request, if any.
+	Fields []FieldError
+}
+
+// FieldError describes an invalid field of the request.
+type FieldError struct {
+	// Field represents a path to the field in the request body, e.g. "roles.0.scope".
+	Field string
+
+	// Reason describes why the value of the field is invalid.
+	Reason string
 }
 
 func (e Error) Error() string {
@@ -117,5 +125,53 @@ func (e Error) Error() string {
 }
 
 func (e Error) Is(err error) bool {
+	if code, ok := err.(Code); ok { //nolint:errorlint // Code is compared by value.
+		return e.Code != "" && e.Code == string(code)
+	}
 	return errors.Is(e.Err, err)
 }
+
+func (e Error) Unwrap() error {
+	return e.Cause
+}
+
+// IsRetryable reports whether the request may succeed if it is sent again later: the IAM API is throttling
+// or temporarily unavailable, or no response was received due to a transient network failure, e.g. a reset
+// connection or a timeout. Permanent failures, e.g. an invalid certificate, aren't retryable.
+func (e Error) IsRetryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case 0:
+		return transient.Is(e.Cause)
+	}
+	return false
+}
+
+// IsNotFound reports whether the IAM API responded with 404 Not Found.
+func (e Error) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsConflict reports whether the IAM API responded with 409 Conflict, e.g. the resource already exists.
+func (e Error) IsConflict() bool {
+	return e.StatusCode == http.StatusConflict
+}
+
+// IsRetryable reports whether err is an Error, which is retryable. See Error.IsRetryable.
+func IsRetryable(err error) bool {
+	var e Error
+	return errors.As(err, &e) && e.IsRetryable()
+}
+
+// IsNotFound reports whether err is an Error with 404 Not Found status code.
+func IsNotFound(err error) bool {
+	var e Error
+	return errors.As(err, &e) && e.IsNotFound()
+}
+
+// IsConflict reports whether err is an Error with 409 Conflict status code.
+func IsConflict(err error) bool {
+	var e Error
+	return errors.As(err, &e) && e.IsConflict()
+}
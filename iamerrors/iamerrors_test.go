@@ -0,0 +1,62 @@
+package iamerrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(GetError("TEST_REGISTERED_CODE"))
+
+	registered := Register("TEST_REGISTERED_CODE")
+
+	assert.Equal(registered, GetError("TEST_REGISTERED_CODE"))
+	assert.Equal(registered, Register("TEST_REGISTERED_CODE"))
+	assert.Equal(ErrUserNotFound, Register(ErrUserNotFound.Error()))
+}
+
+func TestErrorIs(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		matching    []error
+		notMatching []error
+	}{
+		{
+			name:        "Test known code",
+			err:         Error{Err: ErrUserNotFound, Code: "USER_NOT_FOUND"},
+			matching:    []error{ErrUserNotFound, Code("USER_NOT_FOUND")},
+			notMatching: []error{ErrUnknown, ErrGroupNotFound, Code("GROUP_NOT_FOUND")},
+		},
+		{
+			name:        "Test unknown code",
+			err:         fmt.Errorf("wrapped: %w", Error{Err: Code("GROUP_NAME_TAKEN"), Code: "GROUP_NAME_TAKEN"}),
+			matching:    []error{ErrUnknown, Code("GROUP_NAME_TAKEN")},
+			notMatching: []error{ErrGroupAlreadyExists, Code("USER_NOT_FOUND")},
+		},
+		{
+			name:        "Test client error",
+			err:         Error{Err: ErrUserIDRequired},
+			matching:    []error{ErrUserIDRequired},
+			notMatching: []error{ErrUnknown, Code("")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			for _, target := range tt.matching {
+				assert.True(errors.Is(tt.err, target), "%v should match %v", tt.err, target)
+			}
+			for _, target := range tt.notMatching {
+				assert.False(errors.Is(tt.err, target), "%v should not match %v", tt.err, target)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Package iamid provides distinct types of identifiers used by the IAM API, so the compiler catches
+// an identifier of one kind passed where another one is expected, e.g. a User ID instead of a Keystone ID.
+//
+// All types are based on string. Untyped string constants are converted implicitly,
+// while strings from other sources have to be converted explicitly:
+//
+//	user, err := iamClient.Users.Get(ctx, iamid.UserID(os.Getenv("USER_ID")))
+package iamid
+
+// UserID represents an ID of a Panel User or a Service User, e.g. users.User.ID or serviceusers.ServiceUser.ID.
+type UserID string
+
+// KeystoneID represents a Keystone ID of a Panel User, e.g. users.User.KeystoneID.
+// Groups API identifies members of Groups by Keystone IDs, see KeystoneIDOfServiceUser for Service Users.
+type KeystoneID string
+
+// GroupID represents an ID of a Group.
+type GroupID string
+
+// FederationID represents an ID of a SAML Federation.
+type FederationID string
+
+// CertificateID represents an ID of a Certificate of a SAML Federation.
+type CertificateID string
+
+// AccessKey represents an access key of S3 Credentials, which identifies them.
+type AccessKey string
+
+// ProjectID represents an ID of a project.
+type ProjectID string
+
+// String returns the ID as a string.
+func (id UserID) String() string {
+	return string(id)
+}
+
+// String returns the ID as a string.
+func (id KeystoneID) String() string {
+	return string(id)
+}
+
+// String returns the ID as a string.
+func (id GroupID) String() string {
+	return string(id)
+}
+
+// String returns the ID as a string.
+func (id FederationID) String() string {
+	return string(id)
+}
+
+// String returns the ID as a string.
+func (id CertificateID) String() string {
+	return string(id)
+}
+
+// String returns the access key as a string.
+func (key AccessKey) String() string {
+	return string(key)
+}
+
+// String returns the ID as a string.
+func (id ProjectID) String() string {
+	return string(id)
+}
+
+// KeystoneIDOfServiceUser returns the Keystone ID of a Service User. IDs of Service Users are their Keystone IDs,
+// unlike IDs of Panel Users.
+func KeystoneIDOfServiceUser(id UserID) KeystoneID {
+	return KeystoneID(id)
+}
+
+// Strings converts identifiers of any kind to strings.
+func Strings[T ~string](ids []T) []string {
+	if ids == nil {
+		return nil
+	}
+
+	result := make([]string, len(ids))
+	for i, id := range ids {
+		result[i] = string(id)
+	}
+	return result
+}
+
+// FromStrings converts strings to identifiers of the kind T, e.g. iamid.FromStrings[iamid.KeystoneID](ids).
+func FromStrings[T ~string](values []string) []T {
+	if values == nil {
+		return nil
+	}
+
+	result := make([]T, len(values))
+	for i, value := range values {
+		result[i] = T(value)
+	}
+	return result
+}
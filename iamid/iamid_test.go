@@ -0,0 +1,22 @@
+package iamid
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConversions(t *testing.T) {
+	assert := assert.New(t)
+
+	keystoneIDs := FromStrings[KeystoneID]([]string{"123", "456"})
+	assert.Equal([]KeystoneID{"123", "456"}, keystoneIDs)
+	assert.Equal([]string{"123", "456"}, Strings(keystoneIDs))
+
+	assert.Nil(Strings[GroupID](nil))
+	assert.Nil(FromStrings[GroupID](nil))
+
+	assert.Equal(KeystoneID("123"), KeystoneIDOfServiceUser(UserID("123")))
+	assert.Equal("group 123", fmt.Sprintf("group %s", GroupID("123")))
+}
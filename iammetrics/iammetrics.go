@@ -0,0 +1,37 @@
+// Package iammetrics provides a neutral interface to collect metrics of requests to the Selectel IAM API
+// made by iam-go. See the prometheus subpackage for an implementation based on the Prometheus client.
+package iammetrics
+
+import (
+	"time"
+
+	"github.com/selectel/iam-go/v2/iammiddleware"
+)
+
+// Result describes a finished call of a Service method.
+type Result struct {
+	// StatusCode represents an HTTP status code of the last response. It is zero if no response was received.
+	StatusCode int
+
+	// ErrorCode represents a code of the returned iamerrors.Error, e.g. "USER_NOT_FOUND".
+	// It is empty if the call succeeded.
+	ErrorCode string
+
+	// Attempts represents the number of attempts made, including retries.
+	Attempts int
+
+	// Duration represents the time spent on the call, including retries.
+	Duration time.Duration
+}
+
+// Recorder receives events of calls to the IAM API.
+//
+// Implementations must be safe for concurrent use. To keep cardinality bounded, use Operation.PathTemplate
+// instead of Operation.Path and avoid Operation.ResourceIDs in labels.
+type Recorder interface {
+	// RequestStarted is called before the first attempt of the call.
+	RequestStarted(operation iammiddleware.Operation)
+
+	// RequestFinished is called once the call is finished, successfully or not.
+	RequestFinished(operation iammiddleware.Operation, result Result)
+}
@@ -0,0 +1,141 @@
+// Package prometheus provides an iammetrics.Recorder, which exposes metrics of iam-go as a prometheus.Collector.
+package prometheus
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/selectel/iam-go/v2/iammetrics"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+)
+
+const defaultNamespace = "iam_go"
+
+// Opts describes the metrics of Collector.
+type Opts struct {
+	// Namespace is optional and represents a prefix of metric names. The default is "iam_go".
+	Namespace string
+
+	// ConstLabels are optional and added to all metrics.
+	ConstLabels prometheus.Labels
+
+	// DurationBuckets are optional and represent buckets of the request duration histogram.
+	// The default is prometheus.DefBuckets.
+	DurationBuckets []float64
+}
+
+// Collector records calls to the IAM API and exposes them as Prometheus metrics:
+//
+//   - requests_total counts finished calls by operation, method, path template and status code;
+//   - request_duration_seconds observes durations of calls including retries;
+//   - request_errors_total counts failed calls by the iamerrors code;
+//   - request_retries_total counts retries;
+//   - requests_in_flight shows calls, which are not finished yet.
+type Collector struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+	retries  *prometheus.CounterVec
+	inFlight *prometheus.GaugeVec
+}
+
+var (
+	_ iammetrics.Recorder  = (*Collector)(nil)
+	_ prometheus.Collector = (*Collector)(nil)
+)
+
+// NewCollector returns a new instance of Collector. It must be registered to expose the metrics.
+func NewCollector(opts Opts) *Collector {
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	buckets := opts.DurationBuckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+	labels := []string{"operation", "method", "path_template"}
+
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "requests_total",
+			Help:        "Total number of calls to the IAM API.",
+			ConstLabels: opts.ConstLabels,
+		}, append(labels, "status_code")),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "request_duration_seconds",
+			Help:        "Duration of calls to the IAM API including retries.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     buckets,
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "request_errors_total",
+			Help:        "Total number of failed calls to the IAM API by the error code.",
+			ConstLabels: opts.ConstLabels,
+		}, append(labels, "error_code")),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "request_retries_total",
+			Help:        "Total number of retried attempts of calls to the IAM API.",
+			ConstLabels: opts.ConstLabels,
+		}, labels),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "requests_in_flight",
+			Help:        "Number of calls to the IAM API, which are not finished yet.",
+			ConstLabels: opts.ConstLabels,
+		}, labels),
+	}
+}
+
+// RequestStarted implements iammetrics.Recorder.
+func (c *Collector) RequestStarted(operation iammiddleware.Operation) {
+	c.inFlight.WithLabelValues(labelValues(operation)...).Inc()
+}
+
+// RequestFinished implements iammetrics.Recorder.
+func (c *Collector) RequestFinished(operation iammiddleware.Operation, result iammetrics.Result) {
+	values := labelValues(operation)
+
+	c.inFlight.WithLabelValues(values...).Dec()
+	c.duration.WithLabelValues(values...).Observe(result.Duration.Seconds())
+
+	statusCode := ""
+	if result.StatusCode != 0 {
+		statusCode = strconv.Itoa(result.StatusCode)
+	}
+	c.requests.WithLabelValues(append(values, statusCode)...).Inc()
+
+	if result.ErrorCode != "" {
+		c.errors.WithLabelValues(append(values, result.ErrorCode)...).Inc()
+	}
+	if result.Attempts > 1 {
+		c.retries.WithLabelValues(values...).Add(float64(result.Attempts - 1))
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.duration.Describe(ch)
+	c.errors.Describe(ch)
+	c.retries.Describe(ch)
+	c.inFlight.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.duration.Collect(ch)
+	c.errors.Collect(ch)
+	c.retries.Collect(ch)
+	c.inFlight.Collect(ch)
+}
+
+func labelValues(operation iammiddleware.Operation) []string {
+	return []string{operation.String(), operation.Method, operation.PathTemplate}
+}
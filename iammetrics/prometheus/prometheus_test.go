@@ -0,0 +1,85 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iam "github.com/selectel/iam-go/v2"
+	"github.com/selectel/iam-go/v2/iamerrors"
+)
+
+const (
+	testURL   = "http://example.org/"
+	testToken = "test-token"
+
+	testUserNotFoundResponse = `{
+	"code": "USER_NOT_FOUND",
+	"message": "User not found"
+}`
+)
+
+func TestCollector(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	httpClient := &http.Client{}
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		http.MethodGet, testURL+"iam/v1/users/123",
+		httpmock.ResponderFromMultipleResponses([]*http.Response{
+			httpmock.NewStringResponse(http.StatusServiceUnavailable, ""),
+			httpmock.NewStringResponse(http.StatusOK, "{}"),
+		}),
+	)
+	httpmock.RegisterResponder(
+		http.MethodGet, testURL+"iam/v1/users/456",
+		httpmock.NewStringResponder(http.StatusNotFound, testUserNotFoundResponse),
+	)
+
+	collector := NewCollector(Opts{Namespace: "test"})
+	client, err := iam.New(
+		iam.WithAPIUrl(testURL),
+		iam.WithAuthOpts(&iam.AuthOpts{KeystoneToken: testToken}),
+		iam.WithCustomHTTPClient(httpClient),
+		iam.WithRetryPolicy(iam.RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond}),
+		iam.WithMetrics(collector),
+	)
+	require.NoError(err)
+
+	ctx := context.Background()
+	_, err = client.Users.Get(ctx, "123")
+	require.NoError(err)
+	_, err = client.Users.Get(ctx, "456")
+	require.ErrorIs(err, iamerrors.ErrUserNotFound)
+
+	//nolint:lll // Lines of the exposition format can't be wrapped.
+	expected := `
+# HELP test_request_errors_total Total number of failed calls to the IAM API by the error code.
+# TYPE test_request_errors_total counter
+test_request_errors_total{error_code="USER_NOT_FOUND",method="GET",operation="users.Get",path_template="iam/v1/users/{user_id}"} 1
+# HELP test_request_retries_total Total number of retried attempts of calls to the IAM API.
+# TYPE test_request_retries_total counter
+test_request_retries_total{method="GET",operation="users.Get",path_template="iam/v1/users/{user_id}"} 1
+# HELP test_requests_in_flight Number of calls to the IAM API, which are not finished yet.
+# TYPE test_requests_in_flight gauge
+test_requests_in_flight{method="GET",operation="users.Get",path_template="iam/v1/users/{user_id}"} 0
+# HELP test_requests_total Total number of calls to the IAM API.
+# TYPE test_requests_total counter
+test_requests_total{method="GET",operation="users.Get",path_template="iam/v1/users/{user_id}",status_code="200"} 1
+test_requests_total{method="GET",operation="users.Get",path_template="iam/v1/users/{user_id}",status_code="404"} 1
+`
+	require.NoError(testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"test_request_errors_total", "test_request_retries_total", "test_requests_in_flight", "test_requests_total",
+	))
+	assert.Equal(1, testutil.CollectAndCount(collector, "test_request_duration_seconds"))
+}
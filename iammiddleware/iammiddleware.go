@@ -0,0 +1,69 @@
+// Package iammiddleware provides types to intercept requests to the Selectel IAM API made by iam-go.
+//
+// A Middleware is called for every attempt of a request after the authentication headers are set
+// and can modify the request, observe the response or short-circuit the call by returning a response
+// (or an error) without calling the next Handler.
+package iammiddleware
+
+import (
+	"context"
+	"net/http"
+)
+
+// Operation describes a call of a Service method, e.g. users.Service.AssignRoles.
+type Operation struct {
+	// Service represents a name of the Service package, e.g. "users" or "saml".
+	Service string
+
+	// Name represents a name of the Service method, e.g. "AssignRoles".
+	Name string
+
+	// ResourceIDs contains identifiers of resources the call is made for by their names in PathTemplate,
+	// e.g. {"user_id": "123"}.
+	ResourceIDs map[string]string
+
+	// Method represents an HTTP method of the request.
+	Method string
+
+	// PathTemplate represents a path of the request with placeholders instead of resource identifiers,
+	// e.g. "iam/v1/users/{user_id}/roles". It is safe to use as a low-cardinality label.
+	PathTemplate string
+
+	// Path represents an actual path of the request relative to the IAM API URL, e.g. "iam/v1/users/123/roles".
+	Path string
+}
+
+// String returns a full name of the operation, e.g. "users.AssignRoles".
+func (o Operation) String() string {
+	return o.Service + "." + o.Name
+}
+
+// operationKey is a key of the Operation in a context.
+type operationKey struct{}
+
+// ContextWithOperation returns a copy of ctx carrying the operation.
+func ContextWithOperation(ctx context.Context, operation Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// OperationFromContext returns the Operation of a request sent by iam-go, e.g. to be used
+// by the http.RoundTripper of a custom HTTP client. It returns false for other requests,
+// e.g. requests to Keystone.
+func OperationFromContext(ctx context.Context) (Operation, bool) {
+	operation, ok := ctx.Value(operationKey{}).(Operation)
+	return operation, ok
+}
+
+// Handler sends the request of the operation and returns the response.
+type Handler func(request *http.Request, operation Operation) (*http.Response, error)
+
+// Middleware wraps the next Handler.
+type Middleware func(next Handler) Handler
+
+// Chain wraps the handler with middlewares. The first middleware is the outermost one.
+func Chain(handler Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
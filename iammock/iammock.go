@@ -0,0 +1,81 @@
+// Package iammock provides mocks of the service interfaces of the iam package, e.g. iam.UsersAPI,
+// for unit tests of the code using iam-go.
+//
+// Every mock records its calls and returns results of the functions set in its fields:
+//
+//	mock := &iammock.Users{
+//		GetFunc: func(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) (*users.GetResponse, error) {
+//			return nil, iamerrors.ErrUserNotFound
+//		},
+//	}
+//	...
+//	calls := mock.CallsOf("Get")
+//
+// A method without a function set returns ErrUnexpectedCall.
+// Mocks are generated from the interfaces by go generate in the iam package.
+package iammock
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnexpectedCall is returned by a mock method without a scripted response.
+var ErrUnexpectedCall = errors.New("unexpected call")
+
+// Call represents a recorded call of a mock method.
+type Call struct {
+	// Method represents a name of the called method.
+	Method string
+
+	// Args contains the arguments of the call, except the context.
+	Args []interface{}
+}
+
+// Recorder records calls of a mock. It is safe for concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Calls returns all recorded calls in the order they were made.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Call{}, r.calls...)
+}
+
+// CallsOf returns recorded calls of the method in the order they were made.
+func (r *Recorder) CallsOf(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var calls []Call
+	for _, call := range r.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets all recorded calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = nil
+}
+
+func (r *Recorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+func unexpectedCall(mock, method string) error {
+	return fmt.Errorf("%w: %s.%s", ErrUnexpectedCall, mock, method)
+}
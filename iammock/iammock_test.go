@@ -0,0 +1,86 @@
+package iammock
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/service/groups"
+	"github.com/selectel/iam-go/v2/service/users"
+)
+
+func TestScriptedResponse(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	mock := &Users{
+		GetFunc: func(_ context.Context, userID iamid.UserID, _ ...iamrequest.Option) (*users.GetResponse, error) {
+			if userID == "unknown" {
+				return nil, iamerrors.ErrUserNotFound
+			}
+			return &users.GetResponse{User: users.User{ID: userID}}, nil
+		},
+	}
+
+	user, err := mock.Get(context.Background(), "123")
+	require.NoError(err)
+	assert.Equal(iamid.UserID("123"), user.ID)
+
+	_, err = mock.Get(context.Background(), "unknown")
+	require.ErrorIs(err, iamerrors.ErrUserNotFound)
+
+	assert.Equal([]Call{
+		{Method: "Get", Args: []interface{}{iamid.UserID("123"), []iamrequest.Option(nil)}},
+		{Method: "Get", Args: []interface{}{iamid.UserID("unknown"), []iamrequest.Option(nil)}},
+	}, mock.Calls())
+}
+
+func TestUnexpectedCall(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	mock := &Groups{}
+
+	response, err := mock.Create(context.Background(), groups.CreateRequest{Name: "developers"})
+
+	require.ErrorIs(err, ErrUnexpectedCall)
+	assert.EqualError(err, "unexpected call: Groups.Create")
+	assert.Nil(response)
+	assert.Equal([]Call{
+		{Method: "Create", Args: []interface{}{groups.CreateRequest{Name: "developers"}, []iamrequest.Option(nil)}},
+	}, mock.CallsOf("Create"))
+}
+
+func TestRecorder(t *testing.T) {
+	assert := assert.New(t)
+
+	mock := &Groups{
+		AddUsersFunc: func(context.Context, iamid.GroupID, []iamid.KeystoneID, ...iamrequest.Option) error {
+			return nil
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = mock.AddUsers(context.Background(), "123", []iamid.KeystoneID{"456"})
+		}()
+	}
+	wg.Wait()
+	_ = mock.DeleteUsers(context.Background(), "123", []iamid.KeystoneID{"456"})
+
+	assert.Len(mock.Calls(), 11)
+	assert.Len(mock.CallsOf("AddUsers"), 10)
+	assert.Len(mock.CallsOf("DeleteUsers"), 1)
+
+	mock.Reset()
+	assert.Empty(mock.Calls())
+}
@@ -0,0 +1,976 @@
+// Code generated by mockgen from iam interfaces. DO NOT EDIT.
+
+package iammock
+
+import (
+	"context"
+
+	iam "github.com/selectel/iam-go/v2"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/service/federations/saml"
+	"github.com/selectel/iam-go/v2/service/federations/saml/certificates"
+	"github.com/selectel/iam-go/v2/service/federations/saml/groupmappings"
+	"github.com/selectel/iam-go/v2/service/groups"
+	"github.com/selectel/iam-go/v2/service/principals"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/s3credentials"
+	"github.com/selectel/iam-go/v2/service/serviceusers"
+	"github.com/selectel/iam-go/v2/service/users"
+)
+
+// Users is a mock of iam.UsersAPI.
+// A method without a scripted response returns ErrUnexpectedCall.
+type Users struct {
+	Recorder
+
+	// ListFunc is called by List.
+	ListFunc func(ctx context.Context, opts ...iamrequest.Option) (*users.ListResponse, error)
+
+	// ForEachFunc is called by ForEach.
+	ForEachFunc func(ctx context.Context, fn func(users.User) error, opts ...iamrequest.Option) error
+
+	// GetFunc is called by Get.
+	GetFunc func(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) (*users.GetResponse, error)
+
+	// CreateFunc is called by Create.
+	CreateFunc func(ctx context.Context, input users.CreateRequest, opts ...iamrequest.Option) (*users.CreateResponse, error)
+
+	// EnsureFunc is called by Ensure.
+	EnsureFunc func(ctx context.Context, input users.CreateRequest, opts ...iamrequest.Option) (*users.EnsureResponse, error)
+
+	// DeleteFunc is called by Delete.
+	DeleteFunc func(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) error
+
+	// ResendInviteFunc is called by ResendInvite.
+	ResendInviteFunc func(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) error
+
+	// AssignRolesFunc is called by AssignRoles.
+	AssignRolesFunc func(ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option) error
+
+	// UnassignRolesFunc is called by UnassignRoles.
+	UnassignRolesFunc func(ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option) error
+
+	// SetRolesFunc is called by SetRoles.
+	SetRolesFunc func(ctx context.Context, userID iamid.UserID, desired []roles.Role, opts ...iamrequest.Option) (*roles.Change, error)
+}
+
+var _ iam.UsersAPI = (*Users)(nil)
+
+// List records the call and returns the result of ListFunc.
+func (m *Users) List(ctx context.Context, opts ...iamrequest.Option) (*users.ListResponse, error) {
+	m.record("List", opts)
+	if m.ListFunc == nil {
+		var r0 *users.ListResponse
+		return r0, unexpectedCall("Users", "List")
+	}
+	return m.ListFunc(ctx, opts...)
+}
+
+// ForEach records the call and returns the result of ForEachFunc.
+func (m *Users) ForEach(ctx context.Context, fn func(users.User) error, opts ...iamrequest.Option) error {
+	m.record("ForEach", fn, opts)
+	if m.ForEachFunc == nil {
+		return unexpectedCall("Users", "ForEach")
+	}
+	return m.ForEachFunc(ctx, fn, opts...)
+}
+
+// Get records the call and returns the result of GetFunc.
+func (m *Users) Get(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) (*users.GetResponse, error) {
+	m.record("Get", userID, opts)
+	if m.GetFunc == nil {
+		var r0 *users.GetResponse
+		return r0, unexpectedCall("Users", "Get")
+	}
+	return m.GetFunc(ctx, userID, opts...)
+}
+
+// Create records the call and returns the result of CreateFunc.
+func (m *Users) Create(ctx context.Context, input users.CreateRequest, opts ...iamrequest.Option) (*users.CreateResponse, error) {
+	m.record("Create", input, opts)
+	if m.CreateFunc == nil {
+		var r0 *users.CreateResponse
+		return r0, unexpectedCall("Users", "Create")
+	}
+	return m.CreateFunc(ctx, input, opts...)
+}
+
+// Ensure records the call and returns the result of EnsureFunc.
+func (m *Users) Ensure(ctx context.Context, input users.CreateRequest, opts ...iamrequest.Option) (*users.EnsureResponse, error) {
+	m.record("Ensure", input, opts)
+	if m.EnsureFunc == nil {
+		var r0 *users.EnsureResponse
+		return r0, unexpectedCall("Users", "Ensure")
+	}
+	return m.EnsureFunc(ctx, input, opts...)
+}
+
+// Delete records the call and returns the result of DeleteFunc.
+func (m *Users) Delete(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) error {
+	m.record("Delete", userID, opts)
+	if m.DeleteFunc == nil {
+		return unexpectedCall("Users", "Delete")
+	}
+	return m.DeleteFunc(ctx, userID, opts...)
+}
+
+// ResendInvite records the call and returns the result of ResendInviteFunc.
+func (m *Users) ResendInvite(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) error {
+	m.record("ResendInvite", userID, opts)
+	if m.ResendInviteFunc == nil {
+		return unexpectedCall("Users", "ResendInvite")
+	}
+	return m.ResendInviteFunc(ctx, userID, opts...)
+}
+
+// AssignRoles records the call and returns the result of AssignRolesFunc.
+func (m *Users) AssignRoles(ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option) error {
+	m.record("AssignRoles", userID, roles, opts)
+	if m.AssignRolesFunc == nil {
+		return unexpectedCall("Users", "AssignRoles")
+	}
+	return m.AssignRolesFunc(ctx, userID, roles, opts...)
+}
+
+// UnassignRoles records the call and returns the result of UnassignRolesFunc.
+func (m *Users) UnassignRoles(ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option) error {
+	m.record("UnassignRoles", userID, roles, opts)
+	if m.UnassignRolesFunc == nil {
+		return unexpectedCall("Users", "UnassignRoles")
+	}
+	return m.UnassignRolesFunc(ctx, userID, roles, opts...)
+}
+
+// SetRoles records the call and returns the result of SetRolesFunc.
+func (m *Users) SetRoles(ctx context.Context, userID iamid.UserID, desired []roles.Role, opts ...iamrequest.Option) (*roles.Change, error) {
+	m.record("SetRoles", userID, desired, opts)
+	if m.SetRolesFunc == nil {
+		var r0 *roles.Change
+		return r0, unexpectedCall("Users", "SetRoles")
+	}
+	return m.SetRolesFunc(ctx, userID, desired, opts...)
+}
+
+// ServiceUsers is a mock of iam.ServiceUsersAPI.
+// A method without a scripted response returns ErrUnexpectedCall.
+type ServiceUsers struct {
+	Recorder
+
+	// ListFunc is called by List.
+	ListFunc func(ctx context.Context, opts ...iamrequest.Option) (*serviceusers.ListResponse, error)
+
+	// ForEachFunc is called by ForEach.
+	ForEachFunc func(ctx context.Context, fn func(serviceusers.ServiceUser) error, opts ...iamrequest.Option) error
+
+	// GetFunc is called by Get.
+	GetFunc func(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) (*serviceusers.GetResponse, error)
+
+	// CreateFunc is called by Create.
+	CreateFunc func(ctx context.Context, input serviceusers.CreateRequest, opts ...iamrequest.Option) (*serviceusers.CreateResponse, error)
+
+	// EnsureFunc is called by Ensure.
+	EnsureFunc func(ctx context.Context, input serviceusers.EnsureRequest, opts ...iamrequest.Option) (*serviceusers.EnsureResponse, error)
+
+	// UpdateFunc is called by Update.
+	UpdateFunc func(ctx context.Context, userID iamid.UserID, input serviceusers.UpdateRequest, opts ...iamrequest.Option) (*serviceusers.UpdateResponse, error)
+
+	// PatchFunc is called by Patch.
+	PatchFunc func(ctx context.Context, userID iamid.UserID, input serviceusers.PatchRequest, opts ...iamrequest.Option) (*serviceusers.UpdateResponse, error)
+
+	// ModifyFunc is called by Modify.
+	ModifyFunc func(ctx context.Context, userID iamid.UserID, mutate func(*serviceusers.ServiceUser) error, opts ...iamrequest.Option) (*serviceusers.UpdateResponse, error)
+
+	// DeleteFunc is called by Delete.
+	DeleteFunc func(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) error
+
+	// AssignRolesFunc is called by AssignRoles.
+	AssignRolesFunc func(ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option) error
+
+	// UnassignRolesFunc is called by UnassignRoles.
+	UnassignRolesFunc func(ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option) error
+
+	// SetRolesFunc is called by SetRoles.
+	SetRolesFunc func(ctx context.Context, userID iamid.UserID, desired []roles.Role, opts ...iamrequest.Option) (*roles.Change, error)
+}
+
+var _ iam.ServiceUsersAPI = (*ServiceUsers)(nil)
+
+// List records the call and returns the result of ListFunc.
+func (m *ServiceUsers) List(ctx context.Context, opts ...iamrequest.Option) (*serviceusers.ListResponse, error) {
+	m.record("List", opts)
+	if m.ListFunc == nil {
+		var r0 *serviceusers.ListResponse
+		return r0, unexpectedCall("ServiceUsers", "List")
+	}
+	return m.ListFunc(ctx, opts...)
+}
+
+// ForEach records the call and returns the result of ForEachFunc.
+func (m *ServiceUsers) ForEach(ctx context.Context, fn func(serviceusers.ServiceUser) error, opts ...iamrequest.Option) error {
+	m.record("ForEach", fn, opts)
+	if m.ForEachFunc == nil {
+		return unexpectedCall("ServiceUsers", "ForEach")
+	}
+	return m.ForEachFunc(ctx, fn, opts...)
+}
+
+// Get records the call and returns the result of GetFunc.
+func (m *ServiceUsers) Get(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) (*serviceusers.GetResponse, error) {
+	m.record("Get", userID, opts)
+	if m.GetFunc == nil {
+		var r0 *serviceusers.GetResponse
+		return r0, unexpectedCall("ServiceUsers", "Get")
+	}
+	return m.GetFunc(ctx, userID, opts...)
+}
+
+// Create records the call and returns the result of CreateFunc.
+func (m *ServiceUsers) Create(ctx context.Context, input serviceusers.CreateRequest, opts ...iamrequest.Option) (*serviceusers.CreateResponse, error) {
+	m.record("Create", input, opts)
+	if m.CreateFunc == nil {
+		var r0 *serviceusers.CreateResponse
+		return r0, unexpectedCall("ServiceUsers", "Create")
+	}
+	return m.CreateFunc(ctx, input, opts...)
+}
+
+// Ensure records the call and returns the result of EnsureFunc.
+func (m *ServiceUsers) Ensure(ctx context.Context, input serviceusers.EnsureRequest, opts ...iamrequest.Option) (*serviceusers.EnsureResponse, error) {
+	m.record("Ensure", input, opts)
+	if m.EnsureFunc == nil {
+		var r0 *serviceusers.EnsureResponse
+		return r0, unexpectedCall("ServiceUsers", "Ensure")
+	}
+	return m.EnsureFunc(ctx, input, opts...)
+}
+
+// Update records the call and returns the result of UpdateFunc.
+func (m *ServiceUsers) Update(ctx context.Context, userID iamid.UserID, input serviceusers.UpdateRequest, opts ...iamrequest.Option) (*serviceusers.UpdateResponse, error) {
+	m.record("Update", userID, input, opts)
+	if m.UpdateFunc == nil {
+		var r0 *serviceusers.UpdateResponse
+		return r0, unexpectedCall("ServiceUsers", "Update")
+	}
+	return m.UpdateFunc(ctx, userID, input, opts...)
+}
+
+// Patch records the call and returns the result of PatchFunc.
+func (m *ServiceUsers) Patch(ctx context.Context, userID iamid.UserID, input serviceusers.PatchRequest, opts ...iamrequest.Option) (*serviceusers.UpdateResponse, error) {
+	m.record("Patch", userID, input, opts)
+	if m.PatchFunc == nil {
+		var r0 *serviceusers.UpdateResponse
+		return r0, unexpectedCall("ServiceUsers", "Patch")
+	}
+	return m.PatchFunc(ctx, userID, input, opts...)
+}
+
+// Modify records the call and returns the result of ModifyFunc.
+func (m *ServiceUsers) Modify(ctx context.Context, userID iamid.UserID, mutate func(*serviceusers.ServiceUser) error, opts ...iamrequest.Option) (*serviceusers.UpdateResponse, error) {
+	m.record("Modify", userID, mutate, opts)
+	if m.ModifyFunc == nil {
+		var r0 *serviceusers.UpdateResponse
+		return r0, unexpectedCall("ServiceUsers", "Modify")
+	}
+	return m.ModifyFunc(ctx, userID, mutate, opts...)
+}
+
+// Delete records the call and returns the result of DeleteFunc.
+func (m *ServiceUsers) Delete(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) error {
+	m.record("Delete", userID, opts)
+	if m.DeleteFunc == nil {
+		return unexpectedCall("ServiceUsers", "Delete")
+	}
+	return m.DeleteFunc(ctx, userID, opts...)
+}
+
+// AssignRoles records the call and returns the result of AssignRolesFunc.
+func (m *ServiceUsers) AssignRoles(ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option) error {
+	m.record("AssignRoles", userID, roles, opts)
+	if m.AssignRolesFunc == nil {
+		return unexpectedCall("ServiceUsers", "AssignRoles")
+	}
+	return m.AssignRolesFunc(ctx, userID, roles, opts...)
+}
+
+// UnassignRoles records the call and returns the result of UnassignRolesFunc.
+func (m *ServiceUsers) UnassignRoles(ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option) error {
+	m.record("UnassignRoles", userID, roles, opts)
+	if m.UnassignRolesFunc == nil {
+		return unexpectedCall("ServiceUsers", "UnassignRoles")
+	}
+	return m.UnassignRolesFunc(ctx, userID, roles, opts...)
+}
+
+// SetRoles records the call and returns the result of SetRolesFunc.
+func (m *ServiceUsers) SetRoles(ctx context.Context, userID iamid.UserID, desired []roles.Role, opts ...iamrequest.Option) (*roles.Change, error) {
+	m.record("SetRoles", userID, desired, opts)
+	if m.SetRolesFunc == nil {
+		var r0 *roles.Change
+		return r0, unexpectedCall("ServiceUsers", "SetRoles")
+	}
+	return m.SetRolesFunc(ctx, userID, desired, opts...)
+}
+
+// Groups is a mock of iam.GroupsAPI.
+// A method without a scripted response returns ErrUnexpectedCall.
+type Groups struct {
+	Recorder
+
+	// ListFunc is called by List.
+	ListFunc func(ctx context.Context, opts ...iamrequest.Option) (*groups.ListResponse, error)
+
+	// ForEachFunc is called by ForEach.
+	ForEachFunc func(ctx context.Context, fn func(groups.Group) error, opts ...iamrequest.Option) error
+
+	// GetFunc is called by Get.
+	GetFunc func(ctx context.Context, groupID iamid.GroupID, opts ...iamrequest.Option) (*groups.GetResponse, error)
+
+	// CreateFunc is called by Create.
+	CreateFunc func(ctx context.Context, input groups.CreateRequest, opts ...iamrequest.Option) (*groups.CreateResponse, error)
+
+	// EnsureFunc is called by Ensure.
+	EnsureFunc func(ctx context.Context, input groups.CreateRequest, opts ...iamrequest.Option) (*groups.EnsureResponse, error)
+
+	// UpdateFunc is called by Update.
+	UpdateFunc func(ctx context.Context, groupID iamid.GroupID, input groups.UpdateRequest, opts ...iamrequest.Option) (*groups.UpdateResponse, error)
+
+	// PatchFunc is called by Patch.
+	PatchFunc func(ctx context.Context, groupID iamid.GroupID, input groups.PatchRequest, opts ...iamrequest.Option) (*groups.UpdateResponse, error)
+
+	// ModifyFunc is called by Modify.
+	ModifyFunc func(ctx context.Context, groupID iamid.GroupID, mutate func(*groups.Group) error, opts ...iamrequest.Option) (*groups.UpdateResponse, error)
+
+	// DeleteFunc is called by Delete.
+	DeleteFunc func(ctx context.Context, groupID iamid.GroupID, opts ...iamrequest.Option) error
+
+	// AssignRolesFunc is called by AssignRoles.
+	AssignRolesFunc func(ctx context.Context, groupID iamid.GroupID, roles []roles.Role, opts ...iamrequest.Option) error
+
+	// UnassignRolesFunc is called by UnassignRoles.
+	UnassignRolesFunc func(ctx context.Context, groupID iamid.GroupID, roles []roles.Role, opts ...iamrequest.Option) error
+
+	// SetRolesFunc is called by SetRoles.
+	SetRolesFunc func(ctx context.Context, groupID iamid.GroupID, desired []roles.Role, opts ...iamrequest.Option) (*roles.Change, error)
+
+	// AddUsersFunc is called by AddUsers.
+	AddUsersFunc func(ctx context.Context, groupID iamid.GroupID, usersKeystoneIDs []iamid.KeystoneID, opts ...iamrequest.Option) error
+
+	// DeleteUsersFunc is called by DeleteUsers.
+	DeleteUsersFunc func(ctx context.Context, groupID iamid.GroupID, usersKeystoneIDs []iamid.KeystoneID, opts ...iamrequest.Option) error
+
+	// AddMembersFunc is called by AddMembers.
+	AddMembersFunc func(ctx context.Context, groupID iamid.GroupID, members []principals.Ref, opts ...iamrequest.Option) error
+
+	// DeleteMembersFunc is called by DeleteMembers.
+	DeleteMembersFunc func(ctx context.Context, groupID iamid.GroupID, members []principals.Ref, opts ...iamrequest.Option) error
+
+	// SetMembersFunc is called by SetMembers.
+	SetMembersFunc func(ctx context.Context, groupID iamid.GroupID, usersKeystoneIDs []iamid.KeystoneID, opts ...iamrequest.Option) (*groups.MembersChange, error)
+}
+
+var _ iam.GroupsAPI = (*Groups)(nil)
+
+// List records the call and returns the result of ListFunc.
+func (m *Groups) List(ctx context.Context, opts ...iamrequest.Option) (*groups.ListResponse, error) {
+	m.record("List", opts)
+	if m.ListFunc == nil {
+		var r0 *groups.ListResponse
+		return r0, unexpectedCall("Groups", "List")
+	}
+	return m.ListFunc(ctx, opts...)
+}
+
+// ForEach records the call and returns the result of ForEachFunc.
+func (m *Groups) ForEach(ctx context.Context, fn func(groups.Group) error, opts ...iamrequest.Option) error {
+	m.record("ForEach", fn, opts)
+	if m.ForEachFunc == nil {
+		return unexpectedCall("Groups", "ForEach")
+	}
+	return m.ForEachFunc(ctx, fn, opts...)
+}
+
+// Get records the call and returns the result of GetFunc.
+func (m *Groups) Get(ctx context.Context, groupID iamid.GroupID, opts ...iamrequest.Option) (*groups.GetResponse, error) {
+	m.record("Get", groupID, opts)
+	if m.GetFunc == nil {
+		var r0 *groups.GetResponse
+		return r0, unexpectedCall("Groups", "Get")
+	}
+	return m.GetFunc(ctx, groupID, opts...)
+}
+
+// Create records the call and returns the result of CreateFunc.
+func (m *Groups) Create(ctx context.Context, input groups.CreateRequest, opts ...iamrequest.Option) (*groups.CreateResponse, error) {
+	m.record("Create", input, opts)
+	if m.CreateFunc == nil {
+		var r0 *groups.CreateResponse
+		return r0, unexpectedCall("Groups", "Create")
+	}
+	return m.CreateFunc(ctx, input, opts...)
+}
+
+// Ensure records the call and returns the result of EnsureFunc.
+func (m *Groups) Ensure(ctx context.Context, input groups.CreateRequest, opts ...iamrequest.Option) (*groups.EnsureResponse, error) {
+	m.record("Ensure", input, opts)
+	if m.EnsureFunc == nil {
+		var r0 *groups.EnsureResponse
+		return r0, unexpectedCall("Groups", "Ensure")
+	}
+	return m.EnsureFunc(ctx, input, opts...)
+}
+
+// Update records the call and returns the result of UpdateFunc.
+func (m *Groups) Update(ctx context.Context, groupID iamid.GroupID, input groups.UpdateRequest, opts ...iamrequest.Option) (*groups.UpdateResponse, error) {
+	m.record("Update", groupID, input, opts)
+	if m.UpdateFunc == nil {
+		var r0 *groups.UpdateResponse
+		return r0, unexpectedCall("Groups", "Update")
+	}
+	return m.UpdateFunc(ctx, groupID, input, opts...)
+}
+
+// Patch records the call and returns the result of PatchFunc.
+func (m *Groups) Patch(ctx context.Context, groupID iamid.GroupID, input groups.PatchRequest, opts ...iamrequest.Option) (*groups.UpdateResponse, error) {
+	m.record("Patch", groupID, input, opts)
+	if m.PatchFunc == nil {
+		var r0 *groups.UpdateResponse
+		return r0, unexpectedCall("Groups", "Patch")
+	}
+	return m.PatchFunc(ctx, groupID, input, opts...)
+}
+
+// Modify records the call and returns the result of ModifyFunc.
+func (m *Groups) Modify(ctx context.Context, groupID iamid.GroupID, mutate func(*groups.Group) error, opts ...iamrequest.Option) (*groups.UpdateResponse, error) {
+	m.record("Modify", groupID, mutate, opts)
+	if m.ModifyFunc == nil {
+		var r0 *groups.UpdateResponse
+		return r0, unexpectedCall("Groups", "Modify")
+	}
+	return m.ModifyFunc(ctx, groupID, mutate, opts...)
+}
+
+// Delete records the call and returns the result of DeleteFunc.
+func (m *Groups) Delete(ctx context.Context, groupID iamid.GroupID, opts ...iamrequest.Option) error {
+	m.record("Delete", groupID, opts)
+	if m.DeleteFunc == nil {
+		return unexpectedCall("Groups", "Delete")
+	}
+	return m.DeleteFunc(ctx, groupID, opts...)
+}
+
+// AssignRoles records the call and returns the result of AssignRolesFunc.
+func (m *Groups) AssignRoles(ctx context.Context, groupID iamid.GroupID, roles []roles.Role, opts ...iamrequest.Option) error {
+	m.record("AssignRoles", groupID, roles, opts)
+	if m.AssignRolesFunc == nil {
+		return unexpectedCall("Groups", "AssignRoles")
+	}
+	return m.AssignRolesFunc(ctx, groupID, roles, opts...)
+}
+
+// UnassignRoles records the call and returns the result of UnassignRolesFunc.
+func (m *Groups) UnassignRoles(ctx context.Context, groupID iamid.GroupID, roles []roles.Role, opts ...iamrequest.Option) error {
+	m.record("UnassignRoles", groupID, roles, opts)
+	if m.UnassignRolesFunc == nil {
+		return unexpectedCall("Groups", "UnassignRoles")
+	}
+	return m.UnassignRolesFunc(ctx, groupID, roles, opts...)
+}
+
+// SetRoles records the call and returns the result of SetRolesFunc.
+func (m *Groups) SetRoles(ctx context.Context, groupID iamid.GroupID, desired []roles.Role, opts ...iamrequest.Option) (*roles.Change, error) {
+	m.record("SetRoles", groupID, desired, opts)
+	if m.SetRolesFunc == nil {
+		var r0 *roles.Change
+		return r0, unexpectedCall("Groups", "SetRoles")
+	}
+	return m.SetRolesFunc(ctx, groupID, desired, opts...)
+}
+
+// AddUsers records the call and returns the result of AddUsersFunc.
+func (m *Groups) AddUsers(ctx context.Context, groupID iamid.GroupID, usersKeystoneIDs []iamid.KeystoneID, opts ...iamrequest.Option) error {
+	m.record("AddUsers", groupID, usersKeystoneIDs, opts)
+	if m.AddUsersFunc == nil {
+		return unexpectedCall("Groups", "AddUsers")
+	}
+	return m.AddUsersFunc(ctx, groupID, usersKeystoneIDs, opts...)
+}
+
+// DeleteUsers records the call and returns the result of DeleteUsersFunc.
+func (m *Groups) DeleteUsers(ctx context.Context, groupID iamid.GroupID, usersKeystoneIDs []iamid.KeystoneID, opts ...iamrequest.Option) error {
+	m.record("DeleteUsers", groupID, usersKeystoneIDs, opts)
+	if m.DeleteUsersFunc == nil {
+		return unexpectedCall("Groups", "DeleteUsers")
+	}
+	return m.DeleteUsersFunc(ctx, groupID, usersKeystoneIDs, opts...)
+}
+
+// AddMembers records the call and returns the result of AddMembersFunc.
+func (m *Groups) AddMembers(ctx context.Context, groupID iamid.GroupID, members []principals.Ref, opts ...iamrequest.Option) error {
+	m.record("AddMembers", groupID, members, opts)
+	if m.AddMembersFunc == nil {
+		return unexpectedCall("Groups", "AddMembers")
+	}
+	return m.AddMembersFunc(ctx, groupID, members, opts...)
+}
+
+// DeleteMembers records the call and returns the result of DeleteMembersFunc.
+func (m *Groups) DeleteMembers(ctx context.Context, groupID iamid.GroupID, members []principals.Ref, opts ...iamrequest.Option) error {
+	m.record("DeleteMembers", groupID, members, opts)
+	if m.DeleteMembersFunc == nil {
+		return unexpectedCall("Groups", "DeleteMembers")
+	}
+	return m.DeleteMembersFunc(ctx, groupID, members, opts...)
+}
+
+// SetMembers records the call and returns the result of SetMembersFunc.
+func (m *Groups) SetMembers(ctx context.Context, groupID iamid.GroupID, usersKeystoneIDs []iamid.KeystoneID, opts ...iamrequest.Option) (*groups.MembersChange, error) {
+	m.record("SetMembers", groupID, usersKeystoneIDs, opts)
+	if m.SetMembersFunc == nil {
+		var r0 *groups.MembersChange
+		return r0, unexpectedCall("Groups", "SetMembers")
+	}
+	return m.SetMembersFunc(ctx, groupID, usersKeystoneIDs, opts...)
+}
+
+// Roles is a mock of iam.RolesAPI.
+// A method without a scripted response returns ErrUnexpectedCall.
+type Roles struct {
+	Recorder
+
+	// ListFunc is called by List.
+	ListFunc func(ctx context.Context, opts ...iamrequest.Option) (*roles.ListResponse, error)
+
+	// ForEachFunc is called by ForEach.
+	ForEachFunc func(ctx context.Context, fn func(roles.AvailableRole) error, opts ...iamrequest.Option) error
+}
+
+var _ iam.RolesAPI = (*Roles)(nil)
+
+// List records the call and returns the result of ListFunc.
+func (m *Roles) List(ctx context.Context, opts ...iamrequest.Option) (*roles.ListResponse, error) {
+	m.record("List", opts)
+	if m.ListFunc == nil {
+		var r0 *roles.ListResponse
+		return r0, unexpectedCall("Roles", "List")
+	}
+	return m.ListFunc(ctx, opts...)
+}
+
+// ForEach records the call and returns the result of ForEachFunc.
+func (m *Roles) ForEach(ctx context.Context, fn func(roles.AvailableRole) error, opts ...iamrequest.Option) error {
+	m.record("ForEach", fn, opts)
+	if m.ForEachFunc == nil {
+		return unexpectedCall("Roles", "ForEach")
+	}
+	return m.ForEachFunc(ctx, fn, opts...)
+}
+
+// S3Credentials is a mock of iam.S3CredentialsAPI.
+// A method without a scripted response returns ErrUnexpectedCall.
+type S3Credentials struct {
+	Recorder
+
+	// ListFunc is called by List.
+	ListFunc func(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) (*s3credentials.ListResponse, error)
+
+	// ForEachFunc is called by ForEach.
+	ForEachFunc func(ctx context.Context, userID iamid.UserID, fn func(s3credentials.Credential) error, opts ...iamrequest.Option) error
+
+	// CreateFunc is called by Create.
+	CreateFunc func(ctx context.Context, userID iamid.UserID, name string, projectID iamid.ProjectID, opts ...iamrequest.Option) (*s3credentials.CreateResponse, error)
+
+	// DeleteFunc is called by Delete.
+	DeleteFunc func(ctx context.Context, userID iamid.UserID, accessKey iamid.AccessKey, opts ...iamrequest.Option) error
+}
+
+var _ iam.S3CredentialsAPI = (*S3Credentials)(nil)
+
+// List records the call and returns the result of ListFunc.
+func (m *S3Credentials) List(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) (*s3credentials.ListResponse, error) {
+	m.record("List", userID, opts)
+	if m.ListFunc == nil {
+		var r0 *s3credentials.ListResponse
+		return r0, unexpectedCall("S3Credentials", "List")
+	}
+	return m.ListFunc(ctx, userID, opts...)
+}
+
+// ForEach records the call and returns the result of ForEachFunc.
+func (m *S3Credentials) ForEach(ctx context.Context, userID iamid.UserID, fn func(s3credentials.Credential) error, opts ...iamrequest.Option) error {
+	m.record("ForEach", userID, fn, opts)
+	if m.ForEachFunc == nil {
+		return unexpectedCall("S3Credentials", "ForEach")
+	}
+	return m.ForEachFunc(ctx, userID, fn, opts...)
+}
+
+// Create records the call and returns the result of CreateFunc.
+func (m *S3Credentials) Create(ctx context.Context, userID iamid.UserID, name string, projectID iamid.ProjectID, opts ...iamrequest.Option) (*s3credentials.CreateResponse, error) {
+	m.record("Create", userID, name, projectID, opts)
+	if m.CreateFunc == nil {
+		var r0 *s3credentials.CreateResponse
+		return r0, unexpectedCall("S3Credentials", "Create")
+	}
+	return m.CreateFunc(ctx, userID, name, projectID, opts...)
+}
+
+// Delete records the call and returns the result of DeleteFunc.
+func (m *S3Credentials) Delete(ctx context.Context, userID iamid.UserID, accessKey iamid.AccessKey, opts ...iamrequest.Option) error {
+	m.record("Delete", userID, accessKey, opts)
+	if m.DeleteFunc == nil {
+		return unexpectedCall("S3Credentials", "Delete")
+	}
+	return m.DeleteFunc(ctx, userID, accessKey, opts...)
+}
+
+// SAMLFederations is a mock of iam.SAMLFederationsAPI.
+// A method without a scripted response returns ErrUnexpectedCall.
+type SAMLFederations struct {
+	Recorder
+
+	// ListFunc is called by List.
+	ListFunc func(ctx context.Context, opts ...iamrequest.Option) (*saml.ListResponse, error)
+
+	// ForEachFunc is called by ForEach.
+	ForEachFunc func(ctx context.Context, fn func(saml.Federation) error, opts ...iamrequest.Option) error
+
+	// GetFunc is called by Get.
+	GetFunc func(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) (*saml.GetResponse, error)
+
+	// ExistsFunc is called by Exists.
+	ExistsFunc func(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) (bool, error)
+
+	// PreviewFunc is called by Preview.
+	PreviewFunc func(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) (*saml.FederationPreview, error)
+
+	// CreateFunc is called by Create.
+	CreateFunc func(ctx context.Context, input saml.CreateRequest, opts ...iamrequest.Option) (*saml.CreateResponse, error)
+
+	// EnsureFunc is called by Ensure.
+	EnsureFunc func(ctx context.Context, input saml.CreateRequest, opts ...iamrequest.Option) (*saml.EnsureResponse, error)
+
+	// UpdateFunc is called by Update.
+	UpdateFunc func(ctx context.Context, federationID iamid.FederationID, input saml.UpdateRequest, opts ...iamrequest.Option) error
+
+	// PatchFunc is called by Patch.
+	PatchFunc func(ctx context.Context, federationID iamid.FederationID, input saml.PatchRequest, opts ...iamrequest.Option) error
+
+	// ModifyFunc is called by Modify.
+	ModifyFunc func(ctx context.Context, federationID iamid.FederationID, mutate func(*saml.Federation) error, opts ...iamrequest.Option) error
+
+	// DeleteFunc is called by Delete.
+	DeleteFunc func(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) error
+}
+
+var _ iam.SAMLFederationsAPI = (*SAMLFederations)(nil)
+
+// List records the call and returns the result of ListFunc.
+func (m *SAMLFederations) List(ctx context.Context, opts ...iamrequest.Option) (*saml.ListResponse, error) {
+	m.record("List", opts)
+	if m.ListFunc == nil {
+		var r0 *saml.ListResponse
+		return r0, unexpectedCall("SAMLFederations", "List")
+	}
+	return m.ListFunc(ctx, opts...)
+}
+
+// ForEach records the call and returns the result of ForEachFunc.
+func (m *SAMLFederations) ForEach(ctx context.Context, fn func(saml.Federation) error, opts ...iamrequest.Option) error {
+	m.record("ForEach", fn, opts)
+	if m.ForEachFunc == nil {
+		return unexpectedCall("SAMLFederations", "ForEach")
+	}
+	return m.ForEachFunc(ctx, fn, opts...)
+}
+
+// Get records the call and returns the result of GetFunc.
+func (m *SAMLFederations) Get(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) (*saml.GetResponse, error) {
+	m.record("Get", federationID, opts)
+	if m.GetFunc == nil {
+		var r0 *saml.GetResponse
+		return r0, unexpectedCall("SAMLFederations", "Get")
+	}
+	return m.GetFunc(ctx, federationID, opts...)
+}
+
+// Exists records the call and returns the result of ExistsFunc.
+func (m *SAMLFederations) Exists(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) (bool, error) {
+	m.record("Exists", federationID, opts)
+	if m.ExistsFunc == nil {
+		var r0 bool
+		return r0, unexpectedCall("SAMLFederations", "Exists")
+	}
+	return m.ExistsFunc(ctx, federationID, opts...)
+}
+
+// Preview records the call and returns the result of PreviewFunc.
+func (m *SAMLFederations) Preview(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) (*saml.FederationPreview, error) {
+	m.record("Preview", federationID, opts)
+	if m.PreviewFunc == nil {
+		var r0 *saml.FederationPreview
+		return r0, unexpectedCall("SAMLFederations", "Preview")
+	}
+	return m.PreviewFunc(ctx, federationID, opts...)
+}
+
+// Create records the call and returns the result of CreateFunc.
+func (m *SAMLFederations) Create(ctx context.Context, input saml.CreateRequest, opts ...iamrequest.Option) (*saml.CreateResponse, error) {
+	m.record("Create", input, opts)
+	if m.CreateFunc == nil {
+		var r0 *saml.CreateResponse
+		return r0, unexpectedCall("SAMLFederations", "Create")
+	}
+	return m.CreateFunc(ctx, input, opts...)
+}
+
+// Ensure records the call and returns the result of EnsureFunc.
+func (m *SAMLFederations) Ensure(ctx context.Context, input saml.CreateRequest, opts ...iamrequest.Option) (*saml.EnsureResponse, error) {
+	m.record("Ensure", input, opts)
+	if m.EnsureFunc == nil {
+		var r0 *saml.EnsureResponse
+		return r0, unexpectedCall("SAMLFederations", "Ensure")
+	}
+	return m.EnsureFunc(ctx, input, opts...)
+}
+
+// Update records the call and returns the result of UpdateFunc.
+func (m *SAMLFederations) Update(ctx context.Context, federationID iamid.FederationID, input saml.UpdateRequest, opts ...iamrequest.Option) error {
+	m.record("Update", federationID, input, opts)
+	if m.UpdateFunc == nil {
+		return unexpectedCall("SAMLFederations", "Update")
+	}
+	return m.UpdateFunc(ctx, federationID, input, opts...)
+}
+
+// Patch records the call and returns the result of PatchFunc.
+func (m *SAMLFederations) Patch(ctx context.Context, federationID iamid.FederationID, input saml.PatchRequest, opts ...iamrequest.Option) error {
+	m.record("Patch", federationID, input, opts)
+	if m.PatchFunc == nil {
+		return unexpectedCall("SAMLFederations", "Patch")
+	}
+	return m.PatchFunc(ctx, federationID, input, opts...)
+}
+
+// Modify records the call and returns the result of ModifyFunc.
+func (m *SAMLFederations) Modify(ctx context.Context, federationID iamid.FederationID, mutate func(*saml.Federation) error, opts ...iamrequest.Option) error {
+	m.record("Modify", federationID, mutate, opts)
+	if m.ModifyFunc == nil {
+		return unexpectedCall("SAMLFederations", "Modify")
+	}
+	return m.ModifyFunc(ctx, federationID, mutate, opts...)
+}
+
+// Delete records the call and returns the result of DeleteFunc.
+func (m *SAMLFederations) Delete(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) error {
+	m.record("Delete", federationID, opts)
+	if m.DeleteFunc == nil {
+		return unexpectedCall("SAMLFederations", "Delete")
+	}
+	return m.DeleteFunc(ctx, federationID, opts...)
+}
+
+// Certificates is a mock of iam.CertificatesAPI.
+// A method without a scripted response returns ErrUnexpectedCall.
+type Certificates struct {
+	Recorder
+
+	// ListFunc is called by List.
+	ListFunc func(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) (*certificates.ListResponse, error)
+
+	// ForEachFunc is called by ForEach.
+	ForEachFunc func(ctx context.Context, federationID iamid.FederationID, fn func(certificates.Certificate) error, opts ...iamrequest.Option) error
+
+	// GetFunc is called by Get.
+	GetFunc func(ctx context.Context, federationID iamid.FederationID, certificateID iamid.CertificateID, opts ...iamrequest.Option) (*certificates.GetResponse, error)
+
+	// CreateFunc is called by Create.
+	CreateFunc func(ctx context.Context, federationID iamid.FederationID, input certificates.CreateRequest, opts ...iamrequest.Option) (*certificates.CreateResponse, error)
+
+	// EnsureFunc is called by Ensure.
+	EnsureFunc func(ctx context.Context, federationID iamid.FederationID, input certificates.CreateRequest, opts ...iamrequest.Option) (*certificates.EnsureResponse, error)
+
+	// UpdateFunc is called by Update.
+	UpdateFunc func(ctx context.Context, federationID iamid.FederationID, certificateID iamid.CertificateID, input certificates.UpdateRequest, opts ...iamrequest.Option) (*certificates.UpdateResponse, error)
+
+	// PatchFunc is called by Patch.
+	PatchFunc func(ctx context.Context, federationID iamid.FederationID, certificateID iamid.CertificateID, input certificates.PatchRequest, opts ...iamrequest.Option) (*certificates.UpdateResponse, error)
+
+	// ModifyFunc is called by Modify.
+	ModifyFunc func(ctx context.Context, federationID iamid.FederationID, certificateID iamid.CertificateID, mutate func(*certificates.Certificate) error, opts ...iamrequest.Option) (*certificates.UpdateResponse, error)
+
+	// DeleteFunc is called by Delete.
+	DeleteFunc func(ctx context.Context, federationID iamid.FederationID, certificateID iamid.CertificateID, opts ...iamrequest.Option) error
+}
+
+var _ iam.CertificatesAPI = (*Certificates)(nil)
+
+// List records the call and returns the result of ListFunc.
+func (m *Certificates) List(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) (*certificates.ListResponse, error) {
+	m.record("List", federationID, opts)
+	if m.ListFunc == nil {
+		var r0 *certificates.ListResponse
+		return r0, unexpectedCall("Certificates", "List")
+	}
+	return m.ListFunc(ctx, federationID, opts...)
+}
+
+// ForEach records the call and returns the result of ForEachFunc.
+func (m *Certificates) ForEach(ctx context.Context, federationID iamid.FederationID, fn func(certificates.Certificate) error, opts ...iamrequest.Option) error {
+	m.record("ForEach", federationID, fn, opts)
+	if m.ForEachFunc == nil {
+		return unexpectedCall("Certificates", "ForEach")
+	}
+	return m.ForEachFunc(ctx, federationID, fn, opts...)
+}
+
+// Get records the call and returns the result of GetFunc.
+func (m *Certificates) Get(ctx context.Context, federationID iamid.FederationID, certificateID iamid.CertificateID, opts ...iamrequest.Option) (*certificates.GetResponse, error) {
+	m.record("Get", federationID, certificateID, opts)
+	if m.GetFunc == nil {
+		var r0 *certificates.GetResponse
+		return r0, unexpectedCall("Certificates", "Get")
+	}
+	return m.GetFunc(ctx, federationID, certificateID, opts...)
+}
+
+// Create records the call and returns the result of CreateFunc.
+func (m *Certificates) Create(ctx context.Context, federationID iamid.FederationID, input certificates.CreateRequest, opts ...iamrequest.Option) (*certificates.CreateResponse, error) {
+	m.record("Create", federationID, input, opts)
+	if m.CreateFunc == nil {
+		var r0 *certificates.CreateResponse
+		return r0, unexpectedCall("Certificates", "Create")
+	}
+	return m.CreateFunc(ctx, federationID, input, opts...)
+}
+
+// Ensure records the call and returns the result of EnsureFunc.
+func (m *Certificates) Ensure(ctx context.Context, federationID iamid.FederationID, input certificates.CreateRequest, opts ...iamrequest.Option) (*certificates.EnsureResponse, error) {
+	m.record("Ensure", federationID, input, opts)
+	if m.EnsureFunc == nil {
+		var r0 *certificates.EnsureResponse
+		return r0, unexpectedCall("Certificates", "Ensure")
+	}
+	return m.EnsureFunc(ctx, federationID, input, opts...)
+}
+
+// Update records the call and returns the result of UpdateFunc.
+func (m *Certificates) Update(ctx context.Context, federationID iamid.FederationID, certificateID iamid.CertificateID, input certificates.UpdateRequest, opts ...iamrequest.Option) (*certificates.UpdateResponse, error) {
+	m.record("Update", federationID, certificateID, input, opts)
+	if m.UpdateFunc == nil {
+		var r0 *certificates.UpdateResponse
+		return r0, unexpectedCall("Certificates", "Update")
+	}
+	return m.UpdateFunc(ctx, federationID, certificateID, input, opts...)
+}
+
+// Patch records the call and returns the result of PatchFunc.
+func (m *Certificates) Patch(ctx context.Context, federationID iamid.FederationID, certificateID iamid.CertificateID, input certificates.PatchRequest, opts ...iamrequest.Option) (*certificates.UpdateResponse, error) {
+	m.record("Patch", federationID, certificateID, input, opts)
+	if m.PatchFunc == nil {
+		var r0 *certificates.UpdateResponse
+		return r0, unexpectedCall("Certificates", "Patch")
+	}
+	return m.PatchFunc(ctx, federationID, certificateID, input, opts...)
+}
+
+// Modify records the call and returns the result of ModifyFunc.
+func (m *Certificates) Modify(ctx context.Context, federationID iamid.FederationID, certificateID iamid.CertificateID, mutate func(*certificates.Certificate) error, opts ...iamrequest.Option) (*certificates.UpdateResponse, error) {
+	m.record("Modify", federationID, certificateID, mutate, opts)
+	if m.ModifyFunc == nil {
+		var r0 *certificates.UpdateResponse
+		return r0, unexpectedCall("Certificates", "Modify")
+	}
+	return m.ModifyFunc(ctx, federationID, certificateID, mutate, opts...)
+}
+
+// Delete records the call and returns the result of DeleteFunc.
+func (m *Certificates) Delete(ctx context.Context, federationID iamid.FederationID, certificateID iamid.CertificateID, opts ...iamrequest.Option) error {
+	m.record("Delete", federationID, certificateID, opts)
+	if m.DeleteFunc == nil {
+		return unexpectedCall("Certificates", "Delete")
+	}
+	return m.DeleteFunc(ctx, federationID, certificateID, opts...)
+}
+
+// GroupMappings is a mock of iam.GroupMappingsAPI.
+// A method without a scripted response returns ErrUnexpectedCall.
+type GroupMappings struct {
+	Recorder
+
+	// ListFunc is called by List.
+	ListFunc func(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) (*groupmappings.GroupMappingsResponse, error)
+
+	// ForEachFunc is called by ForEach.
+	ForEachFunc func(ctx context.Context, federationID iamid.FederationID, fn func(groupmappings.GroupMapping) error, opts ...iamrequest.Option) error
+
+	// UpdateFunc is called by Update.
+	UpdateFunc func(ctx context.Context, federationID iamid.FederationID, input groupmappings.GroupMappingsRequest, opts ...iamrequest.Option) error
+
+	// AddFunc is called by Add.
+	AddFunc func(ctx context.Context, federationID iamid.FederationID, groupID iamid.GroupID, externalGroupID string, opts ...iamrequest.Option) error
+
+	// DeleteFunc is called by Delete.
+	DeleteFunc func(ctx context.Context, federationID iamid.FederationID, groupID iamid.GroupID, externalGroupID string, opts ...iamrequest.Option) error
+
+	// ExistsFunc is called by Exists.
+	ExistsFunc func(ctx context.Context, federationID iamid.FederationID, groupID iamid.GroupID, externalGroupID string, opts ...iamrequest.Option) (bool, error)
+}
+
+var _ iam.GroupMappingsAPI = (*GroupMappings)(nil)
+
+// List records the call and returns the result of ListFunc.
+func (m *GroupMappings) List(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) (*groupmappings.GroupMappingsResponse, error) {
+	m.record("List", federationID, opts)
+	if m.ListFunc == nil {
+		var r0 *groupmappings.GroupMappingsResponse
+		return r0, unexpectedCall("GroupMappings", "List")
+	}
+	return m.ListFunc(ctx, federationID, opts...)
+}
+
+// ForEach records the call and returns the result of ForEachFunc.
+func (m *GroupMappings) ForEach(ctx context.Context, federationID iamid.FederationID, fn func(groupmappings.GroupMapping) error, opts ...iamrequest.Option) error {
+	m.record("ForEach", federationID, fn, opts)
+	if m.ForEachFunc == nil {
+		return unexpectedCall("GroupMappings", "ForEach")
+	}
+	return m.ForEachFunc(ctx, federationID, fn, opts...)
+}
+
+// Update records the call and returns the result of UpdateFunc.
+func (m *GroupMappings) Update(ctx context.Context, federationID iamid.FederationID, input groupmappings.GroupMappingsRequest, opts ...iamrequest.Option) error {
+	m.record("Update", federationID, input, opts)
+	if m.UpdateFunc == nil {
+		return unexpectedCall("GroupMappings", "Update")
+	}
+	return m.UpdateFunc(ctx, federationID, input, opts...)
+}
+
+// Add records the call and returns the result of AddFunc.
+func (m *GroupMappings) Add(ctx context.Context, federationID iamid.FederationID, groupID iamid.GroupID, externalGroupID string, opts ...iamrequest.Option) error {
+	m.record("Add", federationID, groupID, externalGroupID, opts)
+	if m.AddFunc == nil {
+		return unexpectedCall("GroupMappings", "Add")
+	}
+	return m.AddFunc(ctx, federationID, groupID, externalGroupID, opts...)
+}
+
+// Delete records the call and returns the result of DeleteFunc.
+func (m *GroupMappings) Delete(ctx context.Context, federationID iamid.FederationID, groupID iamid.GroupID, externalGroupID string, opts ...iamrequest.Option) error {
+	m.record("Delete", federationID, groupID, externalGroupID, opts)
+	if m.DeleteFunc == nil {
+		return unexpectedCall("GroupMappings", "Delete")
+	}
+	return m.DeleteFunc(ctx, federationID, groupID, externalGroupID, opts...)
+}
+
+// Exists records the call and returns the result of ExistsFunc.
+func (m *GroupMappings) Exists(ctx context.Context, federationID iamid.FederationID, groupID iamid.GroupID, externalGroupID string, opts ...iamrequest.Option) (bool, error) {
+	m.record("Exists", federationID, groupID, externalGroupID, opts)
+	if m.ExistsFunc == nil {
+		var r0 bool
+		return r0, unexpectedCall("GroupMappings", "Exists")
+	}
+	return m.ExistsFunc(ctx, federationID, groupID, externalGroupID, opts...)
+}
@@ -0,0 +1,100 @@
+// Package iamrequest provides options of a single call of a Service method, e.g. a timeout or extra headers.
+//
+// Options are passed as the last arguments of any Service method:
+//
+//	_, err := iamClient.Users.Get(ctx, userID, iamrequest.WithTimeout(5*time.Second), iamrequest.WithoutRetries())
+package iamrequest
+
+import (
+	"net/http"
+	"time"
+)
+
+// IdempotencyKeyHeader represents a header with the idempotency key of the request.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Option is a functional parameter of a single call of a Service method.
+type Option func(*Options)
+
+// Options contains the configuration of a single call of a Service method.
+type Options struct {
+	// Timeout limits the duration of the call, including all retries. Zero means no limit.
+	Timeout time.Duration
+
+	// Header contains extra headers of the request. They replace headers with the same names set by iam-go.
+	Header http.Header
+
+	// IdempotencyKey is sent in the Idempotency-Key header. Calls with a key are retried according to
+	// the retry policy of the Client even if they use POST or PATCH.
+	IdempotencyKey string
+
+	// Token replaces the Keystone token of the Client for the call. The call isn't repeated with a new token,
+	// if the IAM API rejects this one.
+	Token string
+
+	// DisableRetries disables retries of the call regardless of the retry policy of the Client.
+	DisableRetries bool
+
+	// DryRun collects the call instead of sending it, if the call is mutating. Reads are sent as usual.
+	DryRun *Plan
+}
+
+// NewOptions returns Options with all opts applied in order.
+func NewOptions(opts ...Option) Options {
+	var options Options
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+	return options
+}
+
+// WithTimeout is a functional parameter of a call, used to limit its duration, including all retries.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+// WithHeader is a functional parameter of a call, used to add a header to its request.
+// It replaces a header with the same name set by iam-go.
+func WithHeader(name, value string) Option {
+	return func(o *Options) {
+		if o.Header == nil {
+			o.Header = make(http.Header)
+		}
+		o.Header.Add(name, value)
+	}
+}
+
+// WithIdempotencyKey is a functional parameter of a call, used to send the Idempotency-Key header.
+// Calls with the key are retried even if they use POST or PATCH, since the IAM API is able to deduplicate them.
+func WithIdempotencyKey(key string) Option {
+	return func(o *Options) {
+		o.IdempotencyKey = key
+	}
+}
+
+// WithToken is a functional parameter of a call, used to authenticate it with another Keystone token.
+func WithToken(token string) Option {
+	return func(o *Options) {
+		o.Token = token
+	}
+}
+
+// WithoutRetries is a functional parameter of a call, used to disable its retries.
+func WithoutRetries() Option {
+	return func(o *Options) {
+		o.DisableRetries = true
+	}
+}
+
+// WithDryRun is a functional parameter of a call, used to record the call into the plan instead of sending it,
+// if the call is mutating, e.g. Create or AssignRoles. Reads are sent as usual.
+// The Service method returns an empty response for a planned call.
+func WithDryRun(plan *Plan) Option {
+	return func(o *Options) {
+		o.DryRun = plan
+	}
+}
@@ -0,0 +1,63 @@
+package iamrequest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/selectel/iam-go/v2/iammiddleware"
+)
+
+// PlannedCall represents a mutating call, which was recorded into a Plan instead of being sent.
+type PlannedCall struct {
+	// Operation describes the call, including the HTTP method and the path of the request.
+	Operation iammiddleware.Operation
+
+	// Payload contains the decoded JSON body of the request with sensitive fields, e.g. passwords, redacted.
+	// It is nil for requests without a body.
+	Payload interface{}
+}
+
+// String returns a short description of the call, e.g. "users.Delete: DELETE iam/v1/users/123".
+func (c PlannedCall) String() string {
+	description := fmt.Sprintf("%s: %s %s", c.Operation, c.Operation.Method, c.Operation.Path)
+	if c.Payload == nil {
+		return description
+	}
+
+	payload, err := json.Marshal(c.Payload)
+	if err != nil {
+		return description
+	}
+	return description + " " + string(payload)
+}
+
+// Plan collects mutating calls made in the dry-run mode. It is safe for concurrent use.
+type Plan struct {
+	mu    sync.Mutex
+	calls []PlannedCall
+}
+
+// Calls returns planned calls in the order they were made.
+func (p *Plan) Calls() []PlannedCall {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]PlannedCall{}, p.calls...)
+}
+
+// Add records the call. It is called by iam-go and isn't needed to be called by applications.
+func (p *Plan) Add(call PlannedCall) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls = append(p.calls, call)
+}
+
+// Reset forgets all planned calls.
+func (p *Plan) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls = nil
+}
@@ -0,0 +1,42 @@
+package iamrequest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/selectel/iam-go/v2/iammiddleware"
+)
+
+func TestPlannedCallString(t *testing.T) {
+	operation := iammiddleware.Operation{
+		Service: "users",
+		Name:    "AssignRoles",
+		Method:  http.MethodPut,
+		Path:    "iam/v1/users/123/roles",
+	}
+
+	tests := []struct {
+		name     string
+		call     PlannedCall
+		expected string
+	}{
+		{
+			name:     "Test String with payload",
+			call:     PlannedCall{Operation: operation, Payload: map[string]interface{}{"roles": []interface{}{}}},
+			expected: `users.AssignRoles: PUT iam/v1/users/123/roles {"roles":[]}`,
+		},
+		{
+			name:     "Test String without payload",
+			call:     PlannedCall{Operation: operation},
+			expected: "users.AssignRoles: PUT iam/v1/users/123/roles",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.call.String())
+		})
+	}
+}
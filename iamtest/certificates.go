@@ -0,0 +1,164 @@
+package iamtest
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"time"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/service/federations/saml/certificates"
+)
+
+// AddCertificate adds a Certificate to the Federation with certificate.FederationID.
+// Empty ID and AccountID are generated.
+func (s *Server) AddCertificate(certificate certificates.Certificate) certificates.Certificate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if certificate.ID == "" {
+		certificate.ID = iamid.CertificateID(s.nextID())
+	}
+	if certificate.AccountID == "" {
+		certificate.AccountID = AccountID
+	}
+	s.putCertificate(&certificate)
+
+	return certificate
+}
+
+func (s *Server) certificateRoutes() []route {
+	const (
+		listTemplate = "v1/federations/saml/{federation_id}/certificates"
+		itemTemplate = "v1/federations/saml/{federation_id}/certificates/{certificate_id}"
+	)
+
+	return []route{
+		newRoute(http.MethodGet, listTemplate, http.StatusOK, s.listCertificates),
+		newRoute(http.MethodPost, listTemplate, http.StatusCreated, s.createCertificate),
+		newRoute(http.MethodGet, itemTemplate, http.StatusOK, s.getCertificate),
+		newRoute(http.MethodPatch, itemTemplate, http.StatusOK, s.updateCertificate),
+		newRoute(http.MethodDelete, itemTemplate, http.StatusNoContent, s.deleteCertificate),
+	}
+}
+
+func (s *Server) listCertificates(params map[string]string, _ []byte) (interface{}, error) {
+	federation, err := s.findFederation(params["federation_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	response := certificates.ListResponse{Certificates: []certificates.Certificate{}}
+	if list, ok := s.certificates[string(federation.ID)]; ok {
+		for _, certificate := range list.list() {
+			response.Certificates = append(response.Certificates, *certificate)
+		}
+	}
+	return response, nil
+}
+
+func (s *Server) getCertificate(params map[string]string, _ []byte) (interface{}, error) {
+	certificate, err := s.findCertificate(params["federation_id"], params["certificate_id"])
+	if err != nil {
+		return nil, err
+	}
+	return certificates.GetResponse{Certificate: *certificate}, nil
+}
+
+func (s *Server) createCertificate(params map[string]string, body []byte) (interface{}, error) {
+	federation, err := s.findFederation(params["federation_id"])
+	if err != nil {
+		return nil, err
+	}
+	var request certificates.CreateRequest
+	if err := decodeBody(body, &request); err != nil {
+		return nil, err
+	}
+	if request.Name == "" {
+		return nil, invalidField("name", "Name is required")
+	}
+	if request.Data == "" {
+		return nil, invalidField("data", "Data is required")
+	}
+
+	certificate := &certificates.Certificate{
+		ID:           iamid.CertificateID(s.nextID()),
+		AccountID:    federation.AccountID,
+		FederationID: federation.ID,
+		Name:         request.Name,
+		Description:  request.Description,
+		Data:         request.Data,
+	}
+	fillCertificateInfo(certificate)
+	s.putCertificate(certificate)
+
+	return certificates.CreateResponse{Certificate: *certificate}, nil
+}
+
+func (s *Server) updateCertificate(params map[string]string, body []byte) (interface{}, error) {
+	certificate, err := s.findCertificate(params["federation_id"], params["certificate_id"])
+	if err != nil {
+		return nil, err
+	}
+	var request certificates.UpdateRequest
+	if err := decodeBody(body, &request); err != nil {
+		return nil, err
+	}
+
+	updateString(&certificate.Name, request.Name)
+	if request.Description != nil {
+		certificate.Description = *request.Description
+	}
+	return certificates.UpdateResponse{Certificate: *certificate}, nil
+}
+
+func (s *Server) deleteCertificate(params map[string]string, _ []byte) (interface{}, error) {
+	certificate, err := s.findCertificate(params["federation_id"], params["certificate_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	s.certificates[string(certificate.FederationID)].delete(string(certificate.ID))
+	return nil, nil
+}
+
+func (s *Server) putCertificate(certificate *certificates.Certificate) {
+	if _, ok := s.certificates[string(certificate.FederationID)]; !ok {
+		s.certificates[string(certificate.FederationID)] = newCollection[*certificates.Certificate]()
+	}
+	s.certificates[string(certificate.FederationID)].put(string(certificate.ID), certificate)
+}
+
+func (s *Server) findCertificate(federationID, certificateID string) (*certificates.Certificate, error) {
+	if _, err := s.findFederation(federationID); err != nil {
+		return nil, err
+	}
+
+	if list, ok := s.certificates[federationID]; ok {
+		if certificate, ok := list.get(certificateID); ok {
+			return certificate, nil
+		}
+	}
+	return nil, notFound(iamerrors.ErrFederationCertificateNotFound, "Certificate not found")
+}
+
+// fillCertificateInfo sets the validity period and the fingerprint of the certificate,
+// if its data is a PEM encoded X.509 certificate. Other data is accepted as is.
+func fillCertificateInfo(certificate *certificates.Certificate) {
+	block, _ := pem.Decode([]byte(certificate.Data))
+	if block == nil {
+		return
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return
+	}
+
+	fingerprint := sha256.Sum256(parsed.Raw)
+	certificate.NotBefore = parsed.NotBefore.UTC().Format(time.RFC3339)
+	certificate.NotAfter = parsed.NotAfter.UTC().Format(time.RFC3339)
+	certificate.Fingerprint = hex.EncodeToString(fingerprint[:])
+}
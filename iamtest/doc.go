@@ -0,0 +1,17 @@
+// Package iamtest provides an in-memory fake of the Selectel IAM API for tests of applications using iam-go.
+//
+// Server emulates Users, Service Users, Groups, roles, S3 Credentials, SAML Federations, their Certificates
+// and group mappings. It keeps the state between requests and responds with the same error codes
+// as the IAM API, so errors can be checked with errors.Is and the sentinel errors of iamerrors:
+//
+//	server := iamtest.NewServer()
+//	defer server.Close()
+//
+//	group := server.AddGroup(groups.Group{Name: "developers"})
+//	client, err := server.Client()
+//	...
+//	_, err = client.Groups.Create(ctx, groups.CreateRequest{Name: "developers"})
+//	errors.Is(err, iamerrors.ErrGroupAlreadyExists) // true
+//
+// Use Fail to emulate outages and throttling of the IAM API and Requests to check what was sent.
+package iamtest
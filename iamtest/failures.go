@@ -0,0 +1,85 @@
+package iamtest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Failure describes an error injected with Server.Fail.
+type Failure struct {
+	// Method matches requests by the HTTP method. Empty matches any method.
+	Method string
+
+	// PathTemplate matches requests by the template of the path as in iammiddleware.Operation,
+	// e.g. "iam/v1/users/{user_id}". Empty matches any path.
+	PathTemplate string
+
+	// StatusCode of the response, e.g. http.StatusServiceUnavailable.
+	StatusCode int
+
+	// Err is a sentinel error of iamerrors, which code is returned in the response.
+	// If it's nil, the response is a plain text page, like the ones returned by proxies.
+	Err error
+
+	// RetryAfter is added to the response as the Retry-After header, if it's not zero.
+	RetryAfter time.Duration
+
+	// Times is the number of matching requests to fail. Zero fails them until ClearFailures is called.
+	Times int
+}
+
+type failure struct {
+	Failure
+	remaining int
+}
+
+// Fail makes the Server respond to matching requests with an error instead of handling them.
+// Failures are checked in the order they were added, before the token is checked.
+func (s *Server) Fail(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, &failure{Failure: f, remaining: f.Times})
+}
+
+// ClearFailures removes all failures added with Fail.
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = nil
+}
+
+// takeFailure returns the first failure matching the request and counts it.
+func (s *Server) takeFailure(method, template string) *failure {
+	for i, f := range s.failures {
+		if (f.Method != "" && f.Method != method) || (f.PathTemplate != "" && f.PathTemplate != template) {
+			continue
+		}
+		if f.Times > 0 {
+			f.remaining--
+			if f.remaining == 0 {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			}
+		}
+		return f
+	}
+
+	return nil
+}
+
+func (f *failure) write(w http.ResponseWriter) {
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(f.RetryAfter.Seconds())))
+	}
+
+	if f.Err == nil {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(f.StatusCode)
+		_, _ = w.Write([]byte("<html><body><h1>" + http.StatusText(f.StatusCode) + "</h1></body></html>"))
+		return
+	}
+
+	writeError(w, &apiError{status: f.StatusCode, err: f.Err, message: "Injected failure"})
+}
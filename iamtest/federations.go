@@ -0,0 +1,174 @@
+package iamtest
+
+import (
+	"net/http"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/service/federations/saml"
+)
+
+// AddFederation adds a SAML Federation. Empty ID and AccountID are generated.
+func (s *Server) AddFederation(federation saml.Federation) saml.Federation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if federation.ID == "" {
+		federation.ID = iamid.FederationID(s.nextID())
+	}
+	if federation.AccountID == "" {
+		federation.AccountID = AccountID
+	}
+	s.federations.put(string(federation.ID), &federation)
+
+	return federation
+}
+
+func (s *Server) federationRoutes() []route {
+	return []route{
+		newRoute(http.MethodGet, "v1/federations/saml", http.StatusOK, s.listFederations),
+		newRoute(http.MethodPost, "v1/federations/saml", http.StatusCreated, s.createFederation),
+		newRoute(http.MethodGet, "v1/federations/saml/{federation_id}", http.StatusOK, s.getFederation),
+		newRoute(http.MethodHead, "v1/federations/saml/{federation_id}", http.StatusOK, s.federationExists),
+		newRoute(http.MethodPatch, "v1/federations/saml/{federation_id}", http.StatusNoContent, s.updateFederation),
+		newRoute(http.MethodDelete, "v1/federations/saml/{federation_id}", http.StatusNoContent, s.deleteFederation),
+		newRoute(http.MethodGet, "v1/federations/saml/{federation_id}/preview", http.StatusOK, s.previewFederation),
+	}
+}
+
+func (s *Server) listFederations(map[string]string, []byte) (interface{}, error) {
+	response := saml.ListResponse{Federations: []saml.Federation{}}
+	for _, federation := range s.federations.list() {
+		response.Federations = append(response.Federations, *federation)
+	}
+	return response, nil
+}
+
+func (s *Server) getFederation(params map[string]string, _ []byte) (interface{}, error) {
+	federation, err := s.findFederation(params["federation_id"])
+	if err != nil {
+		return nil, err
+	}
+	return saml.GetResponse{Federation: *federation}, nil
+}
+
+func (s *Server) federationExists(params map[string]string, _ []byte) (interface{}, error) {
+	_, err := s.findFederation(params["federation_id"])
+	return nil, err
+}
+
+func (s *Server) previewFederation(params map[string]string, _ []byte) (interface{}, error) {
+	// Preview is available by the alias as well.
+	for _, federation := range s.federations.list() {
+		if string(federation.ID) == params["federation_id"] || federation.Alias == params["federation_id"] {
+			return saml.FederationPreview{
+				ID:          federation.ID,
+				Name:        federation.Name,
+				Description: federation.Description,
+				Alias:       federation.Alias,
+			}, nil
+		}
+	}
+	return nil, notFound(iamerrors.ErrFederationNotFound, "Federation not found")
+}
+
+func (s *Server) createFederation(_ map[string]string, body []byte) (interface{}, error) {
+	var request saml.CreateRequest
+	if err := decodeBody(body, &request); err != nil {
+		return nil, err
+	}
+	if err := validateFederation(request); err != nil {
+		return nil, err
+	}
+
+	federation := &saml.Federation{
+		AccountID:          AccountID,
+		ID:                 iamid.FederationID(s.nextID()),
+		Name:               request.Name,
+		Description:        request.Description,
+		Alias:              request.Alias,
+		Issuer:             request.Issuer,
+		SSOUrl:             request.SSOUrl,
+		SignAuthnRequests:  request.SignAuthnRequests,
+		ForceAuthn:         request.ForceAuthn,
+		SessionMaxAgeHours: request.SessionMaxAgeHours,
+		AutoUsersCreation:  request.AutoUsersCreation,
+		EnableGroupMapping: request.EnableGroupMapping,
+	}
+	s.federations.put(string(federation.ID), federation)
+
+	return saml.CreateResponse{Federation: *federation}, nil
+}
+
+func validateFederation(request saml.CreateRequest) error {
+	switch {
+	case request.Name == "":
+		return invalidField("name", "Name is required")
+	case request.Issuer == "":
+		return invalidField("issuer", "Issuer is required")
+	case request.SSOUrl == "":
+		return invalidField("sso_url", "SSO URL is required")
+	case request.SessionMaxAgeHours <= 0:
+		return invalidField("session_max_age_hours", "Session max age must be positive")
+	}
+	return nil
+}
+
+func (s *Server) updateFederation(params map[string]string, body []byte) (interface{}, error) {
+	federation, err := s.findFederation(params["federation_id"])
+	if err != nil {
+		return nil, err
+	}
+	// Both Update and Patch send PATCH requests, the fields they send are applied even if they are empty.
+	var request saml.PatchRequest
+	if err := decodeBody(body, &request); err != nil {
+		return nil, err
+	}
+
+	patchField(&federation.Name, request.Name)
+	patchField(&federation.Description, request.Description)
+	patchField(&federation.Alias, request.Alias)
+	patchField(&federation.Issuer, request.Issuer)
+	patchField(&federation.SSOUrl, request.SSOUrl)
+	patchField(&federation.SignAuthnRequests, request.SignAuthnRequests)
+	patchField(&federation.ForceAuthn, request.ForceAuthn)
+	patchField(&federation.SessionMaxAgeHours, request.SessionMaxAgeHours)
+	patchField(&federation.AutoUsersCreation, request.AutoUsersCreation)
+	patchField(&federation.EnableGroupMapping, request.EnableGroupMapping)
+
+	return nil, nil
+}
+
+func (s *Server) deleteFederation(params map[string]string, _ []byte) (interface{}, error) {
+	federation, err := s.findFederation(params["federation_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	s.federations.delete(string(federation.ID))
+	delete(s.certificates, string(federation.ID))
+	delete(s.groupMappings, string(federation.ID))
+	return nil, nil
+}
+
+func (s *Server) findFederation(federationID string) (*saml.Federation, error) {
+	federation, ok := s.federations.get(federationID)
+	if !ok {
+		return nil, notFound(iamerrors.ErrFederationNotFound, "Federation not found")
+	}
+	return federation, nil
+}
+
+// updateString sets the field to the value of a PATCH request, unless the value is omitted.
+func updateString(field *string, value string) {
+	if value != "" {
+		*field = value
+	}
+}
+
+// patchField sets the field to the value of a PATCH request, unless the value is omitted.
+func patchField[T any](field *T, value *T) {
+	if value != nil {
+		*field = *value
+	}
+}
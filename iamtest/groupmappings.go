@@ -0,0 +1,124 @@
+package iamtest
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/service/federations/saml/groupmappings"
+)
+
+// AddGroupMapping maps an external group of the Federation to a Group.
+func (s *Server) AddGroupMapping(federationID iamid.FederationID, mapping groupmappings.GroupMapping) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.addGroupMapping(string(federationID), mapping)
+}
+
+func (s *Server) groupMappingRoutes() []route {
+	const (
+		listTemplate = "v1/federations/saml/{federation_id}/group-mappings"
+		itemTemplate = listTemplate + "/{group_id}/external-groups/{external_group_id}"
+	)
+
+	return []route{
+		newRoute(http.MethodGet, listTemplate, http.StatusOK, s.listGroupMappings),
+		newRoute(http.MethodPut, listTemplate, http.StatusNoContent, s.replaceGroupMappings),
+		newRoute(http.MethodPut, itemTemplate, http.StatusNoContent, s.createGroupMapping),
+		newRoute(http.MethodDelete, itemTemplate, http.StatusNoContent, s.deleteGroupMapping),
+		newRoute(http.MethodHead, itemTemplate, http.StatusOK, s.groupMappingExists),
+	}
+}
+
+func (s *Server) listGroupMappings(params map[string]string, _ []byte) (interface{}, error) {
+	federation, err := s.findFederation(params["federation_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	return groupmappings.GroupMappingsResponse{
+		GroupMappings: append([]groupmappings.GroupMapping{}, s.groupMappings[string(federation.ID)]...),
+	}, nil
+}
+
+func (s *Server) replaceGroupMappings(params map[string]string, body []byte) (interface{}, error) {
+	federation, err := s.findFederation(params["federation_id"])
+	if err != nil {
+		return nil, err
+	}
+	var request groupmappings.GroupMappingsRequest
+	if err := decodeBody(body, &request); err != nil {
+		return nil, err
+	}
+	for _, mapping := range request.GroupMappings {
+		if _, err := s.findGroup(string(mapping.InternalGroupID)); err != nil {
+			return nil, err
+		}
+	}
+
+	delete(s.groupMappings, string(federation.ID))
+	for _, mapping := range request.GroupMappings {
+		s.addGroupMapping(string(federation.ID), mapping)
+	}
+	return nil, nil
+}
+
+func (s *Server) createGroupMapping(params map[string]string, _ []byte) (interface{}, error) {
+	mapping, err := s.groupMappingOf(params)
+	if err != nil {
+		return nil, err
+	}
+
+	s.addGroupMapping(params["federation_id"], mapping)
+	return nil, nil
+}
+
+func (s *Server) deleteGroupMapping(params map[string]string, _ []byte) (interface{}, error) {
+	mapping, err := s.groupMappingOf(params)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := s.groupMappings[params["federation_id"]]
+	i := slices.Index(mappings, mapping)
+	if i < 0 {
+		return nil, notFound(iamerrors.ErrUserOrGroupNotFound, "Group mapping not found")
+	}
+	s.groupMappings[params["federation_id"]] = slices.Delete(mappings, i, i+1)
+	return nil, nil
+}
+
+func (s *Server) groupMappingExists(params map[string]string, _ []byte) (interface{}, error) {
+	mapping, err := s.groupMappingOf(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(s.groupMappings[params["federation_id"]], mapping) {
+		return nil, notFound(iamerrors.ErrUserOrGroupNotFound, "Group mapping not found")
+	}
+	return nil, nil
+}
+
+// groupMappingOf returns the mapping from the path, checking that the Federation and the Group exist.
+func (s *Server) groupMappingOf(params map[string]string) (groupmappings.GroupMapping, error) {
+	if _, err := s.findFederation(params["federation_id"]); err != nil {
+		return groupmappings.GroupMapping{}, err
+	}
+	if _, err := s.findGroup(params["group_id"]); err != nil {
+		return groupmappings.GroupMapping{}, err
+	}
+
+	return groupmappings.GroupMapping{
+		InternalGroupID: iamid.GroupID(params["group_id"]),
+		ExternalGroupID: params["external_group_id"],
+	}, nil
+}
+
+func (s *Server) addGroupMapping(federationID string, mapping groupmappings.GroupMapping) {
+	if !slices.Contains(s.groupMappings[federationID], mapping) {
+		s.groupMappings[federationID] = append(s.groupMappings[federationID], mapping)
+	}
+}
@@ -0,0 +1,266 @@
+package iamtest
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/service/federations/saml/groupmappings"
+	"github.com/selectel/iam-go/v2/service/groups"
+	"github.com/selectel/iam-go/v2/service/roles"
+)
+
+type groupRecord struct {
+	groups.Group
+
+	// members contains Keystone IDs of Users and IDs of Service Users in the order of addition.
+	members []string
+}
+
+func (g *groupRecord) addMember(keystoneID string) {
+	if !slices.Contains(g.members, keystoneID) {
+		g.members = append(g.members, keystoneID)
+	}
+}
+
+func (g *groupRecord) removeMember(keystoneID string) {
+	if i := slices.Index(g.members, keystoneID); i >= 0 {
+		g.members = slices.Delete(g.members, i, i+1)
+	}
+}
+
+type updateGroupRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+type manageUsersRequest struct {
+	KeystoneIds []string `json:"keystone_ids"`
+}
+
+// AddGroup adds a Group with the given members. An empty ID is generated.
+// Members are Keystone IDs of Users and IDs of Service Users.
+func (s *Server) AddGroup(group groups.Group, members ...iamid.KeystoneID) groups.Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if group.ID == "" {
+		group.ID = iamid.GroupID(s.nextID())
+	}
+	if group.Roles == nil {
+		group.Roles = []roles.Role{}
+	}
+	record := &groupRecord{Group: group}
+	for _, member := range members {
+		record.addMember(string(member))
+	}
+	s.groups.put(string(group.ID), record)
+
+	return group
+}
+
+func (s *Server) groupRoutes() []route {
+	return []route{
+		newRoute(http.MethodGet, "iam/v1/groups", http.StatusOK, s.listGroups),
+		newRoute(http.MethodPost, "iam/v1/groups", http.StatusCreated, s.createGroup),
+		newRoute(http.MethodGet, "iam/v1/groups/{group_id}", http.StatusOK, s.getGroup),
+		newRoute(http.MethodPatch, "iam/v1/groups/{group_id}", http.StatusOK, s.updateGroup),
+		newRoute(http.MethodDelete, "iam/v1/groups/{group_id}", http.StatusNoContent, s.deleteGroup),
+		newRoute(http.MethodPut, "iam/v1/groups/{group_id}/roles", http.StatusNoContent,
+			s.rolesHandler(s.groupRoles, true)),
+		newRoute(http.MethodDelete, "iam/v1/groups/{group_id}/roles", http.StatusNoContent,
+			s.rolesHandler(s.groupRoles, false)),
+		newRoute(http.MethodPut, "iam/v1/groups/{group_id}/users", http.StatusNoContent, s.membersHandler(true)),
+		newRoute(http.MethodDelete, "iam/v1/groups/{group_id}/users", http.StatusNoContent, s.membersHandler(false)),
+	}
+}
+
+func (s *Server) listGroups(map[string]string, []byte) (interface{}, error) {
+	response := groups.ListResponse{Groups: []groups.Group{}}
+	for _, group := range s.groups.list() {
+		response.Groups = append(response.Groups, group.Group)
+	}
+	return response, nil
+}
+
+func (s *Server) getGroup(params map[string]string, _ []byte) (interface{}, error) {
+	group, err := s.findGroup(params["group_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	serviceUsers, users := s.groupMembers(group)
+	return groups.GetResponse{Group: group.Group, ServiceUsers: serviceUsers, Users: users}, nil
+}
+
+func (s *Server) createGroup(_ map[string]string, body []byte) (interface{}, error) {
+	var request groups.CreateRequest
+	if err := decodeBody(body, &request); err != nil {
+		return nil, err
+	}
+	if request.Name == "" {
+		return nil, invalidField("name", "Name is required")
+	}
+	if err := s.checkGroupName(request.Name, ""); err != nil {
+		return nil, err
+	}
+
+	group := groups.Group{
+		ID:          iamid.GroupID(s.nextID()),
+		Name:        request.Name,
+		Description: request.Description,
+		Roles:       []roles.Role{},
+	}
+	s.groups.put(string(group.ID), &groupRecord{Group: group})
+
+	return groups.CreateResponse{Group: group, ServiceUsers: []groups.ServiceUser{}, Users: []groups.User{}}, nil
+}
+
+func (s *Server) updateGroup(params map[string]string, body []byte) (interface{}, error) {
+	group, err := s.findGroup(params["group_id"])
+	if err != nil {
+		return nil, err
+	}
+	var request updateGroupRequest
+	if err := decodeBody(body, &request); err != nil {
+		return nil, err
+	}
+
+	if request.Name != "" {
+		if err := s.checkGroupName(request.Name, string(group.ID)); err != nil {
+			return nil, err
+		}
+		group.Name = request.Name
+	}
+	if request.Description != nil {
+		group.Description = *request.Description
+	}
+
+	serviceUsers, users := s.groupMembers(group)
+	return groups.UpdateResponse{Group: group.Group, ServiceUsers: serviceUsers, Users: users}, nil
+}
+
+func (s *Server) deleteGroup(params map[string]string, _ []byte) (interface{}, error) {
+	group, err := s.findGroup(params["group_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	s.groups.delete(string(group.ID))
+	for federationID, mappings := range s.groupMappings {
+		s.groupMappings[federationID] = slices.DeleteFunc(mappings, func(m groupmappings.GroupMapping) bool {
+			return m.InternalGroupID == group.ID
+		})
+	}
+	return nil, nil
+}
+
+func (s *Server) groupRoles(params map[string]string) (*[]roles.Role, error) {
+	group, err := s.findGroup(params["group_id"])
+	if err != nil {
+		return nil, err
+	}
+	return &group.Roles, nil
+}
+
+// membersHandler returns a handler, which adds or removes members of a Group.
+func (s *Server) membersHandler(add bool) handler {
+	return func(params map[string]string, body []byte) (interface{}, error) {
+		group, err := s.findGroup(params["group_id"])
+		if err != nil {
+			return nil, err
+		}
+		var request manageUsersRequest
+		if err := decodeBody(body, &request); err != nil {
+			return nil, err
+		}
+		if len(request.KeystoneIds) == 0 {
+			return nil, invalidField("keystone_ids", "At least one user is required")
+		}
+
+		for _, keystoneID := range request.KeystoneIds {
+			if !add {
+				group.removeMember(keystoneID)
+				continue
+			}
+			if !s.isKnownMember(keystoneID) {
+				return nil, notFound(iamerrors.ErrUserOrGroupNotFound, "User "+keystoneID+" not found")
+			}
+			group.addMember(keystoneID)
+		}
+		return nil, nil
+	}
+}
+
+// groupMembers splits members of the Group into Service Users and Users.
+func (s *Server) groupMembers(group *groupRecord) ([]groups.ServiceUser, []groups.User) {
+	serviceUsers, users := []groups.ServiceUser{}, []groups.User{}
+	for _, member := range group.members {
+		if user, ok := s.serviceUsers.get(member); ok {
+			serviceUsers = append(serviceUsers, groups.ServiceUser{ID: user.ID, Enabled: user.Enabled, Name: user.Name})
+			continue
+		}
+		if user, ok := s.userByKeystoneID(member); ok {
+			users = append(users, groups.User{
+				AuthType: user.AuthType, Federation: user.Federation, ID: user.ID, KeystoneID: user.KeystoneID,
+			})
+		}
+	}
+	return serviceUsers, users
+}
+
+// groupsOf returns Groups the User or the Service User is a member of.
+func (s *Server) groupsOf(keystoneID string) []*groupRecord {
+	var result []*groupRecord
+	for _, group := range s.groups.list() {
+		if slices.Contains(group.members, keystoneID) {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+func (s *Server) isKnownMember(keystoneID string) bool {
+	if _, ok := s.serviceUsers.get(keystoneID); ok {
+		return true
+	}
+	_, ok := s.userByKeystoneID(keystoneID)
+	return ok
+}
+
+func (s *Server) userByKeystoneID(keystoneID string) (*userRecord, bool) {
+	for _, user := range s.users.list() {
+		if string(user.KeystoneID) == keystoneID {
+			return user, true
+		}
+	}
+	return nil, false
+}
+
+// checkGroupName checks that no Group except the one with groupID has the name.
+func (s *Server) checkGroupName(name, groupID string) error {
+	for _, group := range s.groups.list() {
+		if group.Name == name && string(group.ID) != groupID {
+			return conflict(iamerrors.ErrGroupAlreadyExists, "Group with name "+name+" already exists")
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateGroupIDs(groupIDs []string) error {
+	for _, groupID := range groupIDs {
+		if _, err := s.findGroup(groupID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) findGroup(groupID string) (*groupRecord, error) {
+	group, ok := s.groups.get(groupID)
+	if !ok {
+		return nil, notFound(iamerrors.ErrGroupNotFound, "Group not found")
+	}
+	return group, nil
+}
@@ -0,0 +1,163 @@
+package iamtest
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/selectel/iam-go/v2/service/roles"
+)
+
+const (
+	accountScope = "account"
+	projectScope = "project"
+)
+
+func defaultRoles() []roles.AvailableRole {
+	return []roles.AvailableRole{
+		{
+			ID:           "iam_admin",
+			Category:     "iam",
+			Description:  "Manages users, groups and federations of the account",
+			Scopes:       []string{accountScope},
+			SubjectTypes: []string{"user", "service_user", "group"},
+		},
+		{
+			ID:                    "member",
+			Category:              "general",
+			Description:           "Manages all resources except billing and users",
+			Scopes:                []string{accountScope, projectScope},
+			SubjectTypes:          []string{"user", "service_user", "group"},
+			AvailableInOnboarding: true,
+		},
+		{
+			ID:                    "reader",
+			Category:              "general",
+			Description:           "Views all resources",
+			Scopes:                []string{accountScope, projectScope},
+			SubjectTypes:          []string{"user", "service_user", "group"},
+			AvailableInOnboarding: true,
+		},
+		{
+			ID:           "billing",
+			Category:     "billing",
+			Description:  "Manages payments and documents",
+			Scopes:       []string{accountScope},
+			SubjectTypes: []string{"user", "group"},
+		},
+	}
+}
+
+// AddRole adds a role to the catalog of roles available for assignment.
+// The catalog contains iam_admin, member, reader and billing roles by default.
+func (s *Server) AddRole(role roles.AvailableRole) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.roles = append(s.roles, role)
+}
+
+func (s *Server) roleRoutes() []route {
+	return []route{
+		newRoute(http.MethodGet, "iam/v1/roles", http.StatusOK, s.listRoles),
+	}
+}
+
+func (s *Server) listRoles(map[string]string, []byte) (interface{}, error) {
+	return roles.ListResponse{Roles: append([]roles.AvailableRole{}, s.roles...)}, nil
+}
+
+// validateRoles checks that the roles exist in the catalog and are assigned in a supported scope.
+func (s *Server) validateRoles(assigned []roles.Role) error {
+	for i, role := range assigned {
+		field := fmt.Sprintf("roles[%d]", i)
+
+		available, ok := s.findRole(role.RoleName)
+		if !ok {
+			return invalidField(field+".role_name", "Unknown role "+role.RoleName)
+		}
+		if !slices.Contains(available.Scopes, role.Scope) {
+			return invalidField(field+".scope", "Role "+role.RoleName+" can't be assigned in scope "+role.Scope)
+		}
+		if role.Scope == projectScope && role.ProjectID == "" {
+			return invalidField(field+".project_id", "Project ID is required for project scope")
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) findRole(name string) (roles.AvailableRole, bool) {
+	for _, role := range s.roles {
+		if role.ID == name {
+			return role, true
+		}
+	}
+	return roles.AvailableRole{}, false
+}
+
+// assignRoles returns existing roles with new ones added. Duplicates are ignored.
+func assignRoles(existing, assigned []roles.Role) []roles.Role {
+	result := append([]roles.Role{}, existing...)
+	for _, role := range assigned {
+		if !slices.Contains(result, role) {
+			result = append(result, role)
+		}
+	}
+	return result
+}
+
+// unassignRoles returns existing roles without removed ones.
+func unassignRoles(existing, removed []roles.Role) []roles.Role {
+	result := []roles.Role{}
+	for _, role := range existing {
+		if !slices.Contains(removed, role) {
+			result = append(result, role)
+		}
+	}
+	return result
+}
+
+type manageRolesRequest struct {
+	Roles []roles.Role `json:"roles"`
+}
+
+// decodeRoles decodes and validates the body of requests managing roles.
+func (s *Server) decodeRoles(body []byte, validate bool) ([]roles.Role, error) {
+	var request manageRolesRequest
+	if err := decodeBody(body, &request); err != nil {
+		return nil, err
+	}
+	if len(request.Roles) == 0 {
+		return nil, invalidField("roles", "At least one role is required")
+	}
+	if validate {
+		if err := s.validateRoles(request.Roles); err != nil {
+			return nil, err
+		}
+	}
+
+	return request.Roles, nil
+}
+
+// rolesHandler returns a handler, which assigns or unassigns roles of a User, a Service User or a Group.
+// find returns the roles of the subject of the request.
+func (s *Server) rolesHandler(find func(params map[string]string) (*[]roles.Role, error), assign bool) handler {
+	return func(params map[string]string, body []byte) (interface{}, error) {
+		subjectRoles, err := find(params)
+		if err != nil {
+			return nil, err
+		}
+		changed, err := s.decodeRoles(body, assign)
+		if err != nil {
+			return nil, err
+		}
+
+		if assign {
+			*subjectRoles = assignRoles(*subjectRoles, changed)
+		} else {
+			*subjectRoles = unassignRoles(*subjectRoles, changed)
+		}
+		return nil, nil
+	}
+}
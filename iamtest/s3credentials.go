@@ -0,0 +1,103 @@
+package iamtest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/service/s3credentials"
+)
+
+type createCredentialRequest struct {
+	Name      string `json:"name"`
+	ProjectID string `json:"project_id"`
+}
+
+// AddCredential adds S3 Credentials of a Service User. Empty AccessKey and SecretKey are generated.
+func (s *Server) AddCredential(
+	userID iamid.UserID, credential s3credentials.CreateResponse,
+) s3credentials.CreateResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.putCredential(string(userID), &credential)
+	return credential
+}
+
+func (s *Server) credentialRoutes() []route {
+	return []route{
+		newRoute(http.MethodGet, "iam/v1/service_users/{user_id}/credentials", http.StatusOK, s.listCredentials),
+		newRoute(http.MethodPost, "iam/v1/service_users/{user_id}/credentials", http.StatusCreated, s.createCredential),
+		newRoute(http.MethodDelete, "iam/v1/service_users/{user_id}/credentials/{access_key}", http.StatusNoContent,
+			s.deleteCredential),
+	}
+}
+
+func (s *Server) listCredentials(params map[string]string, _ []byte) (interface{}, error) {
+	user, err := s.findServiceUser(params["user_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	response := s3credentials.ListResponse{Credentials: []s3credentials.Credential{}}
+	if credentials, ok := s.credentials[string(user.ID)]; ok {
+		for _, credential := range credentials.list() {
+			response.Credentials = append(response.Credentials, credential.Credential)
+		}
+	}
+	return response, nil
+}
+
+func (s *Server) createCredential(params map[string]string, body []byte) (interface{}, error) {
+	user, err := s.findServiceUser(params["user_id"])
+	if err != nil {
+		return nil, err
+	}
+	var request createCredentialRequest
+	if err := decodeBody(body, &request); err != nil {
+		return nil, err
+	}
+	if request.Name == "" {
+		return nil, invalidField("name", "Name is required")
+	}
+	if request.ProjectID == "" {
+		return nil, invalidField("project_id", "Project ID is required")
+	}
+
+	credential := &s3credentials.CreateResponse{
+		Credential: s3credentials.Credential{Name: request.Name, ProjectID: iamid.ProjectID(request.ProjectID)},
+	}
+	s.putCredential(string(user.ID), credential)
+
+	return credential, nil
+}
+
+func (s *Server) deleteCredential(params map[string]string, _ []byte) (interface{}, error) {
+	user, err := s.findServiceUser(params["user_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, ok := s.credentials[string(user.ID)]
+	if !ok || !credentials.delete(params["access_key"]) {
+		return nil, notFound(iamerrors.ErrCredentialNotFound, "Credentials not found")
+	}
+	return nil, nil
+}
+
+func (s *Server) putCredential(userID string, credential *s3credentials.CreateResponse) {
+	if credential.AccessKey == "" {
+		s.sequence++
+		credential.AccessKey = iamid.AccessKey(fmt.Sprintf("%020X", s.sequence))
+	}
+	if credential.SecretKey == "" {
+		s.sequence++
+		credential.SecretKey = fmt.Sprintf("%040x", s.sequence)
+	}
+
+	if _, ok := s.credentials[userID]; !ok {
+		s.credentials[userID] = newCollection[*s3credentials.CreateResponse]()
+	}
+	s.credentials[userID].put(string(credential.AccessKey), credential)
+}
@@ -0,0 +1,361 @@
+package iamtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+
+	iam "github.com/selectel/iam-go/v2"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/service/federations/saml"
+	"github.com/selectel/iam-go/v2/service/federations/saml/certificates"
+	"github.com/selectel/iam-go/v2/service/federations/saml/groupmappings"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/s3credentials"
+)
+
+const (
+	// Token is the Keystone token accepted by Server until it's changed with SetToken.
+	Token = "iamtest-token"
+
+	// AccountID is the account of all resources of Server.
+	AccountID = "123456"
+)
+
+// Server is an in-memory fake of the IAM API. It is safe for concurrent use.
+type Server struct {
+	// URL of the Server, e.g. "http://127.0.0.1:41235".
+	URL string
+
+	server *httptest.Server
+	routes []route
+
+	mu            sync.Mutex
+	token         string
+	sequence      int
+	users         *collection[*userRecord]
+	serviceUsers  *collection[*serviceUserRecord]
+	groups        *collection[*groupRecord]
+	roles         []roles.AvailableRole
+	credentials   map[string]*collection[*s3credentials.CreateResponse]
+	federations   *collection[*saml.Federation]
+	certificates  map[string]*collection[*certificates.Certificate]
+	groupMappings map[string][]groupmappings.GroupMapping
+	failures      []*failure
+	requests      []Request
+}
+
+// Request represents a request received by Server.
+type Request struct {
+	Method string
+	Path   string
+
+	// PathTemplate is the template of the path as in iammiddleware.Operation, e.g. "iam/v1/users/{user_id}".
+	// It is empty for unknown paths.
+	PathTemplate string
+
+	Body []byte
+}
+
+// NewServer starts a new Server with the default catalog of roles and no other resources.
+// The Server must be stopped with Close.
+func NewServer() *Server {
+	s := &Server{
+		token:         Token,
+		users:         newCollection[*userRecord](),
+		serviceUsers:  newCollection[*serviceUserRecord](),
+		groups:        newCollection[*groupRecord](),
+		roles:         defaultRoles(),
+		credentials:   make(map[string]*collection[*s3credentials.CreateResponse]),
+		federations:   newCollection[*saml.Federation](),
+		certificates:  make(map[string]*collection[*certificates.Certificate]),
+		groupMappings: make(map[string][]groupmappings.GroupMapping),
+	}
+	s.routes = s.allRoutes()
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL
+
+	return s
+}
+
+// Close stops the Server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a new iam.Client, which sends requests to the Server.
+// Options are applied after the ones setting the URL and the token, so they can be overridden.
+func (s *Server) Client(opts ...iam.Option) (*iam.Client, error) {
+	s.mu.Lock()
+	token := s.token
+	s.mu.Unlock()
+
+	return iam.New(append([]iam.Option{
+		iam.WithAPIUrl(s.URL),
+		iam.WithAuthOpts(&iam.AuthOpts{KeystoneToken: token}),
+		iam.WithCustomHTTPClient(s.server.Client()),
+	}, opts...)...)
+}
+
+// SetToken changes the Keystone token accepted by the Server, e.g. to emulate the expiry of a token.
+func (s *Server) SetToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = token
+}
+
+// Requests returns all requests received by the Server in the order of arrival.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	rt, params, methodAllowed := s.match(r.Method, path)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	request := Request{Method: r.Method, Path: path, Body: body}
+	if rt != nil {
+		request.PathTemplate = rt.template
+	}
+	s.requests = append(s.requests, request)
+
+	switch {
+	case rt == nil && methodAllowed:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	case rt == nil:
+		http.NotFound(w, r)
+		return
+	}
+
+	if f := s.takeFailure(r.Method, rt.template); f != nil {
+		f.write(w)
+		return
+	}
+	if r.Header.Get("X-Auth-Token") != s.token {
+		writeError(w, &apiError{
+			status: http.StatusUnauthorized, err: iamerrors.ErrUnauthorized, message: "Invalid token",
+		})
+		return
+	}
+
+	output, err := rt.handle(params, body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, rt.status, output)
+}
+
+// nextID returns a new identifier of a resource.
+func (s *Server) nextID() string {
+	s.sequence++
+	return fmt.Sprintf("%032x", s.sequence)
+}
+
+// route represents an endpoint of the IAM API.
+type route struct {
+	method   string
+	template string
+	segments []string
+	status   int
+	handle   handler
+}
+
+// handler handles a request with the state of the Server locked.
+// It returns the body of a successful response or an error.
+type handler func(params map[string]string, body []byte) (interface{}, error)
+
+func newRoute(method, template string, status int, handle handler) route {
+	return route{
+		method:   method,
+		template: template,
+		segments: strings.Split(template, "/"),
+		status:   status,
+		handle:   handle,
+	}
+}
+
+func (s *Server) allRoutes() []route {
+	var routes []route
+	routes = append(routes, s.userRoutes()...)
+	routes = append(routes, s.serviceUserRoutes()...)
+	routes = append(routes, s.groupRoutes()...)
+	routes = append(routes, s.roleRoutes()...)
+	routes = append(routes, s.credentialRoutes()...)
+	routes = append(routes, s.federationRoutes()...)
+	routes = append(routes, s.certificateRoutes()...)
+	routes = append(routes, s.groupMappingRoutes()...)
+
+	return routes
+}
+
+// match returns the route of the request and its path parameters.
+// If there is no route, it reports whether the path exists for another method.
+func (s *Server) match(method, path string) (*route, map[string]string, bool) {
+	segments := strings.Split(path, "/")
+	pathExists := false
+	for i := range s.routes {
+		params, ok := matchSegments(s.routes[i].segments, segments)
+		if !ok {
+			continue
+		}
+		if s.routes[i].method == method {
+			return &s.routes[i], params, true
+		}
+		pathExists = true
+	}
+
+	return nil, nil, pathExists
+}
+
+func matchSegments(template, segments []string) (map[string]string, bool) {
+	if len(template) != len(segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, segment := range template {
+		switch {
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			params[strings.Trim(segment, "{}")] = segments[i]
+		case segment != segments[i]:
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// apiError represents an error response of the IAM API.
+type apiError struct {
+	status  int
+	err     error
+	message string
+	details []errorDetail
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error() + ": " + e.message
+}
+
+type errorResponse struct {
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Details []errorDetail `json:"details,omitempty"`
+}
+
+type errorDetail struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+func notFound(err error, message string) *apiError {
+	return &apiError{status: http.StatusNotFound, err: err, message: message}
+}
+
+func conflict(err error, message string) *apiError {
+	return &apiError{status: http.StatusConflict, err: err, message: message}
+}
+
+func invalidField(field, reason string) *apiError {
+	return &apiError{
+		status:  http.StatusBadRequest,
+		err:     iamerrors.ErrRequestValidationError,
+		message: "Request validation failed",
+		details: []errorDetail{{Field: field, Reason: reason}},
+	}
+}
+
+func decodeBody(body []byte, v interface{}) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return invalidField("body", err.Error())
+	}
+	return nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		apiErr = &apiError{
+			status: http.StatusInternalServerError, err: iamerrors.ErrInternalServerError, message: err.Error(),
+		}
+	}
+
+	writeJSON(w, apiErr.status, errorResponse{
+		Code:    apiErr.err.Error(),
+		Message: apiErr.message,
+		Details: apiErr.details,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	if body == nil {
+		w.WriteHeader(status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// collection keeps resources by their identifiers in the order of creation.
+type collection[T any] struct {
+	ids   []string
+	items map[string]T
+}
+
+func newCollection[T any]() *collection[T] {
+	return &collection[T]{items: make(map[string]T)}
+}
+
+func (c *collection[T]) get(id string) (T, bool) {
+	item, ok := c.items[id]
+	return item, ok
+}
+
+func (c *collection[T]) put(id string, item T) {
+	if _, ok := c.items[id]; !ok {
+		c.ids = append(c.ids, id)
+	}
+	c.items[id] = item
+}
+
+func (c *collection[T]) delete(id string) bool {
+	if _, ok := c.items[id]; !ok {
+		return false
+	}
+
+	delete(c.items, id)
+	c.ids = slices.DeleteFunc(c.ids, func(existing string) bool { return existing == id })
+	return true
+}
+
+func (c *collection[T]) list() []T {
+	items := make([]T, 0, len(c.ids))
+	for _, id := range c.ids {
+		items = append(items, c.items[id])
+	}
+	return items
+}
@@ -0,0 +1,410 @@
+package iamtest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iam "github.com/selectel/iam-go/v2"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/service/federations/saml"
+	"github.com/selectel/iam-go/v2/service/federations/saml/certificates"
+	"github.com/selectel/iam-go/v2/service/federations/saml/groupmappings"
+	"github.com/selectel/iam-go/v2/service/groups"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/serviceusers"
+	"github.com/selectel/iam-go/v2/service/users"
+)
+
+const (
+	testEmail    = "test@example.com"
+	testPassword = "test-password"
+)
+
+func newTestClient(t *testing.T, opts ...iam.Option) (*Server, *iam.Client) {
+	t.Helper()
+
+	server := NewServer()
+	t.Cleanup(server.Close)
+
+	client, err := server.Client(opts...)
+	require.NoError(t, err)
+
+	return server, client
+}
+
+func TestUsers(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	_, client := newTestClient(t)
+	ctx := context.Background()
+
+	created, err := client.Users.Create(ctx, users.CreateRequest{
+		Email: testEmail,
+		Roles: []roles.Role{{Scope: "account", RoleName: "member"}},
+	})
+	require.NoError(err)
+	assert.Equal(users.Local, created.AuthType)
+
+	_, err = client.Users.Create(ctx, users.CreateRequest{Email: testEmail})
+	require.ErrorIs(err, iamerrors.ErrUserAlreadyExists)
+
+	projectReader := roles.Role{Scope: "project", RoleName: "reader", ProjectID: "1"}
+	err = client.Users.AssignRoles(ctx, created.ID, []roles.Role{projectReader})
+	require.NoError(err)
+	err = client.Users.UnassignRoles(ctx, created.ID, []roles.Role{{Scope: "account", RoleName: "member"}})
+	require.NoError(err)
+
+	user, err := client.Users.Get(ctx, created.ID)
+	require.NoError(err)
+	assert.Equal([]roles.Role{projectReader}, user.Roles)
+
+	require.NoError(client.Users.Delete(ctx, created.ID))
+	_, err = client.Users.Get(ctx, created.ID)
+	require.ErrorIs(err, iamerrors.ErrUserNotFound)
+	assert.True(iamerrors.IsNotFound(err))
+}
+
+func TestRoleValidation(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server, client := newTestClient(t)
+	user := server.AddUser(testEmail, users.User{})
+
+	err := client.Users.AssignRoles(context.Background(), user.ID, []roles.Role{{Scope: "project", RoleName: "member"}})
+
+	require.ErrorIs(err, iamerrors.ErrRequestValidationError)
+	var iamErr iamerrors.Error
+	require.ErrorAs(err, &iamErr)
+	assert.Equal([]iamerrors.FieldError{
+		{Field: "roles[0].project_id", Reason: "Project ID is required for project scope"},
+	}, iamErr.Fields)
+}
+
+func TestGroupMembership(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server, client := newTestClient(t)
+	ctx := context.Background()
+	user := server.AddUser(testEmail, users.User{})
+	serviceUser := server.AddServiceUser(serviceusers.ServiceUser{Name: "robot", Enabled: true}, testPassword)
+
+	group, err := client.Groups.Create(ctx, groups.CreateRequest{Name: "developers"})
+	require.NoError(err)
+	_, err = client.Groups.Create(ctx, groups.CreateRequest{Name: "developers"})
+	require.ErrorIs(err, iamerrors.ErrGroupAlreadyExists)
+
+	members := []iamid.KeystoneID{user.KeystoneID, iamid.KeystoneIDOfServiceUser(serviceUser.ID)}
+	require.NoError(client.Groups.AddUsers(ctx, group.ID, members))
+	err = client.Groups.AddUsers(ctx, group.ID, []iamid.KeystoneID{"unknown"})
+	require.ErrorIs(err, iamerrors.ErrUserOrGroupNotFound)
+
+	got, err := client.Groups.Get(ctx, group.ID)
+	require.NoError(err)
+	assert.Equal([]groups.User{{AuthType: users.Local, ID: user.ID, KeystoneID: user.KeystoneID}}, got.Users)
+	assert.Equal([]groups.ServiceUser{{ID: serviceUser.ID, Enabled: true, Name: "robot"}}, got.ServiceUsers)
+
+	serviceUserInfo, err := client.ServiceUsers.Get(ctx, serviceUser.ID)
+	require.NoError(err)
+	require.Len(serviceUserInfo.Groups, 1)
+	assert.Equal(group.ID, serviceUserInfo.Groups[0].ID)
+
+	require.NoError(client.Users.Delete(ctx, user.ID))
+	got, err = client.Groups.Get(ctx, group.ID)
+	require.NoError(err)
+	assert.Empty(got.Users)
+}
+
+//nolint:funlen // This is a test function.
+func TestSetRolesPartialChange(t *testing.T) {
+	member := roles.Role{Scope: "account", RoleName: "member"}
+	reader := roles.Role{Scope: "account", RoleName: "reader"}
+
+	tests := []struct {
+		name         string
+		pathTemplate string
+		setRoles     func(ctx context.Context, server *Server, client *iam.Client) (*roles.Change, error)
+	}{
+		{
+			name:         "Test Users SetRoles",
+			pathTemplate: "iam/v1/users/{user_id}/roles",
+			setRoles: func(ctx context.Context, server *Server, client *iam.Client) (*roles.Change, error) {
+				user := server.AddUser(testEmail, users.User{Roles: []roles.Role{member}})
+				return client.Users.SetRoles(ctx, user.ID, []roles.Role{reader})
+			},
+		},
+		{
+			name:         "Test ServiceUsers SetRoles",
+			pathTemplate: "iam/v1/service_users/{user_id}/roles",
+			setRoles: func(ctx context.Context, server *Server, client *iam.Client) (*roles.Change, error) {
+				serviceUser := server.AddServiceUser(
+					serviceusers.ServiceUser{Name: "robot", Roles: []roles.Role{member}}, testPassword,
+				)
+				return client.ServiceUsers.SetRoles(ctx, serviceUser.ID, []roles.Role{reader})
+			},
+		},
+		{
+			name:         "Test Groups SetRoles",
+			pathTemplate: "iam/v1/groups/{group_id}/roles",
+			setRoles: func(ctx context.Context, server *Server, client *iam.Client) (*roles.Change, error) {
+				group := server.AddGroup(groups.Group{Name: "developers", Roles: []roles.Role{member}})
+				return client.Groups.SetRoles(ctx, group.ID, []roles.Role{reader})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			server, client := newTestClient(t)
+			server.Fail(Failure{
+				Method: http.MethodDelete, PathTemplate: tt.pathTemplate,
+				StatusCode: http.StatusForbidden, Err: iamerrors.ErrForbidden,
+			})
+
+			change, err := tt.setRoles(context.Background(), server, client)
+
+			require.ErrorIs(err, iamerrors.ErrForbidden)
+			require.NotNil(change)
+			assert.Equal(&roles.Change{Assigned: []roles.Role{reader}}, change)
+		})
+	}
+}
+
+func TestSetMembersPartialChange(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server, client := newTestClient(t)
+	user := server.AddUser(testEmail, users.User{})
+	serviceUser := server.AddServiceUser(serviceusers.ServiceUser{Name: "robot"}, testPassword)
+	group := server.AddGroup(groups.Group{Name: "developers"}, user.KeystoneID)
+	server.Fail(Failure{
+		Method: http.MethodDelete, PathTemplate: "iam/v1/groups/{group_id}/users",
+		StatusCode: http.StatusForbidden, Err: iamerrors.ErrForbidden,
+	})
+
+	serviceUserKeystoneID := iamid.KeystoneIDOfServiceUser(serviceUser.ID)
+	change, err := client.Groups.SetMembers(context.Background(), group.ID, []iamid.KeystoneID{serviceUserKeystoneID})
+
+	require.ErrorIs(err, iamerrors.ErrForbidden)
+	require.NotNil(change)
+	assert.Equal(&groups.MembersChange{Added: []iamid.KeystoneID{serviceUserKeystoneID}}, change)
+}
+
+func TestServiceUsersAndCredentials(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server, client := newTestClient(t)
+	ctx := context.Background()
+
+	created, err := client.ServiceUsers.Create(ctx, serviceusers.CreateRequest{
+		Name:     "robot",
+		Password: testPassword,
+		Enabled:  true,
+		Roles:    []roles.Role{{Scope: "account", RoleName: "member"}},
+	})
+	require.NoError(err)
+
+	_, err = client.ServiceUsers.Update(ctx, created.ID, serviceusers.UpdateRequest{Password: "new-password"})
+	require.NoError(err)
+	password, ok := server.ServiceUserPassword(created.ID)
+	require.True(ok)
+	assert.Equal("new-password", password)
+
+	credential, err := client.S3Credentials.Create(ctx, created.ID, "backup", "project-id")
+	require.NoError(err)
+	assert.NotEmpty(credential.SecretKey)
+
+	list, err := client.S3Credentials.List(ctx, created.ID)
+	require.NoError(err)
+	assert.Equal([]iamid.AccessKey{credential.AccessKey}, []iamid.AccessKey{list.Credentials[0].AccessKey})
+
+	require.NoError(client.S3Credentials.Delete(ctx, created.ID, credential.AccessKey))
+	err = client.S3Credentials.Delete(ctx, created.ID, credential.AccessKey)
+	require.ErrorIs(err, iamerrors.ErrCredentialNotFound)
+
+	require.NoError(client.ServiceUsers.Delete(ctx, created.ID))
+	_, err = client.S3Credentials.List(ctx, created.ID)
+	require.ErrorIs(err, iamerrors.ErrUserNotFound)
+}
+
+func TestFederations(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server, client := newTestClient(t)
+	ctx := context.Background()
+	group := server.AddGroup(groups.Group{Name: "developers"})
+
+	federation, err := client.SAMLFederations.Create(ctx, saml.CreateRequest{
+		Name: "sso", Alias: "corp", Issuer: "https://idp.example.com", SSOUrl: "https://idp.example.com/sso",
+		SessionMaxAgeHours: 24,
+	})
+	require.NoError(err)
+
+	preview, err := client.SAMLFederations.Preview(ctx, "corp")
+	require.NoError(err)
+	assert.Equal(federation.ID, preview.ID)
+
+	// Fields sent in a PATCH are applied, even if they are empty.
+	description, issuer, sessionMaxAgeHours := "", "", 0
+	err = client.SAMLFederations.Patch(ctx, federation.ID, saml.PatchRequest{
+		Description: &description, Issuer: &issuer, SessionMaxAgeHours: &sessionMaxAgeHours,
+	})
+	require.NoError(err)
+	patched, err := client.SAMLFederations.Get(ctx, federation.ID)
+	require.NoError(err)
+	assert.Empty(patched.Issuer)
+	assert.Zero(patched.SessionMaxAgeHours)
+	assert.Equal(federation.SSOUrl, patched.SSOUrl)
+
+	certificate, err := client.SAMLFederations.Certificates.Create(ctx, federation.ID, certificates.CreateRequest{
+		Name: "main", Data: "not a PEM certificate",
+	})
+	require.NoError(err)
+	assert.Equal(federation.ID, certificate.FederationID)
+
+	require.NoError(client.SAMLFederations.GroupMappings.Add(ctx, federation.ID, group.ID, "external"))
+	exists, err := client.SAMLFederations.GroupMappings.Exists(ctx, federation.ID, group.ID, "external")
+	require.NoError(err)
+	assert.True(exists)
+	exists, err = client.SAMLFederations.GroupMappings.Exists(ctx, federation.ID, group.ID, "unknown")
+	require.NoError(err)
+	assert.False(exists)
+
+	require.NoError(client.SAMLFederations.Delete(ctx, federation.ID))
+	exists, err = client.SAMLFederations.Exists(ctx, federation.ID)
+	require.NoError(err)
+	assert.False(exists)
+	_, err = client.SAMLFederations.Certificates.List(ctx, federation.ID)
+	require.ErrorIs(err, iamerrors.ErrFederationNotFound)
+	_, err = client.SAMLFederations.GroupMappings.List(ctx, federation.ID)
+	require.ErrorIs(err, iamerrors.ErrFederationNotFound)
+}
+
+//nolint:funlen // This is a test function.
+func TestFail(t *testing.T) {
+	tests := []struct {
+		name          string
+		failure       Failure
+		opts          []iam.Option
+		expectedError error
+		expectedCalls int
+	}{
+		{
+			name: "Test Fail with an error code",
+			failure: Failure{
+				Method: http.MethodGet, PathTemplate: "iam/v1/groups/{group_id}",
+				StatusCode: http.StatusForbidden, Err: iamerrors.ErrForbidden, Times: 1,
+			},
+			expectedError: iamerrors.ErrForbidden,
+			expectedCalls: 1,
+		},
+		{
+			name:          "Test Fail with a page of a proxy",
+			failure:       Failure{StatusCode: http.StatusBadGateway},
+			expectedError: iamerrors.ErrInternalServerError,
+			expectedCalls: 1,
+		},
+		{
+			name: "Test Fail is retried",
+			failure: Failure{
+				StatusCode: http.StatusTooManyRequests, Err: iamerrors.ErrTooManyRequests, Times: 2,
+			},
+			opts: []iam.Option{
+				iam.WithRetryPolicy(iam.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}),
+			},
+			expectedError: nil,
+			expectedCalls: 3,
+		},
+		{
+			name: "Test Fail of another path",
+			failure: Failure{
+				PathTemplate: "iam/v1/users", StatusCode: http.StatusServiceUnavailable,
+			},
+			expectedError: nil,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			server, client := newTestClient(t, tt.opts...)
+			group := server.AddGroup(groups.Group{Name: "developers"})
+			server.Fail(tt.failure)
+
+			_, err := client.Groups.Get(context.Background(), group.ID)
+
+			if tt.expectedError == nil {
+				require.NoError(err)
+			} else {
+				require.ErrorIs(err, tt.expectedError)
+			}
+			assert.Len(server.Requests(), tt.expectedCalls)
+		})
+	}
+}
+
+func TestSetToken(t *testing.T) {
+	require := require.New(t)
+
+	server, client := newTestClient(t)
+	server.SetToken("new-token")
+
+	_, err := client.Roles.List(context.Background())
+	require.ErrorIs(err, iamerrors.ErrAuthTokenUnathorized)
+
+	client, err = server.Client()
+	require.NoError(err)
+	list, err := client.Roles.List(context.Background())
+	require.NoError(err)
+	require.NotEmpty(list.Roles)
+}
+
+func TestUnknownFederation(t *testing.T) {
+	require := require.New(t)
+
+	_, client := newTestClient(t)
+	ctx := context.Background()
+
+	_, err := client.SAMLFederations.Get(ctx, "unknown")
+	require.ErrorIs(err, iamerrors.ErrFederationNotFound)
+
+	_, err = client.SAMLFederations.Certificates.Get(ctx, "unknown", "unknown")
+	require.ErrorIs(err, iamerrors.ErrFederationNotFound)
+
+	err = client.SAMLFederations.GroupMappings.Update(ctx, "unknown", groupmappings.GroupMappingsRequest{})
+	require.ErrorIs(err, iamerrors.ErrFederationNotFound)
+}
+
+func TestNestedCallsWithMaxInFlight(t *testing.T) {
+	require := require.New(t)
+
+	server, client := newTestClient(t, iam.WithRateLimit(iam.RateLimit{MaxInFlight: 1}))
+	server.AddServiceUser(serviceusers.ServiceUser{Name: "robot", Enabled: true}, testPassword)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := client.ServiceUsers.ForEach(ctx, func(user serviceusers.ServiceUser) error {
+		_, err := client.ServiceUsers.Get(ctx, user.ID)
+		return err
+	})
+
+	require.NoError(err)
+}
@@ -0,0 +1,218 @@
+package iamtest
+
+import (
+	"net/http"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/serviceusers"
+)
+
+// minPasswordLength represents the minimal length of a password of a Service User.
+const minPasswordLength = 8
+
+type serviceUserRecord struct {
+	serviceusers.ServiceUser
+	password string
+}
+
+type createServiceUserRequest struct {
+	Enabled  bool         `json:"enabled"`
+	Name     string       `json:"name"`
+	Password string       `json:"password"`
+	GroupIDs []string     `json:"group_ids"`
+	Roles    []roles.Role `json:"roles"`
+}
+
+type updateServiceUserRequest struct {
+	Enabled  *bool  `json:"enabled"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// AddServiceUser adds a Service User with the given password. An empty ID is generated.
+// The ID of a Service User is also its Keystone ID, e.g. in Groups.
+func (s *Server) AddServiceUser(user serviceusers.ServiceUser, password string) serviceusers.ServiceUser {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user.ID == "" {
+		user.ID = iamid.UserID(s.nextID())
+	}
+	if user.Roles == nil {
+		user.Roles = []roles.Role{}
+	}
+	s.serviceUsers.put(string(user.ID), &serviceUserRecord{ServiceUser: user, password: password})
+
+	return user
+}
+
+// ServiceUserPassword returns the current password of a Service User, e.g. to check that it was updated.
+func (s *Server) ServiceUserPassword(userID iamid.UserID) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.serviceUsers.get(string(userID))
+	if !ok {
+		return "", false
+	}
+	return user.password, true
+}
+
+func (s *Server) serviceUserRoutes() []route {
+	return []route{
+		newRoute(http.MethodGet, "iam/v1/service_users", http.StatusOK, s.listServiceUsers),
+		newRoute(http.MethodPost, "iam/v1/service_users", http.StatusCreated, s.createServiceUser),
+		newRoute(http.MethodGet, "iam/v1/service_users/{user_id}", http.StatusOK, s.getServiceUser),
+		newRoute(http.MethodPatch, "iam/v1/service_users/{user_id}", http.StatusOK, s.updateServiceUser),
+		newRoute(http.MethodDelete, "iam/v1/service_users/{user_id}", http.StatusNoContent, s.deleteServiceUser),
+		newRoute(http.MethodPut, "iam/v1/service_users/{user_id}/roles", http.StatusNoContent,
+			s.rolesHandler(s.serviceUserRoles, true)),
+		newRoute(http.MethodDelete, "iam/v1/service_users/{user_id}/roles", http.StatusNoContent,
+			s.rolesHandler(s.serviceUserRoles, false)),
+	}
+}
+
+func (s *Server) listServiceUsers(map[string]string, []byte) (interface{}, error) {
+	response := serviceusers.ListResponse{Users: []serviceusers.ServiceUser{}}
+	for _, user := range s.serviceUsers.list() {
+		response.Users = append(response.Users, user.ServiceUser)
+	}
+	return response, nil
+}
+
+func (s *Server) getServiceUser(params map[string]string, _ []byte) (interface{}, error) {
+	user, err := s.findServiceUser(params["user_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	return serviceusers.GetResponse{ServiceUser: user.ServiceUser, Groups: s.serviceUserGroups(string(user.ID))}, nil
+}
+
+func (s *Server) createServiceUser(_ map[string]string, body []byte) (interface{}, error) {
+	var request createServiceUserRequest
+	if err := decodeBody(body, &request); err != nil {
+		return nil, err
+	}
+	if err := s.validateNewServiceUser(request); err != nil {
+		return nil, err
+	}
+
+	user := serviceusers.ServiceUser{
+		ID:      iamid.UserID(s.nextID()),
+		Enabled: request.Enabled,
+		Name:    request.Name,
+		Roles:   assignRoles(nil, request.Roles),
+	}
+	s.serviceUsers.put(string(user.ID), &serviceUserRecord{ServiceUser: user, password: request.Password})
+	for _, groupID := range request.GroupIDs {
+		group, _ := s.groups.get(groupID)
+		group.addMember(string(user.ID))
+	}
+
+	return serviceusers.CreateResponse{ServiceUser: user}, nil
+}
+
+func (s *Server) validateNewServiceUser(request createServiceUserRequest) error {
+	if request.Name == "" {
+		return invalidField("name", "Name is required")
+	}
+	if err := s.checkServiceUserName(request.Name, ""); err != nil {
+		return err
+	}
+	if len(request.Password) < minPasswordLength {
+		return invalidField("password", "Password must be at least 8 characters long")
+	}
+	if len(request.Roles) == 0 {
+		return invalidField("roles", "At least one role is required")
+	}
+	if err := s.validateRoles(request.Roles); err != nil {
+		return err
+	}
+
+	return s.validateGroupIDs(request.GroupIDs)
+}
+
+func (s *Server) updateServiceUser(params map[string]string, body []byte) (interface{}, error) {
+	user, err := s.findServiceUser(params["user_id"])
+	if err != nil {
+		return nil, err
+	}
+	var request updateServiceUserRequest
+	if err := decodeBody(body, &request); err != nil {
+		return nil, err
+	}
+
+	if request.Name != "" {
+		if err := s.checkServiceUserName(request.Name, string(user.ID)); err != nil {
+			return nil, err
+		}
+		user.Name = request.Name
+	}
+	if request.Password != "" {
+		if len(request.Password) < minPasswordLength {
+			return nil, invalidField("password", "Password must be at least 8 characters long")
+		}
+		user.password = request.Password
+	}
+	if request.Enabled != nil {
+		user.Enabled = *request.Enabled
+	}
+
+	return serviceusers.UpdateResponse{ServiceUser: user.ServiceUser, Groups: s.serviceUserGroups(string(user.ID))}, nil
+}
+
+func (s *Server) deleteServiceUser(params map[string]string, _ []byte) (interface{}, error) {
+	user, err := s.findServiceUser(params["user_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	s.serviceUsers.delete(string(user.ID))
+	delete(s.credentials, string(user.ID))
+	for _, group := range s.groupsOf(string(user.ID)) {
+		group.removeMember(string(user.ID))
+	}
+	return nil, nil
+}
+
+func (s *Server) serviceUserRoles(params map[string]string) (*[]roles.Role, error) {
+	user, err := s.findServiceUser(params["user_id"])
+	if err != nil {
+		return nil, err
+	}
+	return &user.Roles, nil
+}
+
+// checkServiceUserName checks that no Service User except the one with userID has the name.
+func (s *Server) checkServiceUserName(name, userID string) error {
+	for _, user := range s.serviceUsers.list() {
+		if user.Name == name && string(user.ID) != userID {
+			return conflict(iamerrors.ErrUserAlreadyExists, "Service User with name "+name+" already exists")
+		}
+	}
+	return nil
+}
+
+func (s *Server) serviceUserGroups(userID string) []serviceusers.Group {
+	result := []serviceusers.Group{}
+	for _, group := range s.groupsOf(userID) {
+		result = append(result, serviceusers.Group{
+			ID:          group.ID,
+			Name:        group.Name,
+			Description: group.Description,
+			Roles:       group.Roles,
+		})
+	}
+	return result
+}
+
+func (s *Server) findServiceUser(userID string) (*serviceUserRecord, error) {
+	user, ok := s.serviceUsers.get(userID)
+	if !ok {
+		return nil, notFound(iamerrors.ErrUserNotFound, "Service User not found")
+	}
+	return user, nil
+}
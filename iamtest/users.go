@@ -0,0 +1,173 @@
+package iamtest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/users"
+)
+
+type userRecord struct {
+	users.User
+	email string
+}
+
+type createUserRequest struct {
+	AuthType   users.AuthType    `json:"auth_type"`
+	Email      string            `json:"email"`
+	Federation *users.Federation `json:"federation"`
+	Roles      []roles.Role      `json:"roles"`
+	GroupIDs   []string          `json:"group_ids"`
+}
+
+// AddUser adds a Panel User with the given email.
+// Empty ID and KeystoneID are generated, empty AuthType is set to users.Local.
+func (s *Server) AddUser(email string, user users.User) users.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user.ID == "" {
+		user.ID = iamid.UserID(s.nextID())
+	}
+	if user.KeystoneID == "" {
+		user.KeystoneID = iamid.KeystoneID(s.nextID())
+	}
+	if user.AuthType == "" {
+		user.AuthType = users.Local
+	}
+	if user.Roles == nil {
+		user.Roles = []roles.Role{}
+	}
+	s.users.put(string(user.ID), &userRecord{User: user, email: email})
+
+	return user
+}
+
+func (s *Server) userRoutes() []route {
+	return []route{
+		newRoute(http.MethodGet, "iam/v1/users", http.StatusOK, s.listUsers),
+		newRoute(http.MethodPost, "iam/v1/users", http.StatusCreated, s.createUser),
+		newRoute(http.MethodGet, "iam/v1/users/{user_id}", http.StatusOK, s.getUser),
+		newRoute(http.MethodDelete, "iam/v1/users/{user_id}", http.StatusNoContent, s.deleteUser),
+		newRoute(http.MethodPatch, "iam/v1/users/{user_id}/resend_invite", http.StatusNoContent, s.resendInvite),
+		newRoute(http.MethodPut, "iam/v1/users/{user_id}/roles", http.StatusNoContent,
+			s.rolesHandler(s.userRoles, true)),
+		newRoute(http.MethodDelete, "iam/v1/users/{user_id}/roles", http.StatusNoContent,
+			s.rolesHandler(s.userRoles, false)),
+	}
+}
+
+func (s *Server) listUsers(map[string]string, []byte) (interface{}, error) {
+	response := users.ListResponse{Users: []users.User{}}
+	for _, user := range s.users.list() {
+		response.Users = append(response.Users, user.User)
+	}
+	return response, nil
+}
+
+func (s *Server) getUser(params map[string]string, _ []byte) (interface{}, error) {
+	user, err := s.findUser(params["user_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	response := users.GetResponse{User: user.User, Groups: []users.Group{}}
+	for _, group := range s.groupsOf(string(user.KeystoneID)) {
+		response.Groups = append(response.Groups, users.Group{
+			ID:          group.ID,
+			Name:        group.Name,
+			Description: group.Description,
+			Roles:       group.Roles,
+		})
+	}
+	return response, nil
+}
+
+func (s *Server) createUser(_ map[string]string, body []byte) (interface{}, error) {
+	var request createUserRequest
+	if err := decodeBody(body, &request); err != nil {
+		return nil, err
+	}
+	if err := s.validateNewUser(request); err != nil {
+		return nil, err
+	}
+
+	user := users.User{
+		ID:         iamid.UserID(s.nextID()),
+		KeystoneID: iamid.KeystoneID(s.nextID()),
+		AuthType:   request.AuthType,
+		Federation: request.Federation,
+		Roles:      assignRoles(nil, request.Roles),
+	}
+	if user.AuthType == "" {
+		user.AuthType = users.Local
+	}
+	s.users.put(string(user.ID), &userRecord{User: user, email: request.Email})
+	for _, groupID := range request.GroupIDs {
+		group, _ := s.groups.get(groupID)
+		group.addMember(string(user.KeystoneID))
+	}
+
+	return users.CreateResponse{User: user}, nil
+}
+
+func (s *Server) validateNewUser(request createUserRequest) error {
+	if !strings.Contains(request.Email, "@") {
+		return invalidField("email", "A valid email is required")
+	}
+	for _, user := range s.users.list() {
+		if strings.EqualFold(user.email, request.Email) {
+			return conflict(iamerrors.ErrUserAlreadyExists, "User with email "+request.Email+" already exists")
+		}
+	}
+	if request.AuthType == users.Federated {
+		if request.Federation == nil || request.Federation.ExternalID == "" {
+			return invalidField("federation", "Federation is required for federated users")
+		}
+		if _, ok := s.federations.get(string(request.Federation.ID)); !ok {
+			return notFound(iamerrors.ErrFederationNotFound, "Federation not found")
+		}
+	}
+	if err := s.validateRoles(request.Roles); err != nil {
+		return err
+	}
+
+	return s.validateGroupIDs(request.GroupIDs)
+}
+
+func (s *Server) deleteUser(params map[string]string, _ []byte) (interface{}, error) {
+	user, err := s.findUser(params["user_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	s.users.delete(string(user.ID))
+	for _, group := range s.groupsOf(string(user.KeystoneID)) {
+		group.removeMember(string(user.KeystoneID))
+	}
+	return nil, nil
+}
+
+func (s *Server) resendInvite(params map[string]string, _ []byte) (interface{}, error) {
+	_, err := s.findUser(params["user_id"])
+	return nil, err
+}
+
+func (s *Server) userRoles(params map[string]string) (*[]roles.Role, error) {
+	user, err := s.findUser(params["user_id"])
+	if err != nil {
+		return nil, err
+	}
+	return &user.Roles, nil
+}
+
+func (s *Server) findUser(userID string) (*userRecord, error) {
+	user, ok := s.users.get(userID)
+	if !ok {
+		return nil, notFound(iamerrors.ErrUserNotFound, "User not found")
+	}
+	return user, nil
+}
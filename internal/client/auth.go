@@ -1,8 +1,48 @@
 package client
 
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+)
+
+const (
+	// DefaultKeystoneURL represents a default Selectel Keystone (Identity) API URL.
+	DefaultKeystoneURL = "https://cloud.api.selcloud.ru/identity/v3"
+
+	// defaultTokenRefreshMargin represents how long before the expiry a cached token is re-issued.
+	defaultTokenRefreshMargin = 5 * time.Minute
+
+	// defaultTokenFilePollInterval represents how often a token file is checked for changes.
+	defaultTokenFilePollInterval = 5 * time.Second
+)
+
+var (
+	errEmptyTokenFile = errors.New("the Keystone token file is empty")
+	errEmptyExecToken = errors.New("the credential command returned no token")
+)
+
 // AuthMethod is implemented by all authentication methods.
 type AuthMethod interface {
-	GetKeystoneToken() string
+	Token(ctx context.Context) (string, error)
+}
+
+// Reauthenticator is implemented by authentication methods, which are able to obtain a new token
+// after the current one was rejected by the IAM API.
+type Reauthenticator interface {
+	Invalidate()
 }
 
 // KeystoneTokenAuth represents Keystone token authentication method.
@@ -11,6 +51,355 @@ type KeystoneTokenAuth struct {
 	KeystoneToken string
 }
 
-func (k KeystoneTokenAuth) GetKeystoneToken() string {
-	return k.KeystoneToken
+func (k KeystoneTokenAuth) Token(_ context.Context) (string, error) {
+	return k.KeystoneToken, nil
+}
+
+// ServiceUserAuth represents Keystone password authentication method of a Service User.
+// It issues a token on demand, caches it and re-issues it shortly before the expiry.
+// It conforms to AuthMethod and Reauthenticator interfaces.
+type ServiceUserAuth struct {
+	// HTTPClient represents the HTTP client used to make requests to Keystone.
+	HTTPClient *http.Client
+
+	// KeystoneURL represents a valid Keystone API URL, which will be used to issue tokens.
+	KeystoneURL string
+
+	// UserName represents a name of the Service User.
+	UserName string
+
+	// Password represents a password of the Service User.
+	Password string
+
+	// AccountID represents an ID of the account (Keystone domain) the Service User belongs to.
+	AccountID string
+
+	// ProjectName represents a name of the project to scope the token to.
+	// If it is empty, the token is scoped to the account.
+	ProjectName string
+
+	// RefreshMargin represents how long before the expiry a cached token is re-issued.
+	RefreshMargin time.Duration
+
+	cache tokenCache
+}
+
+// NewServiceUserAuth returns a new instance of ServiceUserAuth with the default refresh margin.
+func NewServiceUserAuth(
+	httpClient *http.Client, keystoneURL, userName, password, accountID, projectName string,
+) *ServiceUserAuth {
+	if keystoneURL == "" {
+		keystoneURL = DefaultKeystoneURL
+	}
+	return &ServiceUserAuth{
+		HTTPClient:    httpClient,
+		KeystoneURL:   keystoneURL,
+		UserName:      userName,
+		Password:      password,
+		AccountID:     accountID,
+		ProjectName:   projectName,
+		RefreshMargin: defaultTokenRefreshMargin,
+	}
+}
+
+// Token returns a cached token or issues a new one, if the cached token is missing or about to expire.
+func (s *ServiceUserAuth) Token(ctx context.Context) (string, error) {
+	return s.cache.get(ctx, s.RefreshMargin, s.issueToken)
+}
+
+// Invalidate drops the cached token, so the next call of Token issues a new one.
+func (s *ServiceUserAuth) Invalidate() {
+	s.cache.invalidate()
+}
+
+func (s *ServiceUserAuth) issueToken(ctx context.Context) (string, time.Time, error) {
+	path, err := url.JoinPath(s.KeystoneURL, "auth", "tokens")
+	if err != nil {
+		return "", time.Time{}, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+	}
+
+	body, err := json.Marshal(s.buildTokenRequest())
+	if err != nil {
+		return "", time.Time{}, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, path, bytes.NewReader(body))
+	if err != nil {
+		return "", time.Time{}, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := s.HTTPClient.Do(request)
+	if err != nil {
+		return "", time.Time{}, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", time.Time{}, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+	}
+
+	if response.StatusCode == http.StatusUnauthorized {
+		return "", time.Time{}, iamerrors.Error{Err: iamerrors.ErrAuthTokenUnathorized, Desc: string(responseBody)}
+	}
+	if response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusOK {
+		return "", time.Time{}, iamerrors.Error{
+			Err:  iamerrors.ErrInternalAppError,
+			Desc: fmt.Sprintf("Keystone returned %d: %s", response.StatusCode, string(responseBody)),
+		}
+	}
+
+	token := response.Header.Get("X-Subject-Token")
+	if token == "" {
+		return "", time.Time{}, iamerrors.Error{
+			Err:  iamerrors.ErrInternalAppError,
+			Desc: "Keystone response has no X-Subject-Token header.",
+		}
+	}
+
+	var issued keystoneTokenResponse
+	err = UnmarshalJSON(responseBody, &issued)
+	if err != nil {
+		return "", time.Time{}, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+	}
+
+	return token, issued.Token.ExpiresAt, nil
+}
+
+func (s *ServiceUserAuth) buildTokenRequest() keystoneTokenRequest {
+	var request keystoneTokenRequest
+	request.Auth.Identity.Methods = []string{"password"}
+	request.Auth.Identity.Password.User.Name = s.UserName
+	request.Auth.Identity.Password.User.Password = s.Password
+	request.Auth.Identity.Password.User.Domain.Name = s.AccountID
+
+	if s.ProjectName != "" {
+		request.Auth.Scope.Project = &keystoneProjectScope{Name: s.ProjectName}
+		request.Auth.Scope.Project.Domain.Name = s.AccountID
+	} else {
+		request.Auth.Scope.Domain = &keystoneDomain{Name: s.AccountID}
+	}
+
+	return request
+}
+
+// FileTokenAuth represents authentication method, which reads a Keystone token from a file.
+// The file is checked for changes at most once per PollInterval, so the token can be rotated
+// (e.g. by a sidecar) without re-creating the Client.
+// It conforms to AuthMethod and Reauthenticator interfaces.
+type FileTokenAuth struct {
+	// Path represents a path to the file with a Keystone token.
+	Path string
+
+	// PollInterval represents how often the file is checked for changes.
+	PollInterval time.Duration
+
+	mu        sync.Mutex
+	token     string
+	modTime   time.Time
+	size      int64
+	checkedAt time.Time
+	now       func() time.Time
+}
+
+// NewFileTokenAuth returns a new instance of FileTokenAuth.
+// If pollInterval is zero, the default one is used.
+func NewFileTokenAuth(path string, pollInterval time.Duration) *FileTokenAuth {
+	if pollInterval == 0 {
+		pollInterval = defaultTokenFilePollInterval
+	}
+	return &FileTokenAuth{
+		Path:         path,
+		PollInterval: pollInterval,
+	}
+}
+
+// Token returns the token read from the file. The file is read again if it has been changed since the last read.
+func (f *FileTokenAuth) Token(_ context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now
+	if f.now != nil {
+		now = f.now
+	}
+
+	if f.token != "" && now().Sub(f.checkedAt) < f.PollInterval {
+		return f.token, nil
+	}
+
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Keystone token file: %w", err)
+	}
+	f.checkedAt = now()
+	if f.token != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.token, nil
+	}
+
+	content, err := os.ReadFile(f.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Keystone token file: %w", err)
+	}
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", fmt.Errorf("%w: %s", errEmptyTokenFile, f.Path)
+	}
+	f.token, f.modTime, f.size = token, info.ModTime(), info.Size()
+
+	return f.token, nil
+}
+
+// Invalidate forces the next call of Token to read the file again.
+func (f *FileTokenAuth) Invalidate() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.token, f.modTime, f.size, f.checkedAt = "", time.Time{}, 0, time.Time{}
+}
+
+// ExecTokenAuth represents authentication method, which obtains a Keystone token from an external command
+// (a credential plugin, e.g. a corporate SSO helper). The command is invoked lazily and is expected to print
+// a JSON object with "token" and optional "expires_at" (RFC 3339) fields to stdout.
+// The command is invoked again when the token is about to expire or was rejected by the IAM API.
+// It conforms to AuthMethod and Reauthenticator interfaces.
+type ExecTokenAuth struct {
+	// Command represents a path to the executable or its name to look up in PATH.
+	Command string
+
+	// Args represents arguments passed to the command.
+	Args []string
+
+	// Env represents additional environment variables in the "KEY=value" form passed to the command
+	// along with the environment of the current process.
+	Env []string
+
+	// RefreshMargin represents how long before the expiry the command is invoked again.
+	RefreshMargin time.Duration
+
+	cache tokenCache
+}
+
+// NewExecTokenAuth returns a new instance of ExecTokenAuth with the default refresh margin.
+func NewExecTokenAuth(command string, args, env []string) *ExecTokenAuth {
+	return &ExecTokenAuth{
+		Command:       command,
+		Args:          args,
+		Env:           env,
+		RefreshMargin: defaultTokenRefreshMargin,
+	}
+}
+
+// Token returns a cached token or invokes the command, if the cached token is missing or about to expire.
+func (e *ExecTokenAuth) Token(ctx context.Context) (string, error) {
+	return e.cache.get(ctx, e.RefreshMargin, e.issueToken)
+}
+
+// Invalidate drops the cached token, so the next call of Token invokes the command again.
+func (e *ExecTokenAuth) Invalidate() {
+	e.cache.invalidate()
+}
+
+func (e *ExecTokenAuth) issueToken(ctx context.Context) (string, time.Time, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, e.Command, e.Args...)
+	cmd.Env = append(os.Environ(), e.Env...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", time.Time{}, fmt.Errorf(
+			"credential command %s failed: %w: %s", e.Command, err, strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	var credential execCredential
+	if err := json.Unmarshal(stdout.Bytes(), &credential); err != nil {
+		return "", time.Time{}, fmt.Errorf("credential command %s returned invalid output: %w", e.Command, err)
+	}
+	if credential.Token == "" {
+		return "", time.Time{}, fmt.Errorf("%w: %s", errEmptyExecToken, e.Command)
+	}
+
+	return credential.Token, credential.ExpiresAt, nil
+}
+
+// tokenCache stores an issued token until it is about to expire.
+type tokenCache struct {
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+	now       func() time.Time
+}
+
+func (c *tokenCache) get(
+	ctx context.Context, margin time.Duration, issue func(context.Context) (string, time.Time, error),
+) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+
+	if c.token != "" && (c.expiresAt.IsZero() || now().Add(margin).Before(c.expiresAt)) {
+		return c.token, nil
+	}
+
+	token, expiresAt, err := issue(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token, c.expiresAt = token, expiresAt
+
+	return c.token, nil
+}
+
+func (c *tokenCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.token, c.expiresAt = "", time.Time{}
+}
+
+type keystoneDomain struct {
+	Name string `json:"name"`
+}
+
+type keystoneProjectScope struct {
+	Name   string         `json:"name"`
+	Domain keystoneDomain `json:"domain"`
+}
+
+type keystoneTokenRequest struct {
+	Auth struct {
+		Identity struct {
+			Methods  []string `json:"methods"`
+			Password struct {
+				User struct {
+					Name     string         `json:"name"`
+					Password string         `json:"password"`
+					Domain   keystoneDomain `json:"domain"`
+				} `json:"user"`
+			} `json:"password"`
+		} `json:"identity"`
+		Scope struct {
+			Project *keystoneProjectScope `json:"project,omitempty"`
+			Domain  *keystoneDomain       `json:"domain,omitempty"`
+		} `json:"scope"`
+	} `json:"auth"`
+}
+
+type execCredential struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type keystoneTokenResponse struct {
+	Token struct {
+		ExpiresAt time.Time `json:"expires_at"`
+	} `json:"token"`
 }
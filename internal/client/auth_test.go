@@ -0,0 +1,380 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/internal/client/testdata"
+)
+
+// keystoneStub is a local stand-in for the Keystone /auth/tokens endpoint.
+type keystoneStub struct {
+	server    *httptest.Server
+	issued    atomic.Int32
+	expiresIn time.Duration
+	lastBody  keystoneTokenRequest
+}
+
+func newKeystoneStub(t *testing.T, expiresIn time.Duration) *keystoneStub {
+	t.Helper()
+
+	stub := &keystoneStub{expiresIn: expiresIn}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/auth/tokens" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var body keystoneTokenRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		stub.lastBody = body
+
+		if body.Auth.Identity.Password.User.Password != testdata.TestPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		n := stub.issued.Add(1)
+		expiresAt := time.Now().Add(stub.expiresIn).UTC().Format(time.RFC3339)
+		w.Header().Set("X-Subject-Token", "token-"+strconv.Itoa(int(n)))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, testdata.TestKeystoneTokenResponse, expiresAt)
+	}))
+	t.Cleanup(stub.server.Close)
+
+	return stub
+}
+
+func TestServiceUserAuthToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		password       string
+		projectName    string
+		expiresIn      time.Duration
+		calls          int
+		expectedToken  string
+		expectedIssued int32
+		expectedError  error
+	}{
+		{
+			name:           "Test issue token once and cache it",
+			password:       testdata.TestPassword,
+			expiresIn:      time.Hour,
+			calls:          3,
+			expectedToken:  "token-1",
+			expectedIssued: 1,
+		},
+		{
+			name:           "Test re-issue token which is about to expire",
+			password:       testdata.TestPassword,
+			expiresIn:      time.Minute,
+			calls:          3,
+			expectedToken:  "token-3",
+			expectedIssued: 3,
+		},
+		{
+			name:           "Test issue project scoped token",
+			password:       testdata.TestPassword,
+			projectName:    testdata.TestProjectName,
+			expiresIn:      time.Hour,
+			calls:          1,
+			expectedToken:  "token-1",
+			expectedIssued: 1,
+		},
+		{
+			name:           "Test wrong password",
+			password:       "wrong-password",
+			expiresIn:      time.Hour,
+			calls:          1,
+			expectedIssued: 0,
+			expectedError:  iamerrors.ErrAuthTokenUnathorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			stub := newKeystoneStub(t, tt.expiresIn)
+			auth := NewServiceUserAuth(
+				&http.Client{},
+				stub.server.URL,
+				testdata.TestUserName,
+				tt.password,
+				testdata.TestAccountID,
+				tt.projectName,
+			)
+
+			var (
+				token string
+				err   error
+			)
+			for i := 0; i < tt.calls; i++ {
+				token, err = auth.Token(context.Background())
+			}
+
+			require.ErrorIs(err, tt.expectedError)
+			assert.Equal(tt.expectedToken, token)
+			assert.Equal(tt.expectedIssued, stub.issued.Load())
+
+			user := stub.lastBody.Auth.Identity.Password.User
+			assert.Equal(testdata.TestUserName, user.Name)
+			assert.Equal(testdata.TestAccountID, user.Domain.Name)
+			if tt.projectName != "" {
+				require.NotNil(stub.lastBody.Auth.Scope.Project)
+				assert.Equal(tt.projectName, stub.lastBody.Auth.Scope.Project.Name)
+				assert.Nil(stub.lastBody.Auth.Scope.Domain)
+			} else {
+				require.NotNil(stub.lastBody.Auth.Scope.Domain)
+				assert.Equal(testdata.TestAccountID, stub.lastBody.Auth.Scope.Domain.Name)
+			}
+		})
+	}
+}
+
+func TestDoRequestReauthenticates(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	stub := newKeystoneStub(t, time.Hour)
+
+	var attempts atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		// Only the second issued token is accepted.
+		if r.Header.Get("X-Auth-Token") != "token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, testdata.TestDoRequestUnauthorized)
+			return
+		}
+		fmt.Fprint(w, testdata.TestDoRequestRaw)
+	}))
+	defer api.Close()
+
+	baseClient := &BaseClient{
+		HTTPClient: &http.Client{},
+		APIUrl:     api.URL,
+		AuthMethod: NewServiceUserAuth(
+			&http.Client{}, stub.server.URL, testdata.TestUserName, testdata.TestPassword, testdata.TestAccountID, "",
+		),
+		UserAgent: testdata.TestUserAgent,
+	}
+
+	body, err := baseClient.DoRequest(context.Background(), DoRequestInput{
+		Method: http.MethodGet,
+		Path:   "/",
+	})
+
+	require.NoError(err)
+	assert.Equal([]byte(testdata.TestDoRequestRaw), body)
+	assert.Equal(int32(2), attempts.Load())
+	assert.Equal(int32(2), stub.issued.Load())
+
+	// The token is rejected again, but DoRequest re-authenticates only once per call.
+	baseClient.AuthMethod.(Reauthenticator).Invalidate()
+	stub.issued.Store(2)
+	_, err = baseClient.DoRequest(context.Background(), DoRequestInput{
+		Method: http.MethodGet,
+		Path:   "/",
+	})
+	require.ErrorIs(err, iamerrors.ErrAuthTokenUnathorized)
+	assert.Equal(int32(4), attempts.Load())
+}
+
+func TestFileTokenAuthToken(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(os.WriteFile(path, []byte(testdata.TestToken+"\n"), 0o600))
+
+	now := time.Now()
+	auth := NewFileTokenAuth(path, time.Minute)
+	auth.now = func() time.Time { return now }
+
+	token, err := auth.Token(context.Background())
+	require.NoError(err)
+	assert.Equal(testdata.TestToken, token)
+
+	// The file is rewritten, but the poll interval hasn't passed yet.
+	require.NoError(os.WriteFile(path, []byte("rotated-token"), 0o600))
+	require.NoError(os.Chtimes(path, now.Add(time.Second), now.Add(time.Second)))
+	token, err = auth.Token(context.Background())
+	require.NoError(err)
+	assert.Equal(testdata.TestToken, token)
+
+	now = now.Add(time.Minute)
+	token, err = auth.Token(context.Background())
+	require.NoError(err)
+	assert.Equal("rotated-token", token)
+
+	// Read failures are reported instead of returning an empty token.
+	require.NoError(os.WriteFile(path, []byte(" \n"), 0o600))
+	auth.Invalidate()
+	_, err = auth.Token(context.Background())
+	require.ErrorIs(err, errEmptyTokenFile)
+
+	require.NoError(os.Remove(path))
+	auth.Invalidate()
+	_, err = auth.Token(context.Background())
+	require.ErrorIs(err, os.ErrNotExist)
+}
+
+func TestDoRequestFileTokenAuthReadFailure(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var attempts atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		fmt.Fprint(w, testdata.TestDoRequestRaw)
+	}))
+	defer api.Close()
+
+	baseClient := &BaseClient{
+		HTTPClient: &http.Client{},
+		APIUrl:     api.URL,
+		AuthMethod: NewFileTokenAuth(filepath.Join(t.TempDir(), "missing"), 0),
+		UserAgent:  testdata.TestUserAgent,
+	}
+
+	_, err := baseClient.DoRequest(context.Background(), DoRequestInput{
+		Method: http.MethodGet,
+		Path:   "/",
+	})
+
+	require.ErrorIs(err, iamerrors.ErrAuthTokenProviderFailed)
+	require.ErrorIs(err, os.ErrNotExist)
+	assert.Equal(int32(0), attempts.Load())
+}
+
+// TestExecHelperProcess isn't a real test. It's used as a credential command by TestExecTokenAuthToken.
+func TestExecHelperProcess(*testing.T) {
+	counterFile := os.Getenv("IAM_GO_TEST_EXEC_COUNTER")
+	if counterFile == "" {
+		return
+	}
+
+	invocations, _ := os.ReadFile(counterFile)
+	invocations = append(invocations, '+')
+	_ = os.WriteFile(counterFile, invocations, 0o600)
+
+	switch os.Getenv("IAM_GO_TEST_EXEC_MODE") {
+	case "fail":
+		fmt.Fprint(os.Stderr, "SSO session expired")
+		os.Exit(1)
+	case "invalid":
+		fmt.Fprint(os.Stdout, "not a json")
+	default:
+		expiresIn, _ := time.ParseDuration(os.Getenv("IAM_GO_TEST_EXEC_EXPIRES_IN"))
+		fmt.Fprintf(os.Stdout, `{"token": "token-%d", "expires_at": %q}`,
+			len(invocations), time.Now().Add(expiresIn).UTC().Format(time.RFC3339))
+	}
+	os.Exit(0)
+}
+
+func TestExecTokenAuthToken(t *testing.T) {
+	tests := []struct {
+		name                string
+		mode                string
+		expiresIn           time.Duration
+		calls               int
+		invalidate          bool
+		expectedToken       string
+		expectedInvocations int
+		expectedErrorTarget interface{}
+	}{
+		{
+			name:                "Test invoke command once and cache the token",
+			expiresIn:           time.Hour,
+			calls:               3,
+			expectedToken:       "token-1",
+			expectedInvocations: 1,
+		},
+		{
+			name:                "Test invoke command again when the token is about to expire",
+			expiresIn:           time.Minute,
+			calls:               2,
+			expectedToken:       "token-2",
+			expectedInvocations: 2,
+		},
+		{
+			name:                "Test invoke command again after invalidation",
+			expiresIn:           time.Hour,
+			calls:               1,
+			invalidate:          true,
+			expectedToken:       "token-2",
+			expectedInvocations: 2,
+		},
+		{
+			name:                "Test command failure",
+			mode:                "fail",
+			calls:               1,
+			expectedInvocations: 1,
+			expectedErrorTarget: new(*exec.ExitError),
+		},
+		{
+			name:                "Test command invalid output",
+			mode:                "invalid",
+			calls:               1,
+			expectedInvocations: 1,
+			expectedErrorTarget: new(*json.SyntaxError),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			counterFile := filepath.Join(t.TempDir(), "counter")
+			auth := NewExecTokenAuth(os.Args[0], []string{"-test.run=^TestExecHelperProcess$"}, []string{
+				"IAM_GO_TEST_EXEC_COUNTER=" + counterFile,
+				"IAM_GO_TEST_EXEC_MODE=" + tt.mode,
+				"IAM_GO_TEST_EXEC_EXPIRES_IN=" + tt.expiresIn.String(),
+			})
+
+			// The command is invoked lazily.
+			_, err := os.Stat(counterFile)
+			require.ErrorIs(err, os.ErrNotExist)
+
+			var token string
+			for i := 0; i < tt.calls; i++ {
+				token, err = auth.Token(context.Background())
+			}
+			if tt.invalidate {
+				auth.Invalidate()
+				token, err = auth.Token(context.Background())
+			}
+
+			if tt.expectedErrorTarget != nil {
+				require.ErrorAs(err, tt.expectedErrorTarget)
+			} else {
+				require.NoError(err)
+			}
+			assert.Equal(tt.expectedToken, token)
+
+			invocations, err := os.ReadFile(counterFile)
+			require.NoError(err)
+			assert.Len(invocations, tt.expectedInvocations)
+		})
+	}
+}
@@ -1,20 +1,40 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
+	"unicode/utf8"
 
-	"github.com/selectel/iam-go/iamerrors"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iammetrics"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/iamrequest"
 )
 
+// maxBodySnippetLength represents the maximum length of a response body added to the description of an error.
+const maxBodySnippetLength = 256
+
 type DoRequestInput struct {
 	Body   io.Reader
 	Method string
 	Path   string
+
+	// Operation describes the call for middlewares. Method and Path are filled automatically.
+	Operation iammiddleware.Operation
+
+	// Options contains options of the call passed to the Service method, e.g. a timeout.
+	Options []iamrequest.Option
 }
 
 type BaseClient struct {
@@ -32,49 +52,314 @@ type BaseClient struct {
 
 	// UserAgentPrefix contains custom prefix to be added to userAgent.
 	UserAgentPrefix string
+
+	// RetryPolicy describes how failed requests are retried. The zero value disables retries.
+	RetryPolicy RetryPolicy
+
+	// RateLimiter limits the rate and the concurrency of requests. Nil means no limits.
+	RateLimiter *RateLimiter
+
+	// Middlewares are called for every attempt of a request. The first middleware is the outermost one.
+	Middlewares []iammiddleware.Middleware
+
+	// Tracer starts a span for every request. Nil disables tracing.
+	Tracer trace.Tracer
+
+	// Propagator injects trace context headers into requests. If it is nil, W3C Trace Context is used.
+	Propagator propagation.TextMapPropagator
+
+	// Metrics records every request. Nil disables metrics.
+	Metrics iammetrics.Recorder
+
+	// Logger logs every request at debug level and failed ones at warn level. Nil disables logging.
+	Logger *slog.Logger
+
+	// LogBodies enables logging of request and response bodies at debug level. Secrets are redacted.
+	LogBodies bool
+
+	// MaxResponseSize limits the size of a response body in bytes. Zero means no limit.
+	MaxResponseSize int64
+
+	// DryRun collects mutating calls instead of sending them. Nil disables the dry-run mode.
+	DryRun *iamrequest.Plan
 }
 
 // DoRequest performs the HTTP request with the current Client.HTTPClient and given User-Agent prefix.
 //
 // X-Auth-Token and other optional headers are added automatically.
+// If the IAM API rejects the token and AuthMethod is able to obtain a new one,
+// the request is repeated once with the new token.
+// Transient failures are retried according to RetryPolicy.
 func (bc *BaseClient) DoRequest(ctx context.Context, input DoRequestInput) ([]byte, error) {
-	url, err := url.JoinPath(bc.APIUrl, input.Path)
+	var body []byte
+	err := bc.DoRequestDecode(ctx, input, func(r io.Reader) error {
+		var err error
+		body, err = io.ReadAll(r)
+		return err
+	})
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// DoRequestDecode performs the request like DoRequest, but passes the body of a successful response
+// to decode as a stream instead of reading it into memory.
+//
+// Errors returned by decode are returned as iamerrors.ErrInternalAppError,
+// or as iamerrors.ErrResponseTooLarge, if the body exceeds MaxResponseSize.
+// In the dry-run mode mutating requests are recorded into the plan without calling decode.
+func (bc *BaseClient) DoRequestDecode(ctx context.Context, input DoRequestInput, decode func(io.Reader) error) error {
+	operation := input.Operation
+	operation.Method = input.Method
+	operation.Path = input.Path
+
+	options := iamrequest.NewOptions(input.Options...)
+	if plan := bc.dryRunPlan(options); plan != nil && isMutating(operation.Method) {
+		return planCall(plan, operation, input.Body)
+	}
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	finishMetrics := bc.startMetrics(operation)
+	finishLog := bc.startLog(ctx, operation)
+	ctx, span := bc.startSpan(ctx, operation)
+	result, err := bc.doRequest(ctx, operation, options, input.Body, decode)
+
+	var cbErr *callbackError
+	if errors.As(err, &cbErr) {
+		// The request itself succeeded, the caller has just stopped processing the response.
+		err = nil
+	}
+	err = annotateError(err, operation, result)
+	endSpan(span, result.statusCode, result.attempts, err)
+	finishMetrics(result, err)
+	finishLog(result, err)
+	if cbErr != nil {
+		return cbErr.err
+	}
+
+	return err
+}
+
+// requestResult represents an outcome of doRequest.
+type requestResult struct {
+	requestBody []byte
+	body        []byte
+	statusCode  int
+	requestID   string
+	attempts    int
+}
+
+// doRequest sends the request of the operation, retrying it according to RetryPolicy.
+func (bc *BaseClient) doRequest(
+	ctx context.Context, operation iammiddleware.Operation, options iamrequest.Options, inputBody io.Reader,
+	decode func(io.Reader) error,
+) (requestResult, error) {
+	url, err := url.JoinPath(bc.APIUrl, operation.Path)
+	if err != nil {
+		return requestResult{}, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+	}
+
+	var body []byte
+	if inputBody != nil {
+		body, err = io.ReadAll(inputBody)
+		if err != nil {
+			return requestResult{}, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		}
+	}
+	result := requestResult{requestBody: body}
+	policy := retryPolicyFor(bc.RetryPolicy, options)
+
+	var (
+		response *http.Response
+		attempt  int
+	)
+	for attempt = 1; ; attempt++ {
+		response, err = bc.sendWithReauth(ctx, operation, options, url, inputBody != nil, body)
+		if !policy.shouldRetry(ctx, attempt, operation.Method, response, err) {
+			break
+		}
+
+		delay := policy.backoff(attempt, response)
+		if response != nil {
+			response.Body.Close()
+		}
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			result.attempts = attempt
+			return result, iamerrors.Error{
+				Err: iamerrors.ErrInternalAppError, Desc: sleepErr.Error(), Cause: sleepErr, Attempts: attempt,
+			}
+		}
+	}
+	result.attempts = attempt
+	if err != nil {
+		var sendErr *sendError
+		if errors.As(err, &sendErr) {
+			return result, iamerrors.Error{
+				Err: iamerrors.ErrInternalAppError, Desc: err.Error(), Cause: sendErr.err, Attempts: attempt,
+			}
+		}
+		return result, err
+	}
+	defer response.Body.Close()
+	result.statusCode = response.StatusCode
+	result.requestID = response.Header.Get(requestIDHeader)
+
+	if bc.MaxResponseSize > 0 && response.ContentLength > bc.MaxResponseSize {
+		return result, bc.responseError(errResponseTooLarge, attempt)
+	}
+	responseBody := bc.limitResponseBody(response.Body)
+
+	if response.StatusCode >= 400 {
+		result.body, err = io.ReadAll(responseBody)
+		if err != nil {
+			return result, bc.responseError(err, attempt)
+		}
+		err := decodeError(response.StatusCode, result.body)
+		err.Attempts = attempt
+		return result, err
+	}
+
+	var logged bytes.Buffer
+	if bc.Logger != nil && bc.LogBodies {
+		responseBody = io.TeeReader(responseBody, &logged)
+	}
+	err = decode(responseBody)
+	result.body = logged.Bytes()
+	if err != nil {
+		return result, bc.responseError(err, attempt)
 	}
 
-	request, err := http.NewRequestWithContext(ctx, input.Method, url, input.Body)
+	return result, nil
+}
+
+// sendWithReauth sends the request and repeats it once with a new token, if the IAM API rejects the current one.
+// Requests with a token passed in options are not repeated.
+func (bc *BaseClient) sendWithReauth(
+	ctx context.Context, operation iammiddleware.Operation, options iamrequest.Options, url string, hasBody bool,
+	body []byte,
+) (*http.Response, error) {
+	response, err := bc.send(ctx, operation, options, url, hasBody, body)
+	if err != nil {
+		return nil, err
+	}
+
+	reauthenticator, ok := bc.AuthMethod.(Reauthenticator)
+	if !ok || options.Token != "" || response.StatusCode != http.StatusUnauthorized {
+		return response, nil
+	}
+	response.Body.Close()
+	reauthenticator.Invalidate()
+
+	return bc.send(ctx, operation, options, url, hasBody, body)
+}
+
+// send sends the request once through Middlewares.
+// Errors returned by HTTPClient are wrapped into sendError, so they can be retried.
+func (bc *BaseClient) send(
+	ctx context.Context, operation iammiddleware.Operation, options iamrequest.Options, url string, hasBody bool,
+	body []byte,
+) (*http.Response, error) {
+	var bodyReader io.Reader
+	if hasBody {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, operation.Method, url, bodyReader)
 	if err != nil {
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	request.Header.Set("X-Auth-Token", bc.AuthMethod.GetKeystoneToken())
-	if input.Body != nil {
+	token := options.Token
+	if token == "" {
+		token, err = bc.AuthMethod.Token(ctx)
+		if err != nil {
+			return nil, iamerrors.Error{Err: iamerrors.ErrAuthTokenProviderFailed, Desc: err.Error(), Cause: err}
+		}
+	}
+
+	request.Header.Set("X-Auth-Token", token)
+	if hasBody {
 		request.Header.Set("Content-Type", "application/json")
 	}
 
 	request.Header.Set("User-Agent", bc.UserAgent)
+	bc.injectTraceContext(ctx, request)
+	setOptionHeaders(request, options)
 
-	response, err := bc.HTTPClient.Do(request)
+	response, err := iammiddleware.Chain(bc.do, bc.Middlewares...)(request, operation)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		var (
+			sendErr *sendError
+			iamErr  iamerrors.Error
+		)
+		if errors.As(err, &sendErr) || errors.As(err, &iamErr) {
+			return nil, err
+		}
+		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error(), Cause: err}
 	}
-	defer response.Body.Close()
+	// A middleware may short-circuit the chain and return neither a response nor an error.
+	if response == nil || response.Body == nil {
+		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: "A middleware returned no response."}
+	}
+
+	return response, nil
+}
 
-	body, err := io.ReadAll(response.Body)
+// setOptionHeaders adds headers passed in options of the call to the request.
+func setOptionHeaders(request *http.Request, options iamrequest.Options) {
+	if options.IdempotencyKey != "" {
+		request.Header.Set(iamrequest.IdempotencyKeyHeader, options.IdempotencyKey)
+	}
+	for name, values := range options.Header {
+		request.Header[http.CanonicalHeaderKey(name)] = append([]string{}, values...)
+	}
+}
+
+// do is the innermost Handler, which sends the request with HTTPClient once RateLimiter allows it.
+// The operation is passed to HTTPClient in the context of the request.
+func (bc *BaseClient) do(request *http.Request, operation iammiddleware.Operation) (*http.Response, error) {
+	release, err := bc.RateLimiter.Wait(request.Context())
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error(), Cause: err}
 	}
 
-	if response.StatusCode >= 400 {
-		err := decodeError(response.StatusCode, body)
-		return nil, err
+	request = request.WithContext(iammiddleware.ContextWithOperation(request.Context(), operation))
+	response, err := bc.HTTPClient.Do(request)
+	// The request stops being in flight once the response headers are received. Holding the slot while
+	// the body is read would block requests made while a response is streamed, e.g. in ForEach callbacks.
+	release()
+	if err != nil {
+		return nil, &sendError{err: err}
 	}
 
-	return body, nil
+	return response, nil
 }
 
-func decodeError(statusCode int, body []byte) error {
+// annotateError adds details of the request to iamerrors.Error.
+func annotateError(err error, operation iammiddleware.Operation, result requestResult) error {
+	var iamErr iamerrors.Error
+	if !errors.As(err, &iamErr) {
+		return err
+	}
+
+	iamErr.StatusCode = result.statusCode
+	iamErr.RequestID = result.requestID
+	iamErr.Method = operation.Method
+	iamErr.PathTemplate = operation.PathTemplate
+	if operation.Service != "" {
+		iamErr.Operation = operation.String()
+	}
+
+	return iamErr
+}
+
+func decodeError(statusCode int, body []byte) iamerrors.Error {
 	if statusCode == http.StatusUnauthorized {
 		errDescription := string(body)
 		return iamerrors.Error{Err: iamerrors.ErrAuthTokenUnathorized, Desc: errDescription}
@@ -82,13 +367,54 @@ func decodeError(statusCode int, body []byte) error {
 
 	var eg ErrorGeneric
 	err := UnmarshalJSON(body, &eg)
-	if err != nil {
-		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+	if err != nil || eg.Code == "" {
+		// The body wasn't produced by the IAM API itself, e.g. it's an HTML page of a proxy.
+		return iamerrors.Error{
+			Err:  errorByStatus(statusCode),
+			Desc: fmt.Sprintf("Unexpected response with status %d: %s", statusCode, bodySnippet(body)),
+		}
+	}
+
+	var fields []iamerrors.FieldError
+	for _, detail := range eg.Details {
+		fields = append(fields, iamerrors.FieldError{Field: detail.Field, Reason: detail.Reason})
 	}
+
 	if e := iamerrors.GetError(eg.Code); e != nil {
-		return iamerrors.Error{Err: e, Desc: eg.Message}
+		return iamerrors.Error{Err: e, Desc: eg.Message, Code: eg.Code, Fields: fields}
+	}
+	// The code is kept as is, so it can be matched with errors.Is(err, iamerrors.Code(...)).
+	return iamerrors.Error{Err: iamerrors.Code(eg.Code), Desc: eg.Message, Code: eg.Code, Fields: fields}
+}
+
+// errorByStatus returns an error for the response, which doesn't contain an IAM API error code.
+func errorByStatus(statusCode int) error {
+	switch {
+	case statusCode == http.StatusBadRequest:
+		return iamerrors.ErrRequestValidationError
+	case statusCode == http.StatusForbidden:
+		return iamerrors.ErrForbidden
+	case statusCode == http.StatusTooManyRequests:
+		return iamerrors.ErrTooManyRequests
+	case statusCode >= http.StatusInternalServerError:
+		return iamerrors.ErrInternalServerError
 	}
-	return iamerrors.Error{Err: iamerrors.ErrUnknown, Desc: fmt.Sprintf("%s -- %s", eg.Code, eg.Message)}
+	return iamerrors.ErrUnknown
+}
+
+// bodySnippet returns the beginning of the body to be added to the description of an error.
+func bodySnippet(body []byte) string {
+	snippet := strings.TrimSpace(string(body))
+	if len(snippet) <= maxBodySnippetLength {
+		return snippet
+	}
+
+	snippet = snippet[:maxBodySnippetLength]
+	// Don't cut a multibyte character in the middle.
+	for !utf8.ValidString(snippet) {
+		snippet = snippet[:len(snippet)-1]
+	}
+	return snippet + "..."
 }
 
 // ErrorGeneric represents an error returned by the IAM API.
@@ -99,10 +425,22 @@ type ErrorGeneric struct {
 	// Message describes the reason of error.
 	Message string `json:"message"`
 
+	// Details contains invalid fields of the request for REQUEST_VALIDATION_FAILED errors.
+	Details []ErrorDetail `json:"details"`
+
 	// ErrorDescription represents a human-readable description of the error.
 	ErrorDescription error `json:"-"`
 }
 
+// ErrorDetail represents an invalid field of the request.
+type ErrorDetail struct {
+	// Field is a path to the field in the request body.
+	Field string `json:"field"`
+
+	// Reason describes why the value of the field is invalid.
+	Reason string `json:"reason"`
+}
+
 // UnmarshalJSON accepts an object in which ResposeResult.Body will be extracted.
 func UnmarshalJSON(body []byte, to interface{}) error {
 	err := json.Unmarshal(body, to)
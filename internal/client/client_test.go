@@ -3,16 +3,23 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/x509"
+	"errors"
 	"io"
 	"net/http"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client/testdata"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/internal/client/testdata"
 )
 
 func TestDoRequest(t *testing.T) {
@@ -110,3 +117,411 @@ func TestDoRequest(t *testing.T) {
 		})
 	}
 }
+
+type testTokenProvider struct {
+	tokens      []string
+	err         error
+	invalidated int
+}
+
+func (p *testTokenProvider) Token(_ context.Context) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	return p.tokens[p.invalidated], nil
+}
+
+func (p *testTokenProvider) Invalidate() {
+	p.invalidated++
+}
+
+func TestDoRequestTokenProvider(t *testing.T) {
+	errVault := errors.New("vault is sealed")
+
+	tests := []struct {
+		name                string
+		provider            *testTokenProvider
+		prepare             func()
+		expectedBody        []byte
+		expectedInvalidated int
+		expectedErrors      []error
+	}{
+		{
+			name:     "Test DoRequest with TokenProvider",
+			provider: &testTokenProvider{tokens: []string{testdata.TestToken}},
+			prepare: func() {
+				httpmock.RegisterResponder(http.MethodGet, testdata.TestURL,
+					func(r *http.Request) (*http.Response, error) {
+						if r.Header.Get("X-Auth-Token") != testdata.TestToken {
+							return httpmock.NewStringResponse(401, testdata.TestDoRequestUnauthorized), nil
+						}
+						return httpmock.NewStringResponse(200, testdata.TestDoRequestRaw), nil
+					})
+			},
+			expectedBody:        []byte(testdata.TestDoRequestRaw),
+			expectedInvalidated: 0,
+		},
+		{
+			name:     "Test DoRequest invalidates rejected token",
+			provider: &testTokenProvider{tokens: []string{"expired-token", testdata.TestToken}},
+			prepare: func() {
+				httpmock.RegisterResponder(http.MethodGet, testdata.TestURL,
+					func(r *http.Request) (*http.Response, error) {
+						if r.Header.Get("X-Auth-Token") != testdata.TestToken {
+							return httpmock.NewStringResponse(401, testdata.TestDoRequestUnauthorized), nil
+						}
+						return httpmock.NewStringResponse(200, testdata.TestDoRequestRaw), nil
+					})
+			},
+			expectedBody:        []byte(testdata.TestDoRequestRaw),
+			expectedInvalidated: 1,
+		},
+		{
+			name:     "Test DoRequest propagates TokenProvider error",
+			provider: &testTokenProvider{err: errVault},
+			prepare: func() {
+				httpmock.RegisterResponder(http.MethodGet, testdata.TestURL,
+					httpmock.NewStringResponder(200, testdata.TestDoRequestRaw))
+			},
+			expectedBody:   nil,
+			expectedErrors: []error{iamerrors.ErrAuthTokenProviderFailed, errVault},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			baseClient := &BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: tt.provider,
+				UserAgent:  testdata.TestUserAgent,
+			}
+
+			httpmock.ActivateNonDefault(baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			tt.prepare()
+
+			actualBody, err := baseClient.DoRequest(context.Background(), DoRequestInput{
+				Method: http.MethodGet,
+				Path:   "/",
+			})
+
+			if len(tt.expectedErrors) == 0 {
+				require.NoError(err)
+			}
+			for _, expectedError := range tt.expectedErrors {
+				require.ErrorIs(err, expectedError)
+			}
+			assert.Equal(tt.expectedBody, actualBody)
+			assert.Equal(tt.expectedInvalidated, tt.provider.invalidated)
+		})
+	}
+}
+
+//nolint:funlen // This is a test function.
+func TestDoRequestErrorDetails(t *testing.T) {
+	operation := iammiddleware.Operation{
+		Service:      "users",
+		Name:         "Get",
+		PathTemplate: "iam/v1/users/{user_id}",
+	}
+
+	tests := []struct {
+		name              string
+		responder         httpmock.Responder
+		expectedError     iamerrors.Error
+		expectedRetryable bool
+		expectedNotFound  bool
+		expectedConflict  bool
+	}{
+		{
+			name: "Test DoRequest returns details of known error",
+			responder: func(r *http.Request) (*http.Response, error) {
+				resp := httpmock.NewStringResponse(http.StatusNotFound, testdata.TestDoRequestNotFound)
+				resp.Header.Set("X-Request-Id", "test-request-id")
+				return resp, nil
+			},
+			expectedError: iamerrors.Error{
+				Err:          iamerrors.ErrUserNotFound,
+				Desc:         "User not found",
+				Attempts:     1,
+				StatusCode:   http.StatusNotFound,
+				Code:         "USER_NOT_FOUND",
+				RequestID:    "test-request-id",
+				Operation:    "users.Get",
+				Method:       http.MethodGet,
+				PathTemplate: "iam/v1/users/{user_id}",
+			},
+			expectedNotFound: true,
+		},
+		{
+			name:      "Test DoRequest returns raw code of unknown error",
+			responder: httpmock.NewStringResponder(http.StatusConflict, testdata.TestDoRequestConflict),
+			expectedError: iamerrors.Error{
+				Err:          iamerrors.Code("GROUP_NAME_TAKEN"),
+				Desc:         "Group with this name already exists",
+				Attempts:     1,
+				StatusCode:   http.StatusConflict,
+				Code:         "GROUP_NAME_TAKEN",
+				Operation:    "users.Get",
+				Method:       http.MethodGet,
+				PathTemplate: "iam/v1/users/{user_id}",
+			},
+			expectedConflict: true,
+		},
+		{
+			name:      "Test DoRequest returns retryable error",
+			responder: httpmock.NewStringResponder(http.StatusServiceUnavailable, testdata.TestDoRequestUnavailable),
+			expectedError: iamerrors.Error{
+				Err:          iamerrors.ErrInternalServerError,
+				Desc:         "Service is temporarily unavailable",
+				Attempts:     1,
+				StatusCode:   http.StatusServiceUnavailable,
+				Code:         "INTERNAL_SERVER_ERROR",
+				Operation:    "users.Get",
+				Method:       http.MethodGet,
+				PathTemplate: "iam/v1/users/{user_id}",
+			},
+			expectedRetryable: true,
+		},
+		{
+			name:      "Test DoRequest falls back to status for non-JSON body",
+			responder: httpmock.NewStringResponder(http.StatusBadGateway, testdata.TestDoRequestBadGateway),
+			expectedError: iamerrors.Error{
+				Err:          iamerrors.ErrInternalServerError,
+				Desc:         "Unexpected response with status 502: " + testdata.TestDoRequestBadGateway,
+				Attempts:     1,
+				StatusCode:   http.StatusBadGateway,
+				Operation:    "users.Get",
+				Method:       http.MethodGet,
+				PathTemplate: "iam/v1/users/{user_id}",
+			},
+			expectedRetryable: true,
+		},
+		{
+			name: "Test DoRequest truncates long non-JSON body",
+			responder: httpmock.NewStringResponder(
+				http.StatusTooManyRequests, strings.Repeat("Слишком много запросов. ", 20),
+			),
+			expectedError: iamerrors.Error{
+				Err: iamerrors.ErrTooManyRequests,
+				Desc: "Unexpected response with status 429: " +
+					strings.Repeat("Слишком много запросов. ", 5) + "Слишком много запро...",
+				Attempts:     1,
+				StatusCode:   http.StatusTooManyRequests,
+				Operation:    "users.Get",
+				Method:       http.MethodGet,
+				PathTemplate: "iam/v1/users/{user_id}",
+			},
+			expectedRetryable: true,
+		},
+		{
+			name:      "Test DoRequest returns invalid fields",
+			responder: httpmock.NewStringResponder(http.StatusBadRequest, testdata.TestDoRequestValidationFailed),
+			expectedError: iamerrors.Error{
+				Err:        iamerrors.ErrRequestValidationError,
+				Desc:       "Request validation failed",
+				Attempts:   1,
+				StatusCode: http.StatusBadRequest,
+				Code:       "REQUEST_VALIDATION_FAILED",
+				Fields: []iamerrors.FieldError{
+					{Field: "email", Reason: "must be a valid email"},
+					{Field: "roles.0.scope", Reason: "must be one of: account, project"},
+				},
+				Operation:    "users.Get",
+				Method:       http.MethodGet,
+				PathTemplate: "iam/v1/users/{user_id}",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			baseClient := &BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+				UserAgent:  testdata.TestUserAgent,
+			}
+
+			httpmock.ActivateNonDefault(baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+"iam/v1/users/123", tt.responder)
+
+			_, err := baseClient.DoRequest(context.Background(), DoRequestInput{
+				Method:    http.MethodGet,
+				Path:      "iam/v1/users/123",
+				Operation: operation,
+			})
+
+			var actual iamerrors.Error
+			require.ErrorAs(err, &actual)
+			assert.Equal(tt.expectedError, actual)
+			assert.Equal(tt.expectedRetryable, iamerrors.IsRetryable(err))
+			assert.Equal(tt.expectedNotFound, iamerrors.IsNotFound(err))
+			assert.Equal(tt.expectedConflict, iamerrors.IsConflict(err))
+		})
+	}
+}
+
+func TestDoRequestNetworkErrorIsRetryable(t *testing.T) {
+	tests := []struct {
+		name              string
+		err               error
+		expectedRetryable bool
+	}{
+		{
+			name:              "Test refused connection is retryable",
+			err:               syscall.ECONNREFUSED,
+			expectedRetryable: true,
+		},
+		{
+			name:              "Test invalid certificate isn't retryable",
+			err:               x509.UnknownAuthorityError{},
+			expectedRetryable: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			baseClient := &BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			}
+
+			httpmock.ActivateNonDefault(baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL, httpmock.NewErrorResponder(tt.err))
+
+			_, err := baseClient.DoRequest(context.Background(), DoRequestInput{Method: http.MethodGet, Path: "/"})
+
+			require.ErrorIs(err, iamerrors.ErrInternalAppError)
+			require.Equal(tt.expectedRetryable, iamerrors.IsRetryable(err))
+			require.False(iamerrors.IsNotFound(err))
+		})
+	}
+}
+
+//nolint:funlen // This is a test function.
+func TestDoRequestOptions(t *testing.T) {
+	ok := httpmock.NewStringResponder(http.StatusOK, testdata.TestDoRequestRaw)
+	policy := RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	tests := []struct {
+		name             string
+		method           string
+		options          []iamrequest.Option
+		responder        func(t *testing.T) httpmock.Responder
+		expectedError    error
+		expectedAttempts int
+	}{
+		{
+			name:    "Test DoRequest with headers",
+			method:  http.MethodGet,
+			options: []iamrequest.Option{iamrequest.WithHeader("X-Request-Source", "test")},
+			responder: func(t *testing.T) httpmock.Responder {
+				return func(r *http.Request) (*http.Response, error) {
+					assert.Equal(t, "test", r.Header.Get("X-Request-Source"))
+					return ok(r)
+				}
+			},
+			expectedAttempts: 1,
+		},
+		{
+			name:    "Test DoRequest with idempotency key retries POST",
+			method:  http.MethodPost,
+			options: []iamrequest.Option{iamrequest.WithIdempotencyKey("key")},
+			responder: func(t *testing.T) httpmock.Responder {
+				next := sequenceResponder(t, "", unavailable(""), ok)
+				return func(r *http.Request) (*http.Response, error) {
+					assert.Equal(t, "key", r.Header.Get(iamrequest.IdempotencyKeyHeader))
+					return next(r)
+				}
+			},
+			expectedAttempts: 2,
+		},
+		{
+			name:    "Test DoRequest without retries",
+			method:  http.MethodGet,
+			options: []iamrequest.Option{iamrequest.WithoutRetries()},
+			responder: func(_ *testing.T) httpmock.Responder {
+				return unavailable("")
+			},
+			expectedError:    iamerrors.ErrInternalServerError,
+			expectedAttempts: 1,
+		},
+		{
+			name:    "Test DoRequest with another token",
+			method:  http.MethodGet,
+			options: []iamrequest.Option{iamrequest.WithToken("another-token")},
+			responder: func(t *testing.T) httpmock.Responder {
+				return func(r *http.Request) (*http.Response, error) {
+					assert.Equal(t, "another-token", r.Header.Get("X-Auth-Token"))
+					return httpmock.NewStringResponse(http.StatusUnauthorized, testdata.TestDoRequestUnauthorized), nil
+				}
+			},
+			expectedError:    iamerrors.ErrAuthTokenUnathorized,
+			expectedAttempts: 1,
+		},
+		{
+			name:    "Test DoRequest with timeout",
+			method:  http.MethodGet,
+			options: []iamrequest.Option{iamrequest.WithTimeout(50 * time.Millisecond)},
+			responder: func(_ *testing.T) httpmock.Responder {
+				return func(r *http.Request) (*http.Response, error) {
+					<-r.Context().Done()
+					return nil, r.Context().Err()
+				}
+			},
+			expectedError:    iamerrors.ErrInternalAppError,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			provider := &testTokenProvider{tokens: []string{testdata.TestToken, testdata.TestToken}}
+			baseClient := &BaseClient{
+				HTTPClient:  &http.Client{},
+				APIUrl:      testdata.TestURL,
+				AuthMethod:  provider,
+				UserAgent:   testdata.TestUserAgent,
+				RetryPolicy: policy,
+			}
+
+			httpmock.ActivateNonDefault(baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(tt.method, testdata.TestURL, tt.responder(t))
+
+			_, err := baseClient.DoRequest(context.Background(), DoRequestInput{
+				Method:  tt.method,
+				Path:    "/",
+				Options: tt.options,
+			})
+
+			if tt.expectedError == nil {
+				require.NoError(err)
+			} else {
+				require.ErrorIs(err, tt.expectedError)
+			}
+			assert.Equal(tt.expectedAttempts, httpmock.GetTotalCallCount())
+			assert.Zero(provider.invalidated)
+		})
+	}
+}
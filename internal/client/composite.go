@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+
+	"github.com/selectel/iam-go/v2/iamrequest"
+)
+
+// Composite derives options of the requests made by a method, which sends several requests,
+// e.g. Modify or SetRoles, from options of the method call.
+type Composite struct {
+	opts           []iamrequest.Option
+	idempotencyKey string
+}
+
+// NewComposite returns the context and the Composite for requests of a method with the given options.
+// The timeout of the options limits the method as a whole, so it's applied once to the returned context,
+// which must be released with the returned cancel function.
+func NewComposite(ctx context.Context, opts []iamrequest.Option) (context.Context, context.CancelFunc, Composite) {
+	options := iamrequest.NewOptions(opts...)
+
+	cancel := context.CancelFunc(func() {})
+	if options.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+	}
+
+	return ctx, cancel, Composite{opts: opts, idempotencyKey: options.IdempotencyKey}
+}
+
+// Read returns options of a reading request. The idempotency key isn't sent with reads.
+func (c Composite) Read() []iamrequest.Option {
+	return c.with(iamrequest.WithIdempotencyKey(""))
+}
+
+// Write returns options of a mutating request made at the given step of the method, e.g. "assign".
+// The idempotency key of the request is derived from the key of the method and the step,
+// so different requests never share a key, while a repeated call of the method sends the same keys.
+func (c Composite) Write(step string) []iamrequest.Option {
+	if c.idempotencyKey == "" {
+		return c.with()
+	}
+	return c.with(iamrequest.WithIdempotencyKey(c.idempotencyKey + "/" + step))
+}
+
+// with returns the options of the method followed by extra ones. The timeout is reset,
+// since it's already applied to the context of the method.
+func (c Composite) with(extra ...iamrequest.Option) []iamrequest.Option {
+	opts := make([]iamrequest.Option, 0, len(c.opts)+len(extra)+1)
+	opts = append(opts, c.opts...)
+	opts = append(opts, iamrequest.WithTimeout(0))
+	return append(opts, extra...)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selectel/iam-go/v2/iamrequest"
+)
+
+func TestComposite(t *testing.T) {
+	tests := []struct {
+		name               string
+		opts               []iamrequest.Option
+		expectedDeadline   bool
+		expectedReadKey    string
+		expectedWriteKey   string
+		expectedReadToken  string
+		expectedWriteToken string
+	}{
+		{
+			name: "Test Composite with idempotency key and timeout",
+			opts: []iamrequest.Option{
+				iamrequest.WithIdempotencyKey("key"),
+				iamrequest.WithTimeout(time.Minute),
+				iamrequest.WithToken("token"),
+			},
+			expectedDeadline:   true,
+			expectedWriteKey:   "key/assign",
+			expectedReadToken:  "token",
+			expectedWriteToken: "token",
+		},
+		{
+			name: "Test Composite without options",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			ctx, cancel, calls := NewComposite(context.Background(), tt.opts)
+			defer cancel()
+
+			_, ok := ctx.Deadline()
+			require.Equal(tt.expectedDeadline, ok)
+
+			read := iamrequest.NewOptions(calls.Read()...)
+			assert.Equal(tt.expectedReadKey, read.IdempotencyKey)
+			assert.Equal(tt.expectedReadToken, read.Token)
+			assert.Zero(read.Timeout)
+
+			write := iamrequest.NewOptions(calls.Write("assign")...)
+			assert.Equal(tt.expectedWriteKey, write.IdempotencyKey)
+			assert.Equal(tt.expectedWriteToken, write.Token)
+			assert.Zero(write.Timeout)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+)
+
+// errResponseTooLarge is returned by the reader of a response body, which exceeds BaseClient.MaxResponseSize.
+var errResponseTooLarge = errors.New("response is too large")
+
+// callbackError wraps an error returned by a callback of ForEachJSON, so it's returned to the caller as is.
+type callbackError struct {
+	err error
+}
+
+func (e *callbackError) Error() string {
+	return e.err.Error()
+}
+
+func (e *callbackError) Unwrap() error {
+	return e.err
+}
+
+// DoRequestJSON performs the request like DoRequest and decodes the JSON body of the response into output.
+func (bc *BaseClient) DoRequestJSON(ctx context.Context, input DoRequestInput, output interface{}) error {
+	return bc.DoRequestDecode(ctx, input, DecodeJSON(output))
+}
+
+// DecodeJSON returns a decode function for DoRequestDecode, which decodes the JSON body into output.
+// json.Decoder reads the whole JSON value before decoding it, so use ForEachJSON to stream large lists.
+func DecodeJSON(output interface{}) func(io.Reader) error {
+	return func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(output)
+	}
+}
+
+// ForEachJSON returns a decode function for DoRequestDecode, which reads the array in the given field
+// of a JSON object and calls fn for every item of it without decoding the whole array into memory.
+//
+// If fn returns an error, decoding is stopped and DoRequestDecode returns the error as is.
+func ForEachJSON[T any](field string, fn func(T) error) func(io.Reader) error {
+	return func(r io.Reader) error {
+		decoder := json.NewDecoder(r)
+		if err := expectDelim(decoder, '{'); err != nil {
+			return err
+		}
+
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return fmt.Errorf("ForEachJSON() — decode error: %w", err)
+			}
+			if key, _ := token.(string); key != field {
+				var skipped json.RawMessage
+				if err := decoder.Decode(&skipped); err != nil {
+					return fmt.Errorf("ForEachJSON() — decode error: %w", err)
+				}
+				continue
+			}
+			if err := decodeArray(decoder, fn); err != nil {
+				return err
+			}
+		}
+
+		return expectDelim(decoder, '}')
+	}
+}
+
+// decodeArray calls fn for every item of the JSON array, which is the next value of the decoder.
+// A null value is treated as an empty array.
+func decodeArray[T any](decoder *json.Decoder, fn func(T) error) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("ForEachJSON() — decode error: %w", err)
+	}
+	if token == nil {
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("ForEachJSON() — expected an array, got %v", token)
+	}
+
+	for decoder.More() {
+		var item T
+		if err := decoder.Decode(&item); err != nil {
+			return fmt.Errorf("ForEachJSON() — decode error: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return &callbackError{err: err}
+		}
+	}
+
+	return expectDelim(decoder, ']')
+}
+
+func expectDelim(decoder *json.Decoder, expected json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("ForEachJSON() — decode error: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != expected {
+		return fmt.Errorf("ForEachJSON() — expected %v, got %v", expected, token)
+	}
+	return nil
+}
+
+// limitResponseBody returns a reader of the body, which fails with errResponseTooLarge
+// once more than MaxResponseSize bytes are read.
+func (bc *BaseClient) limitResponseBody(body io.Reader) io.Reader {
+	if bc.MaxResponseSize <= 0 {
+		return body
+	}
+	return &limitedReader{reader: body, remaining: bc.MaxResponseSize}
+}
+
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Any byte beyond the limit means that the response is too large.
+		var probe [1]byte
+		n, err := l.reader.Read(probe[:])
+		if n > 0 {
+			return 0, errResponseTooLarge
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// responseError converts an error of reading or decoding a response body into iamerrors.Error.
+func (bc *BaseClient) responseError(err error, attempts int) error {
+	var cbErr *callbackError
+	switch {
+	case errors.As(err, &cbErr):
+		return cbErr
+	case errors.Is(err, errResponseTooLarge):
+		return iamerrors.Error{
+			Err:      iamerrors.ErrResponseTooLarge,
+			Desc:     fmt.Sprintf("Response exceeds %d bytes.", bc.MaxResponseSize),
+			Attempts: attempts,
+		}
+	default:
+		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error(), Cause: err, Attempts: attempts}
+	}
+}
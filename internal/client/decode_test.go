@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/internal/client/testdata"
+)
+
+const testListResponse = `{"total": 3, "items": [{"id": "1"}, {"id": "2"}, {"id": "3"}], "links": {"next": null}}`
+
+var errTestStop = errors.New("stop")
+
+type testItem struct {
+	ID string `json:"id"`
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	tests := []struct {
+		name            string
+		maxResponseSize int64
+		contentLength   int64
+		expectedError   error
+	}{
+		{
+			name:            "Test response within the limit",
+			maxResponseSize: int64(len(testListResponse)),
+			contentLength:   -1,
+			expectedError:   nil,
+		},
+		{
+			name:            "Test response exceeding the limit without Content-Length",
+			maxResponseSize: 16,
+			contentLength:   -1,
+			expectedError:   iamerrors.ErrResponseTooLarge,
+		},
+		{
+			name:            "Test response exceeding the limit by Content-Length",
+			maxResponseSize: 16,
+			contentLength:   int64(len(testListResponse)),
+			expectedError:   iamerrors.ErrResponseTooLarge,
+		},
+		{
+			name:            "Test response without a limit",
+			maxResponseSize: 0,
+			contentLength:   int64(len(testListResponse)),
+			expectedError:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			baseClient := &BaseClient{
+				HTTPClient:      &http.Client{},
+				APIUrl:          testdata.TestURL,
+				AuthMethod:      &KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+				MaxResponseSize: tt.maxResponseSize,
+			}
+
+			httpmock.ActivateNonDefault(baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL,
+				func(r *http.Request) (*http.Response, error) {
+					resp := httpmock.NewStringResponse(http.StatusOK, testListResponse)
+					resp.ContentLength = tt.contentLength
+					return resp, nil
+				})
+
+			var output map[string]interface{}
+			input := DoRequestInput{Method: http.MethodGet, Path: "/"}
+			err := baseClient.DoRequestJSON(context.Background(), input, &output)
+
+			require.ErrorIs(err, tt.expectedError)
+		})
+	}
+}
+
+//nolint:funlen // This is a test function.
+func TestForEachJSON(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      string
+		fn            func(items *[]testItem) func(testItem) error
+		expectedItems []testItem
+		expectedError error
+	}{
+		{
+			name:     "Test ForEachJSON skips other fields",
+			response: testListResponse,
+			fn: func(items *[]testItem) func(testItem) error {
+				return func(item testItem) error {
+					*items = append(*items, item)
+					return nil
+				}
+			},
+			expectedItems: []testItem{{ID: "1"}, {ID: "2"}, {ID: "3"}},
+			expectedError: nil,
+		},
+		{
+			name:     "Test ForEachJSON with null",
+			response: `{"items": null}`,
+			fn: func(items *[]testItem) func(testItem) error {
+				return func(item testItem) error {
+					*items = append(*items, item)
+					return nil
+				}
+			},
+			expectedItems: nil,
+			expectedError: nil,
+		},
+		{
+			name:     "Test ForEachJSON returns an error of the callback as is",
+			response: testListResponse,
+			fn: func(items *[]testItem) func(testItem) error {
+				return func(item testItem) error {
+					*items = append(*items, item)
+					if item.ID == "2" {
+						return errTestStop
+					}
+					return nil
+				}
+			},
+			expectedItems: []testItem{{ID: "1"}, {ID: "2"}},
+			expectedError: errTestStop,
+		},
+		{
+			name:     "Test ForEachJSON with an invalid body",
+			response: `{"items": {"id": "1"}}`,
+			fn: func(items *[]testItem) func(testItem) error {
+				return func(item testItem) error {
+					*items = append(*items, item)
+					return nil
+				}
+			},
+			expectedItems: nil,
+			expectedError: iamerrors.ErrInternalAppError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			baseClient := &BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			}
+
+			httpmock.ActivateNonDefault(baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL,
+				httpmock.NewStringResponder(http.StatusOK, tt.response))
+
+			var items []testItem
+			err := baseClient.DoRequestDecode(context.Background(), DoRequestInput{Method: http.MethodGet, Path: "/"},
+				ForEachJSON("items", tt.fn(&items)))
+
+			require.ErrorIs(err, tt.expectedError)
+			if errors.Is(tt.expectedError, errTestStop) {
+				require.Equal(errTestStop, err)
+			}
+			assert.Equal(tt.expectedItems, items)
+		})
+	}
+}
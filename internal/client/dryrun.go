@@ -0,0 +1,40 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/iamrequest"
+)
+
+// dryRunPlan returns the plan for mutating calls: the one passed in options of the call takes precedence
+// over DryRun of the client.
+func (bc *BaseClient) dryRunPlan(options iamrequest.Options) *iamrequest.Plan {
+	if options.DryRun != nil {
+		return options.DryRun
+	}
+	return bc.DryRun
+}
+
+// planCall records the call into the plan instead of sending it.
+// Sensitive fields of the body, e.g. passwords, are redacted like in logs.
+func planCall(plan *iamrequest.Plan, operation iammiddleware.Operation, body io.Reader) error {
+	call := iamrequest.PlannedCall{Operation: operation}
+	if body != nil {
+		if err := json.NewDecoder(body).Decode(&call.Payload); err != nil {
+			return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error(), Cause: err}
+		}
+		call.Payload = redactValue(call.Payload)
+	}
+
+	plan.Add(call)
+	return nil
+}
+
+// isMutating reports whether a request with the method may change resources.
+func isMutating(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/internal/client/testdata"
+)
+
+//nolint:funlen // This is a test function.
+func TestDoRequestDryRun(t *testing.T) {
+	clientPlan := &iamrequest.Plan{}
+	callPlan := &iamrequest.Plan{}
+
+	tests := []struct {
+		name             string
+		clientPlan       *iamrequest.Plan
+		method           string
+		body             string
+		options          []iamrequest.Option
+		expectedError    error
+		expectedCalls    int
+		expectedPlan     *iamrequest.Plan
+		expectedPayload  interface{}
+		expectedPlanSize int
+	}{
+		{
+			name:         "Test DryRun plans mutating request",
+			clientPlan:   clientPlan,
+			method:       http.MethodPut,
+			body:         `{"roles":[{"role_name":"member"}]}`,
+			expectedPlan: clientPlan,
+			expectedPayload: map[string]interface{}{
+				"roles": []interface{}{map[string]interface{}{"role_name": "member"}},
+			},
+			expectedPlanSize: 1,
+		},
+		{
+			name:             "Test DryRun plans request without body",
+			clientPlan:       clientPlan,
+			method:           http.MethodDelete,
+			expectedPlan:     clientPlan,
+			expectedPlanSize: 1,
+		},
+		{
+			name:          "Test DryRun sends reads",
+			clientPlan:    clientPlan,
+			method:        http.MethodGet,
+			expectedCalls: 1,
+			expectedPlan:  clientPlan,
+		},
+		{
+			name:             "Test DryRun of a single call",
+			method:           http.MethodPost,
+			body:             `{"name":"test"}`,
+			options:          []iamrequest.Option{iamrequest.WithDryRun(callPlan)},
+			expectedPlan:     callPlan,
+			expectedPayload:  map[string]interface{}{"name": "test"},
+			expectedPlanSize: 1,
+		},
+		{
+			name:             "Test DryRun redacts sensitive fields",
+			clientPlan:       clientPlan,
+			method:           http.MethodPatch,
+			body:             `{"name":"robot","password":"Qazwsxedc123"}`,
+			expectedPlan:     clientPlan,
+			expectedPayload:  map[string]interface{}{"name": "robot", "password": Redacted},
+			expectedPlanSize: 1,
+		},
+		{
+			name:          "Test DryRun with invalid body",
+			clientPlan:    clientPlan,
+			method:        http.MethodPatch,
+			body:          `{`,
+			expectedError: iamerrors.ErrInternalAppError,
+			expectedPlan:  clientPlan,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			tt.expectedPlan.Reset()
+			baseClient := &BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+				UserAgent:  testdata.TestUserAgent,
+				DryRun:     tt.clientPlan,
+			}
+
+			httpmock.ActivateNonDefault(baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(tt.method, testdata.TestURL+"iam/v1/users/123",
+				httpmock.NewStringResponder(http.StatusOK, testdata.TestDoRequestRaw))
+
+			input := DoRequestInput{
+				Method:    tt.method,
+				Path:      "iam/v1/users/123",
+				Operation: iammiddleware.Operation{Service: "users", Name: "Test"},
+				Options:   tt.options,
+			}
+			if tt.body != "" {
+				input.Body = strings.NewReader(tt.body)
+			}
+			_, err := baseClient.DoRequest(context.Background(), input)
+
+			if tt.expectedError == nil {
+				require.NoError(err)
+			} else {
+				require.ErrorIs(err, tt.expectedError)
+			}
+			assert.Equal(tt.expectedCalls, httpmock.GetTotalCallCount())
+
+			calls := tt.expectedPlan.Calls()
+			require.Len(calls, tt.expectedPlanSize)
+			if tt.expectedPlanSize > 0 {
+				assert.Equal(tt.method, calls[0].Operation.Method)
+				assert.Equal("iam/v1/users/123", calls[0].Operation.Path)
+				assert.Equal(tt.expectedPayload, calls[0].Payload)
+			}
+		})
+	}
+}
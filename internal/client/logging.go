@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/selectel/iam-go/v2/iammiddleware"
+)
+
+const (
+	// Redacted replaces secrets in logs.
+	Redacted = "[REDACTED]"
+
+	// requestIDHeader represents a header of the IAM API response with an identifier of the request.
+	requestIDHeader = "X-Request-Id"
+)
+
+// startLog returns a function, which logs the operation once it's finished.
+func (bc *BaseClient) startLog(ctx context.Context, operation iammiddleware.Operation) func(requestResult, error) {
+	if bc.Logger == nil {
+		return func(requestResult, error) {}
+	}
+
+	start := time.Now()
+
+	return func(result requestResult, err error) {
+		attributes := []slog.Attr{
+			slog.String("operation", operation.String()),
+			slog.String("method", operation.Method),
+			slog.String("path", operation.Path),
+			slog.Int("status", result.statusCode),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("request_id", result.requestID),
+			slog.Int("attempts", result.attempts),
+		}
+		if bc.LogBodies && bc.Logger.Enabled(ctx, slog.LevelDebug) {
+			attributes = append(attributes,
+				slog.String("request_body", redactBody(result.requestBody)),
+				slog.String("response_body", redactBody(result.body)),
+			)
+		}
+
+		if err != nil {
+			attributes = append(attributes, slog.String("error", err.Error()))
+			bc.Logger.LogAttrs(ctx, slog.LevelWarn, "IAM API request failed", attributes...)
+			return
+		}
+		bc.Logger.LogAttrs(ctx, slog.LevelDebug, "IAM API request", attributes...)
+	}
+}
+
+// redactBody returns the JSON body with values of sensitive fields replaced by Redacted.
+// Bodies, which are not valid JSON, are not logged at all, since they can't be redacted reliably.
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	redacted, err := RedactJSON(body)
+	if err != nil {
+		return fmt.Sprintf("[%d bytes of non-JSON body]", len(body))
+	}
+	return string(redacted)
+}
+
+// RedactJSON returns the JSON body with values of sensitive fields replaced by Redacted.
+// Keys of JSON objects in the result are sorted, so equal bodies are always redacted to the same bytes.
+func RedactJSON(body []byte) ([]byte, error) {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return redacted, nil
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if isSensitiveField(key) {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = redactValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// isSensitiveField reports whether the JSON field contains a secret, e.g. a password of a Service User
+// or a secret key of S3 Credentials.
+func isSensitiveField(name string) bool {
+	switch name {
+	case "password", "secret_key", "token":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"errors"
+	"time"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iammetrics"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+)
+
+// startMetrics notifies Metrics about the started operation and returns a function to be called once it's finished.
+func (bc *BaseClient) startMetrics(operation iammiddleware.Operation) func(result requestResult, err error) {
+	if bc.Metrics == nil {
+		return func(requestResult, error) {}
+	}
+
+	start := time.Now()
+	bc.Metrics.RequestStarted(operation)
+
+	return func(result requestResult, err error) {
+		bc.Metrics.RequestFinished(operation, iammetrics.Result{
+			StatusCode: result.statusCode,
+			ErrorCode:  errorCode(err),
+			Attempts:   result.attempts,
+			Duration:   time.Since(start),
+		})
+	}
+}
+
+// errorCode returns a code of iamerrors.Error, e.g. "USER_NOT_FOUND", or an empty string for other errors.
+func errorCode(err error) string {
+	var iamErr iamerrors.Error
+	if errors.As(err, &iamErr) && iamErr.Err != nil {
+		return iamErr.Err.Error()
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter limits the rate of requests with a token bucket and the number of requests in flight
+// with a semaphore. A nil RateLimiter doesn't limit anything.
+type RateLimiter struct {
+	// rate represents the number of tokens added to the bucket per second. Zero means no rate limit.
+	rate float64
+
+	// burst represents the capacity of the bucket.
+	burst float64
+
+	// inFlight is a semaphore for requests in flight. Nil means no concurrency limit.
+	inFlight chan struct{}
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a new instance of RateLimiter.
+// Zero requestsPerSecond or maxInFlight disables the corresponding limit.
+func NewRateLimiter(requestsPerSecond float64, burst, maxInFlight int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	limiter := &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+	if maxInFlight > 0 {
+		limiter.inFlight = make(chan struct{}, maxInFlight)
+	}
+
+	return limiter
+}
+
+// Wait blocks until the request is allowed to be sent or the context is done.
+// The returned function must be called once the response headers are received or the request fails.
+func (l *RateLimiter) Wait(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	if err := l.waitToken(ctx); err != nil {
+		return nil, err
+	}
+
+	if l.inFlight == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.inFlight <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-l.inFlight })
+	}, nil
+}
+
+func (l *RateLimiter) waitToken(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+	// The token is reserved right away, so concurrent callers wait for their own turn.
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	if err := sleep(ctx, delay); err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/internal/client/testdata"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	tests := []struct {
+		name        string
+		limiter     *RateLimiter
+		requests    int
+		minDuration time.Duration
+		maxDuration time.Duration
+	}{
+		{
+			name:        "Test nil RateLimiter doesn't limit",
+			limiter:     nil,
+			requests:    10,
+			maxDuration: 50 * time.Millisecond,
+		},
+		{
+			name:        "Test requests within burst aren't delayed",
+			limiter:     NewRateLimiter(1, 5, 0),
+			requests:    5,
+			maxDuration: 50 * time.Millisecond,
+		},
+		{
+			name:        "Test requests above burst are delayed",
+			limiter:     NewRateLimiter(50, 1, 0),
+			requests:    6,
+			minDuration: 90 * time.Millisecond,
+			maxDuration: time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			started := time.Now()
+			for i := 0; i < tt.requests; i++ {
+				release, err := tt.limiter.Wait(context.Background())
+				require.NoError(err)
+				release()
+			}
+			elapsed := time.Since(started)
+
+			assert.GreaterOrEqual(elapsed, tt.minDuration)
+			assert.Less(elapsed, tt.maxDuration)
+		})
+	}
+}
+
+func TestRateLimiterWaitHonorsContext(t *testing.T) {
+	require := require.New(t)
+
+	limiter := NewRateLimiter(0.1, 1, 1)
+	release, err := limiter.Wait(context.Background())
+	require.NoError(err)
+
+	// Both the token bucket and the semaphore are exhausted.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Wait(ctx)
+	require.ErrorIs(err, context.DeadlineExceeded)
+
+	release()
+	limiter = NewRateLimiter(0, 1, 1)
+	_, err = limiter.Wait(context.Background())
+	require.NoError(err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Wait(ctx)
+	require.ErrorIs(err, context.DeadlineExceeded)
+}
+
+func TestDoRequestMaxInFlight(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	const maxInFlight = 3
+
+	var inFlight, maxObserved atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			observed := maxObserved.Load()
+			if current <= observed || maxObserved.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprint(w, testdata.TestDoRequestRaw)
+	}))
+	defer api.Close()
+
+	baseClient := &BaseClient{
+		HTTPClient:  &http.Client{},
+		APIUrl:      api.URL,
+		AuthMethod:  &KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+		UserAgent:   testdata.TestUserAgent,
+		RateLimiter: NewRateLimiter(0, 0, maxInFlight),
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := baseClient.DoRequest(context.Background(), DoRequestInput{Method: http.MethodGet, Path: "/"})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(err)
+	}
+	assert.LessOrEqual(maxObserved.Load(), int32(maxInFlight))
+	assert.Positive(maxObserved.Load())
+}
+
+func TestDoRequestRateLimitCanceled(t *testing.T) {
+	require := require.New(t)
+
+	baseClient := &BaseClient{
+		HTTPClient:  &http.Client{},
+		APIUrl:      testdata.TestURL,
+		AuthMethod:  &KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+		UserAgent:   testdata.TestUserAgent,
+		RateLimiter: NewRateLimiter(0, 0, 1),
+	}
+	release, err := baseClient.RateLimiter.Wait(context.Background())
+	require.NoError(err)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = baseClient.DoRequest(ctx, DoRequestInput{Method: http.MethodGet, Path: "/"})
+
+	require.ErrorIs(err, iamerrors.ErrInternalAppError)
+	require.ErrorIs(err, context.Canceled)
+}
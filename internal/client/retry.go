@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/internal/transient"
+)
+
+const (
+	// defaultRetryMinBackoff represents the default delay before the first retry.
+	defaultRetryMinBackoff = 500 * time.Millisecond
+
+	// defaultRetryMaxBackoff represents the default upper bound of the delay between retries.
+	defaultRetryMaxBackoff = 10 * time.Second
+)
+
+// RetryPolicy describes how failed requests are retried.
+// The zero value disables retries.
+type RetryPolicy struct {
+	// MaxAttempts represents the maximum number of attempts, including the first one.
+	MaxAttempts int
+
+	// MinBackoff represents the delay before the first retry. It doubles with every next retry.
+	MinBackoff time.Duration
+
+	// MaxBackoff represents the upper bound of the delay between retries, including the delay
+	// requested by the Retry-After header.
+	MaxBackoff time.Duration
+
+	// RetryNonIdempotent enables retries of POST and PATCH requests.
+	RetryNonIdempotent bool
+}
+
+// retryPolicyFor returns the policy adjusted by options of the call: retries may be disabled,
+// and calls with an idempotency key may be retried regardless of their method.
+func retryPolicyFor(policy RetryPolicy, options iamrequest.Options) RetryPolicy {
+	if options.DisableRetries {
+		return RetryPolicy{}
+	}
+	if options.IdempotencyKey != "" {
+		policy.RetryNonIdempotent = true
+	}
+	return policy
+}
+
+// shouldRetry reports whether the request can be sent again after the given attempt.
+func (p RetryPolicy) shouldRetry(
+	ctx context.Context, attempt int, method string, response *http.Response, err error,
+) bool {
+	if attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+	if !p.RetryNonIdempotent && !isIdempotent(method) {
+		return false
+	}
+	if err != nil {
+		// Errors before sending (e.g. from AuthMethod) and permanent network failures (e.g. an invalid
+		// certificate) can't be fixed by retrying.
+		var sendErr *sendError
+		return errors.As(err, &sendErr) && transient.Is(sendErr.err)
+	}
+
+	switch response.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns a delay before the next attempt. Retry-After header of the response takes precedence
+// over the exponential backoff with full jitter. Both are limited by MaxBackoff.
+func (p RetryPolicy) backoff(attempt int, response *http.Response) time.Duration {
+	minBackoff, maxBackoff := p.MinBackoff, p.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultRetryMinBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+
+	if response != nil {
+		if delay, ok := parseRetryAfter(response.Header.Get("Retry-After")); ok {
+			return min(delay, maxBackoff)
+		}
+	}
+
+	backoff := maxBackoff
+	if shift := attempt - 1; shift < 32 && minBackoff<<shift < maxBackoff {
+		backoff = minBackoff << shift
+	}
+
+	//nolint:gosec // Jitter doesn't need a cryptographically secure random.
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// sendError represents a failure to get any response from the IAM API, e.g. a connection reset.
+type sendError struct {
+	err error
+}
+
+func (e *sendError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sendError) Unwrap() error {
+	return e.err
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// sleep waits for the delay or until the context is done.
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,265 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/internal/client/testdata"
+)
+
+const testRetryBackoff = time.Millisecond
+
+// sequenceResponder returns responses in the given order and checks that every attempt has the same body.
+func sequenceResponder(t *testing.T, body string, responders ...httpmock.Responder) httpmock.Responder {
+	t.Helper()
+
+	attempt := 0
+	return func(r *http.Request) (*http.Response, error) {
+		if body != "" {
+			actual, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Equal(t, body, string(actual))
+		}
+		responder := responders[attempt]
+		if attempt < len(responders)-1 {
+			attempt++
+		}
+		return responder(r)
+	}
+}
+
+func unavailable(retryAfter string) httpmock.Responder {
+	return func(r *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusServiceUnavailable, testdata.TestDoRequestUnavailable)
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp, nil
+	}
+}
+
+//nolint:funlen // This is a test function.
+func TestDoRequestRetries(t *testing.T) {
+	ok := httpmock.NewStringResponder(http.StatusOK, testdata.TestDoRequestRaw)
+	tooManyRequests := httpmock.NewStringResponder(http.StatusTooManyRequests, testdata.TestDoRequestErr)
+	forbidden := httpmock.NewStringResponder(http.StatusForbidden, testdata.TestDoRequestErr)
+	connectionReset := httpmock.NewErrorResponder(syscall.ECONNRESET)
+	badCertificate := httpmock.NewErrorResponder(x509.UnknownAuthorityError{})
+	policy := RetryPolicy{MaxAttempts: 3, MinBackoff: testRetryBackoff, MaxBackoff: testRetryBackoff}
+
+	tests := []struct {
+		name             string
+		policy           RetryPolicy
+		method           string
+		body             string
+		responder        func(t *testing.T) httpmock.Responder
+		expectedBody     []byte
+		expectedError    error
+		expectedAttempts int
+	}{
+		{
+			name:   "Test retry GET on 503 until success",
+			policy: policy,
+			method: http.MethodGet,
+			responder: func(t *testing.T) httpmock.Responder {
+				return sequenceResponder(t, "", unavailable(""), unavailable(""), ok)
+			},
+			expectedBody:     []byte(testdata.TestDoRequestRaw),
+			expectedAttempts: 3,
+		},
+		{
+			name:   "Test retry GET on 503 until attempts are exhausted",
+			policy: policy,
+			method: http.MethodGet,
+			responder: func(t *testing.T) httpmock.Responder {
+				return sequenceResponder(t, "", unavailable(""))
+			},
+			expectedError:    iamerrors.ErrInternalServerError,
+			expectedAttempts: 3,
+		},
+		{
+			name:   "Test retry DELETE on 429 with Retry-After",
+			policy: policy,
+			method: http.MethodDelete,
+			responder: func(t *testing.T) httpmock.Responder {
+				return sequenceResponder(t, "", unavailable("0"), tooManyRequests, ok)
+			},
+			expectedBody:     []byte(testdata.TestDoRequestRaw),
+			expectedAttempts: 3,
+		},
+		{
+			name:   "Test retry PUT on connection reset with the same body",
+			policy: policy,
+			method: http.MethodPut,
+			body:   testdata.TestDoRequestRaw,
+			responder: func(t *testing.T) httpmock.Responder {
+				return sequenceResponder(t, testdata.TestDoRequestRaw, connectionReset, ok)
+			},
+			expectedBody:     []byte(testdata.TestDoRequestRaw),
+			expectedAttempts: 2,
+		},
+		{
+			name:   "Test don't retry invalid certificate",
+			policy: policy,
+			method: http.MethodGet,
+			responder: func(t *testing.T) httpmock.Responder {
+				return sequenceResponder(t, "", badCertificate, ok)
+			},
+			expectedError:    iamerrors.ErrInternalAppError,
+			expectedAttempts: 1,
+		},
+		{
+			name:   "Test don't retry POST by default",
+			policy: policy,
+			method: http.MethodPost,
+			body:   testdata.TestDoRequestRaw,
+			responder: func(t *testing.T) httpmock.Responder {
+				return sequenceResponder(t, testdata.TestDoRequestRaw, unavailable(""), ok)
+			},
+			expectedError:    iamerrors.ErrInternalServerError,
+			expectedAttempts: 1,
+		},
+		{
+			name: "Test retry POST when opted in",
+			policy: RetryPolicy{
+				MaxAttempts: 3, MinBackoff: testRetryBackoff, MaxBackoff: testRetryBackoff, RetryNonIdempotent: true,
+			},
+			method: http.MethodPost,
+			body:   testdata.TestDoRequestRaw,
+			responder: func(t *testing.T) httpmock.Responder {
+				return sequenceResponder(t, testdata.TestDoRequestRaw, unavailable(""), ok)
+			},
+			expectedBody:     []byte(testdata.TestDoRequestRaw),
+			expectedAttempts: 2,
+		},
+		{
+			name:   "Test don't retry client errors",
+			policy: policy,
+			method: http.MethodGet,
+			responder: func(t *testing.T) httpmock.Responder {
+				return sequenceResponder(t, "", forbidden, ok)
+			},
+			expectedError:    iamerrors.ErrForbidden,
+			expectedAttempts: 1,
+		},
+		{
+			name:   "Test don't retry without RetryPolicy",
+			policy: RetryPolicy{},
+			method: http.MethodGet,
+			responder: func(t *testing.T) httpmock.Responder {
+				return sequenceResponder(t, "", connectionReset, ok)
+			},
+			expectedError:    iamerrors.ErrInternalAppError,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			baseClient := &BaseClient{
+				HTTPClient:  &http.Client{},
+				APIUrl:      testdata.TestURL,
+				AuthMethod:  &KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+				UserAgent:   testdata.TestUserAgent,
+				RetryPolicy: tt.policy,
+			}
+
+			httpmock.ActivateNonDefault(baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(tt.method, testdata.TestURL, tt.responder(t))
+
+			var body io.Reader
+			if tt.body != "" {
+				body = bytes.NewReader([]byte(tt.body))
+			}
+			actualBody, err := baseClient.DoRequest(context.Background(), DoRequestInput{
+				Body:   body,
+				Method: tt.method,
+				Path:   "/",
+			})
+
+			require.ErrorIs(err, tt.expectedError)
+			assert.Equal(tt.expectedBody, actualBody)
+			assert.Equal(tt.expectedAttempts, httpmock.GetTotalCallCount())
+
+			var iamError iamerrors.Error
+			if errors.As(err, &iamError) {
+				assert.Equal(tt.expectedAttempts, iamError.Attempts)
+			}
+		})
+	}
+}
+
+func TestDoRequestRetryHonorsContext(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	baseClient := &BaseClient{
+		HTTPClient:  &http.Client{},
+		APIUrl:      testdata.TestURL,
+		AuthMethod:  &KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+		UserAgent:   testdata.TestUserAgent,
+		RetryPolicy: RetryPolicy{MaxAttempts: 3},
+	}
+
+	httpmock.ActivateNonDefault(baseClient.HTTPClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, testdata.TestURL, unavailable("3600"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := baseClient.DoRequest(ctx, DoRequestInput{
+		Method: http.MethodGet,
+		Path:   "/",
+	})
+
+	require.ErrorIs(err, iamerrors.ErrInternalAppError)
+	assert.Less(time.Since(started), time.Second)
+	assert.Equal(1, httpmock.GetTotalCallCount())
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	assert := assert.New(t)
+
+	policy := RetryPolicy{MaxAttempts: 10, MinBackoff: time.Second, MaxBackoff: 4 * time.Second}
+	for attempt := 1; attempt < 100; attempt++ {
+		backoff := policy.backoff(attempt, nil)
+		assert.GreaterOrEqual(backoff, time.Duration(0))
+		assert.LessOrEqual(backoff, 4*time.Second)
+	}
+
+	for _, value := range []string{"3", time.Now().Add(3 * time.Second).UTC().Format(http.TimeFormat)} {
+		response := &http.Response{Header: http.Header{"Retry-After": []string{value}}}
+		backoff := policy.backoff(1, response)
+		assert.InDelta(float64(3*time.Second), float64(backoff), float64(time.Second), value)
+	}
+
+	response := &http.Response{Header: http.Header{"Retry-After": []string{"3600"}}}
+	assert.Equal(4*time.Second, policy.backoff(1, response))
+
+	response = &http.Response{Header: http.Header{"Retry-After": []string{"soon"}}}
+	assert.LessOrEqual(policy.backoff(1, response), time.Second)
+
+	_, ok := parseRetryAfter(strconv.Itoa(-1))
+	assert.False(ok)
+}
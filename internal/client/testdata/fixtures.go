@@ -15,3 +15,47 @@ const TestDoRequestErr = `{
 	"code": "REQUEST_FORBIDDEN",
 	"message": "You don't have permission to do this"
 }`
+
+const (
+	TestUserName    = "service-user"
+	TestPassword    = "test-password"
+	TestAccountID   = "123456"
+	TestProjectName = "test-project"
+)
+
+const TestKeystoneTokenResponse = `{
+	"token": {
+		"expires_at": "%s"
+	}
+}`
+
+const TestDoRequestUnauthorized = `Authentication required`
+
+const TestDoRequestUnavailable = `{
+	"code": "INTERNAL_SERVER_ERROR",
+	"message": "Service is temporarily unavailable"
+}`
+
+const TestDoRequestConflict = `{
+	"code": "GROUP_NAME_TAKEN",
+	"message": "Group with this name already exists"
+}`
+
+const TestDoRequestNotFound = `{
+	"code": "USER_NOT_FOUND",
+	"message": "User not found"
+}`
+
+const TestDoRequestBadGateway = `<html>
+<head><title>502 Bad Gateway</title></head>
+<body><center><h1>502 Bad Gateway</h1></center></body>
+</html>`
+
+const TestDoRequestValidationFailed = `{
+	"code": "REQUEST_VALIDATION_FAILED",
+	"message": "Request validation failed",
+	"details": [
+		{"field": "email", "reason": "must be a valid email"},
+		{"field": "roles.0.scope", "reason": "must be one of: account, project"}
+	]
+}`
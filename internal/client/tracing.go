@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/selectel/iam-go/v2/iammiddleware"
+)
+
+const (
+	attributeOperation    = attribute.Key("iam.operation")
+	attributeService      = attribute.Key("iam.service")
+	attributeErrorCode    = attribute.Key("iam.error_code")
+	attributeAttempts     = attribute.Key("iam.attempts")
+	attributeResourceID   = "iam.resource."
+	attributeMethod       = attribute.Key("http.request.method")
+	attributeStatusCode   = attribute.Key("http.response.status_code")
+	attributePathTemplate = attribute.Key("url.template")
+)
+
+// startSpan starts a span for the operation if Tracer is set.
+// Otherwise, it returns the context as is and a non-recording span.
+func (bc *BaseClient) startSpan(ctx context.Context, operation iammiddleware.Operation) (context.Context, trace.Span) {
+	if bc.Tracer == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+
+	attributes := []attribute.KeyValue{
+		attributeOperation.String(operation.String()),
+		attributeService.String(operation.Service),
+		attributeMethod.String(operation.Method),
+		attributePathTemplate.String(operation.PathTemplate),
+	}
+	for name, id := range operation.ResourceIDs {
+		attributes = append(attributes, attribute.String(attributeResourceID+name, id))
+	}
+
+	return bc.Tracer.Start(ctx, operation.String(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+}
+
+// endSpan records the result of the operation and ends the span.
+func endSpan(span trace.Span, statusCode, attempts int, err error) {
+	if !span.IsRecording() {
+		return
+	}
+
+	if statusCode != 0 {
+		span.SetAttributes(attributeStatusCode.Int(statusCode))
+	}
+	if attempts != 0 {
+		span.SetAttributes(attributeAttempts.Int(attempts))
+	}
+	if err != nil {
+		if code := errorCode(err); code != "" {
+			span.SetAttributes(attributeErrorCode.String(code))
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// injectTraceContext adds trace context headers of the span from ctx to the request.
+func (bc *BaseClient) injectTraceContext(ctx context.Context, request *http.Request) {
+	if bc.Tracer == nil {
+		return
+	}
+
+	propagator := bc.Propagator
+	if propagator == nil {
+		propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	}
+	propagator.Inject(ctx, propagation.HeaderCarrier(request.Header))
+}
@@ -0,0 +1,188 @@
+// Command errorsgen generates sentinel errors of the iamerrors package and the error catalog in docs/errors.md
+// from iamerrors/catalog.yaml, so the SDK and the documentation never drift from each other.
+//
+// It is invoked by go generate in the iamerrors package.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	docsBeginMarker = "<!-- BEGIN ERROR CATALOG -->"
+	docsEndMarker   = "<!-- END ERROR CATALOG -->"
+)
+
+var errNoDocsMarkers = errors.New("error catalog markers were not found")
+
+// group represents a group of errors in the catalog.
+type group struct {
+	Group  string      `yaml:"group"`
+	Errors []errorInfo `yaml:"errors"`
+}
+
+// errorInfo represents an error in the catalog.
+type errorInfo struct {
+	Name        string `yaml:"name"`
+	Code        string `yaml:"code"`
+	Source      string `yaml:"source"`
+	Description string `yaml:"description"`
+}
+
+const goTemplate = `// Code generated by errorsgen from catalog.yaml. DO NOT EDIT.
+
+package iamerrors
+
+import "errors"
+
+var (
+{{- range $i, $group := . }}
+{{- if $i }}
+{{ end }}
+{{- range $group.Errors }}
+	// {{ .Name }} means that {{ lowerFirst .Description }}
+	{{ .Name }} = errors.New("{{ .Code }}")
+{{- end }}
+{{- end }}
+)
+
+// catalogErrors contains all errors of the catalog.
+//
+//nolint:gochecknoglobals // catalogErrors is not global.
+var catalogErrors = []error{
+{{- range . }}
+{{- range .Errors }}
+	{{ .Name }},
+{{- end }}
+{{- end }}
+}
+`
+
+const docsTemplate = `{{ range . }}
+### {{ .Group }}
+
+| Error | Code | Returned by | Description |
+|-------|------|-------------|-------------|
+{{- range .Errors }}
+| _{{ .Name }}_ | ` + "`{{ .Code }}`" + ` | {{ source .Source }} | {{ .Description }} |
+{{- end }}
+{{ end }}`
+
+func main() {
+	catalogPath := flag.String("catalog", "catalog.yaml", "path to the error catalog")
+	outPath := flag.String("out", "errors_gen.go", "path to the generated Go file")
+	docsPath := flag.String("docs", "../docs/errors.md", "path to the documentation with the catalog markers")
+	flag.Parse()
+
+	if err := run(*catalogPath, *outPath, *docsPath); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(catalogPath, outPath, docsPath string) error {
+	groups, err := loadCatalog(catalogPath)
+	if err != nil {
+		return err
+	}
+
+	code, err := renderGo(groups)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outPath, code, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outPath, err)
+	}
+
+	docs, err := os.ReadFile(docsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", docsPath, err)
+	}
+	docs, err = renderDocs(groups, docs)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(docsPath, docs, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", docsPath, err)
+	}
+
+	return nil
+}
+
+func loadCatalog(path string) ([]group, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var groups []group
+	if err := yaml.Unmarshal(data, &groups); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return groups, nil
+}
+
+// renderGo returns the formatted Go source with sentinel errors of the catalog.
+func renderGo(groups []group) ([]byte, error) {
+	tmpl := template.Must(template.New("go").Funcs(template.FuncMap{"lowerFirst": lowerFirst}).Parse(goTemplate))
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, groups); err != nil {
+		return nil, fmt.Errorf("failed to render Go source: %w", err)
+	}
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format Go source: %w", err)
+	}
+	return code, nil
+}
+
+// renderDocs returns the documentation with the catalog replaced between the markers.
+func renderDocs(groups []group, docs []byte) ([]byte, error) {
+	tmpl := template.Must(template.New("docs").Funcs(template.FuncMap{"source": sourceName}).Parse(docsTemplate))
+
+	begin := bytes.Index(docs, []byte(docsBeginMarker))
+	end := bytes.Index(docs, []byte(docsEndMarker))
+	if begin == -1 || end < begin {
+		return nil, errNoDocsMarkers
+	}
+
+	var buf bytes.Buffer
+	buf.Write(docs[:begin+len(docsBeginMarker)])
+	buf.WriteString("\n")
+	if err := tmpl.Execute(&buf, groups); err != nil {
+		return nil, fmt.Errorf("failed to render documentation: %w", err)
+	}
+	buf.WriteString("\n")
+	buf.Write(docs[end:])
+
+	return buf.Bytes(), nil
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	// Keep abbreviations and identifiers, e.g. "IAM" or "iam-go", as is.
+	if next, _ := utf8.DecodeRuneInString(s[size:]); unicode.IsUpper(next) {
+		return s
+	}
+	return string(unicode.ToLower(r)) + s[size:]
+}
+
+func sourceName(source string) string {
+	if strings.EqualFold(source, "api") {
+		return "IAM API"
+	}
+	return "iam-go"
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testCatalogPath = "../../../iamerrors/catalog.yaml"
+	testOutPath     = "../../../iamerrors/errors_gen.go"
+	testDocsPath    = "../../../docs/errors.md"
+)
+
+// TestGeneratedFilesAreUpToDate fails if catalog.yaml was changed without running go generate ./iamerrors.
+func TestGeneratedFilesAreUpToDate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	groups, err := loadCatalog(testCatalogPath)
+	require.NoError(err)
+
+	expectedCode, err := renderGo(groups)
+	require.NoError(err)
+	actualCode, err := os.ReadFile(testOutPath)
+	require.NoError(err)
+	assert.Equal(string(expectedCode), string(actualCode), "run go generate ./iamerrors")
+
+	actualDocs, err := os.ReadFile(testDocsPath)
+	require.NoError(err)
+	expectedDocs, err := renderDocs(groups, actualDocs)
+	require.NoError(err)
+	assert.Equal(string(expectedDocs), string(actualDocs), "run go generate ./iamerrors")
+}
+
+func TestCatalogIsValid(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	groups, err := loadCatalog(testCatalogPath)
+	require.NoError(err)
+
+	names := make(map[string]bool)
+	codes := make(map[string]bool)
+	for _, group := range groups {
+		for _, info := range group.Errors {
+			assert.False(names[info.Name], "duplicate name %s", info.Name)
+			assert.False(codes[info.Code], "duplicate code %s", info.Code)
+			assert.Contains([]string{"api", "client"}, info.Source, "invalid source of %s", info.Name)
+			assert.NotEmpty(info.Description, "no description of %s", info.Name)
+			names[info.Name] = true
+			codes[info.Code] = true
+		}
+	}
+}
+
+func TestRenderDocsWithoutMarkers(t *testing.T) {
+	_, err := renderDocs(nil, []byte("# Error Handling\n"))
+
+	require.ErrorIs(t, err, errNoDocsMarkers)
+}
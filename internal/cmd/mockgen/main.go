@@ -0,0 +1,260 @@
+// Command mockgen generates mocks of the iammock package from the service interfaces of the iam package,
+// so the mocks never drift from the interfaces.
+//
+// It is invoked by go generate in the iam package.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+)
+
+const (
+	// interfaceSuffix represents a suffix of the interfaces to generate mocks for.
+	interfaceSuffix = "API"
+
+	// contextType represents a type of the arguments, which are not recorded.
+	contextType = "context.Context"
+)
+
+var errNoInterfaces = errors.New("no interfaces found")
+
+// source represents a parsed file with interfaces.
+type source struct {
+	StdImports []string
+	Imports    []string
+	Package    string
+	ImportPath string
+	Mocks      []mock
+}
+
+// mock represents a mock of an interface.
+type mock struct {
+	Name      string
+	Interface string
+	Methods   []method
+}
+
+// method represents a method of an interface.
+type method struct {
+	Name       string
+	Params     string
+	Results    string
+	Recorded   string
+	CallArgs   string
+	ZeroValues []string
+}
+
+const goTemplate = `// Code generated by mockgen from {{ .Package }} interfaces. DO NOT EDIT.
+
+package iammock
+
+import (
+{{- range .StdImports }}
+	{{ . }}
+{{- end }}
+
+	{{ .Package }} "{{ .ImportPath }}"
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{ range $mock := .Mocks }}
+// {{ .Name }} is a mock of {{ $.Package }}.{{ .Interface }}.
+// A method without a scripted response returns ErrUnexpectedCall.
+type {{ .Name }} struct {
+	Recorder
+{{- range .Methods }}
+
+	// {{ .Name }}Func is called by {{ .Name }}.
+	{{ .Name }}Func func({{ .Params }}) {{ .Results }}
+{{- end }}
+}
+
+var _ {{ $.Package }}.{{ .Interface }} = (*{{ .Name }})(nil)
+{{ range .Methods }}
+// {{ .Name }} records the call and returns the result of {{ .Name }}Func.
+func (m *{{ $mock.Name }}) {{ .Name }}({{ .Params }}) {{ .Results }} {
+	m.record("{{ .Name }}"{{ .Recorded }})
+	if m.{{ .Name }}Func == nil {
+{{- range $i, $zero := .ZeroValues }}
+		var r{{ $i }} {{ $zero }}
+{{- end }}
+		return {{ range $i, $zero := .ZeroValues }}r{{ $i }}, {{ end }}unexpectedCall("{{ $mock.Name }}", "{{ .Name }}")
+	}
+	return m.{{ .Name }}Func({{ .CallArgs }})
+}
+{{ end }}
+{{- end }}`
+
+func main() {
+	sourcePath := flag.String("source", "api.go", "path to the Go file with interfaces")
+	outPath := flag.String("out", "iammock/mocks_gen.go", "path to the generated Go file")
+	importPath := flag.String("import", "github.com/selectel/iam-go/v2", "import path of the package with interfaces")
+	flag.Parse()
+
+	if err := run(*sourcePath, *outPath, *importPath); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(sourcePath, outPath, importPath string) error {
+	src, err := parseSource(sourcePath, importPath)
+	if err != nil {
+		return err
+	}
+
+	code, err := renderGo(src)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outPath, code, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outPath, err)
+	}
+
+	return nil
+}
+
+// parseSource returns mocks of all interfaces with the "API" suffix declared in the file.
+func parseSource(path, importPath string) (*source, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	src := &source{Package: file.Name.Name, ImportPath: importPath}
+	for _, spec := range file.Imports {
+		// Paths of the standard library have no dots in the first element.
+		if first, _, _ := strings.Cut(spec.Path.Value, "/"); strings.Contains(first, ".") {
+			src.Imports = append(src.Imports, nodeString(fset, spec))
+		} else {
+			src.StdImports = append(src.StdImports, nodeString(fset, spec))
+		}
+	}
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok || !strings.HasSuffix(typeSpec.Name.Name, interfaceSuffix) {
+				continue
+			}
+			iface, ok := typeSpec.Type.(*ast.InterfaceType)
+			if !ok {
+				continue
+			}
+			src.Mocks = append(src.Mocks, newMock(fset, typeSpec.Name.Name, iface))
+		}
+	}
+
+	if len(src.Mocks) == 0 {
+		return nil, fmt.Errorf("%w in %s", errNoInterfaces, path)
+	}
+	return src, nil
+}
+
+func newMock(fset *token.FileSet, name string, iface *ast.InterfaceType) mock {
+	m := mock{Name: strings.TrimSuffix(name, interfaceSuffix), Interface: name}
+	for _, field := range iface.Methods.List {
+		funcType, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			continue
+		}
+		for _, methodName := range field.Names {
+			m.Methods = append(m.Methods, newMethod(fset, methodName.Name, funcType))
+		}
+	}
+	return m
+}
+
+func newMethod(fset *token.FileSet, name string, funcType *ast.FuncType) method {
+	var params, recorded, callArgs []string
+	for i, field := range funcType.Params.List {
+		typ := nodeString(fset, field.Type)
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent(fmt.Sprintf("arg%d", i))}
+		}
+		for _, paramName := range names {
+			params = append(params, paramName.Name+" "+typ)
+			arg := paramName.Name
+			if _, ok := field.Type.(*ast.Ellipsis); ok {
+				arg += "..."
+			}
+			callArgs = append(callArgs, arg)
+			if typ != contextType {
+				recorded = append(recorded, ", "+paramName.Name)
+			}
+		}
+	}
+
+	m := method{
+		Name:     name,
+		Params:   strings.Join(params, ", "),
+		Recorded: strings.Join(recorded, ""),
+		CallArgs: strings.Join(callArgs, ", "),
+	}
+
+	results := resultTypes(fset, funcType)
+	m.Results = strings.Join(results, ", ")
+	if len(results) > 1 {
+		m.Results = "(" + m.Results + ")"
+		// The last result is an error, the other ones are returned as zero values by unscripted calls.
+		m.ZeroValues = results[:len(results)-1]
+	}
+
+	return m
+}
+
+func resultTypes(fset *token.FileSet, funcType *ast.FuncType) []string {
+	if funcType.Results == nil {
+		return nil
+	}
+
+	var results []string
+	for _, field := range funcType.Results.List {
+		typ := nodeString(fset, field.Type)
+		for i := 0; i < max(len(field.Names), 1); i++ {
+			results = append(results, typ)
+		}
+	}
+	return results
+}
+
+// renderGo returns the formatted Go source with mocks.
+func renderGo(src *source) ([]byte, error) {
+	tmpl := template.Must(template.New("go").Parse(goTemplate))
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, src); err != nil {
+		return nil, fmt.Errorf("failed to render Go source: %w", err)
+	}
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format Go source: %w", err)
+	}
+	return code, nil
+}
+
+func nodeString(fset *token.FileSet, node interface{}) string {
+	var buf bytes.Buffer
+	// Nodes of a successfully parsed file are always printable.
+	_ = printer.Fprint(&buf, fset, node)
+	return buf.String()
+}
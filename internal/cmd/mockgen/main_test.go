@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSourcePath = "../../../api.go"
+	testOutPath    = "../../../iammock/mocks_gen.go"
+	testImportPath = "github.com/selectel/iam-go/v2"
+)
+
+// TestGeneratedFilesAreUpToDate fails if api.go was changed without running go generate in the iam package.
+func TestGeneratedFilesAreUpToDate(t *testing.T) {
+	require := require.New(t)
+
+	src, err := parseSource(testSourcePath, testImportPath)
+	require.NoError(err)
+
+	expectedCode, err := renderGo(src)
+	require.NoError(err)
+	actualCode, err := os.ReadFile(testOutPath)
+	require.NoError(err)
+	assert.Equal(t, string(expectedCode), string(actualCode), "run go generate in the iam package")
+}
+
+func TestParseSource(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "api.go")
+	require.NoError(os.WriteFile(path, []byte(`package iam
+
+import "context"
+
+type Options struct{}
+
+type ExampleAPI interface {
+	Get(ctx context.Context, id, name string, opts ...Options) (string, int, error)
+	Delete(context.Context, string) error
+}
+`), 0o600))
+
+	src, err := parseSource(path, testImportPath)
+	require.NoError(err)
+
+	assert.Equal([]string{`"context"`}, src.StdImports)
+	require.Len(src.Mocks, 1)
+	assert.Equal("Example", src.Mocks[0].Name)
+	assert.Equal([]method{
+		{
+			Name:       "Get",
+			Params:     "ctx context.Context, id string, name string, opts ...Options",
+			Results:    "(string, int, error)",
+			Recorded:   ", id, name, opts",
+			CallArgs:   "ctx, id, name, opts...",
+			ZeroValues: []string{"string", "int"},
+		},
+		{
+			Name:     "Delete",
+			Params:   "arg0 context.Context, arg1 string",
+			Results:  "error",
+			Recorded: ", arg1",
+			CallArgs: "arg0, arg1",
+		},
+	}, src.Mocks[0].Methods)
+}
+
+func TestParseSourceWithoutInterfaces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.go")
+	require.NoError(t, os.WriteFile(path, []byte("package iam\n"), 0o600))
+
+	_, err := parseSource(path, testImportPath)
+
+	require.ErrorIs(t, err, errNoInterfaces)
+}
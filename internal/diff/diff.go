@@ -0,0 +1,26 @@
+// Package diff compares the current and the desired sets of values.
+package diff
+
+// Diff returns the values which are missing from current and the values which are present in current
+// but not in desired. Duplicates are ignored, and the order of values is kept.
+func Diff[T comparable](current, desired []T) (missing, extra []T) {
+	return subtract(desired, current), subtract(current, desired)
+}
+
+// subtract returns the values from a which are not in b.
+func subtract[T comparable](a, b []T) []T {
+	seen := make(map[T]struct{}, len(a)+len(b))
+	for _, value := range b {
+		seen[value] = struct{}{}
+	}
+
+	var result []T
+	for _, value := range a {
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		result = append(result, value)
+	}
+	return result
+}
@@ -0,0 +1,28 @@
+// Package transient tells network failures, which may disappear if the request is sent again,
+// from permanent ones, e.g. an invalid certificate or a malformed URL.
+package transient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// Is reports whether err is a transient network failure: the connection was reset or refused,
+// closed unexpectedly or timed out. Cancellation of the context isn't transient.
+func Is(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
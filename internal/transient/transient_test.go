@@ -0,0 +1,76 @@
+package transient
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIs(t *testing.T) {
+	urlError := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://iam.example.org", Err: err}
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name: "Test connection reset",
+			err: urlError(&net.OpError{
+				Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET),
+			}),
+			expected: true,
+		},
+		{
+			name:     "Test connection refused",
+			err:      urlError(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}),
+			expected: true,
+		},
+		{
+			name:     "Test unexpected EOF",
+			err:      urlError(io.ErrUnexpectedEOF),
+			expected: true,
+		},
+		{
+			name:     "Test timeout",
+			err:      urlError(os.ErrDeadlineExceeded),
+			expected: true,
+		},
+		{
+			name:     "Test invalid certificate",
+			err:      urlError(x509.UnknownAuthorityError{}),
+			expected: false,
+		},
+		{
+			name:     "Test malformed URL",
+			err:      &url.Error{Op: "parse", URL: "::", Err: errors.New("missing protocol scheme")},
+			expected: false,
+		},
+		{
+			name:     "Test canceled context",
+			err:      urlError(context.Canceled),
+			expected: false,
+		},
+		{
+			name:     "Test no error",
+			err:      nil,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Is(tt.err))
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package iam
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/s3credentials"
+	"github.com/selectel/iam-go/v2/service/serviceusers"
+)
+
+const (
+	testSecretKey = "test-secret-key"
+
+	testCreateCredentialResponse = `{
+	"name": "test",
+	"project_id": "test-project",
+	"access_key": "test-access-key",
+	"secret_key": "test-secret-key"
+}`
+)
+
+//nolint:funlen // This is a test function.
+func TestWithLogger(t *testing.T) {
+	tests := []struct {
+		name          string
+		prepare       func()
+		call          func(ctx context.Context, c *Client) error
+		bodyLogging   bool
+		expectedLines []string
+	}{
+		{
+			name: "Test WithLogger with a successful call",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodPost, testURL+"iam/v1/service_users/"+testUserID+"/credentials",
+					func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusOK, testCreateCredentialResponse)
+						resp.Header.Set("X-Request-Id", "test-request-id")
+						return resp, nil
+					})
+			},
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.S3Credentials.Create(ctx, testUserID, "test", "test-project")
+				return err
+			},
+			expectedLines: []string{
+				`level=DEBUG msg="IAM API request" operation=s3credentials.Create method=POST ` +
+					`path=iam/v1/service_users/123/credentials status=200 duration=`,
+				`request_id=test-request-id attempts=1`,
+			},
+		},
+		{
+			name: "Test WithLogger with a failed call",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testURL+"iam/v1/users/"+testUserID,
+					httpmock.NewStringResponder(http.StatusNotFound, testUserNotFoundResponse),
+				)
+			},
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.Users.Get(ctx, testUserID)
+				return err
+			},
+			expectedLines: []string{
+				`level=WARN msg="IAM API request failed" operation=users.Get method=GET ` +
+					`path=iam/v1/users/123 status=404`,
+				`error="iam-go: error — USER_NOT_FOUND: User not found"`,
+			},
+		},
+		{
+			name: "Test WithBodyLogging redacts a secret key",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodPost, testURL+"iam/v1/service_users/"+testUserID+"/credentials",
+					httpmock.NewStringResponder(http.StatusOK, testCreateCredentialResponse),
+				)
+			},
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.S3Credentials.Create(ctx, testUserID, "test", "test-project")
+				return err
+			},
+			bodyLogging: true,
+			expectedLines: []string{
+				`request_body="{\"name\":\"test\",\"project_id\":\"test-project\"}"`,
+				`\"secret_key\":\"[REDACTED]\"`,
+			},
+		},
+		{
+			name: "Test WithBodyLogging redacts a password",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodPost, testURL+"iam/v1/service_users",
+					httpmock.NewStringResponder(http.StatusOK, "{}"),
+				)
+			},
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.ServiceUsers.Create(ctx, serviceusers.CreateRequest{
+					Name:     "test",
+					Password: testPassword,
+					Roles:    []roles.Role{{RoleName: "member", Scope: "account"}},
+				})
+				return err
+			},
+			bodyLogging: true,
+			expectedLines: []string{
+				`\"password\":\"[REDACTED]\"`,
+				`response_body={}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			httpClient := &http.Client{}
+			httpmock.ActivateNonDefault(httpClient)
+			defer httpmock.DeactivateAndReset()
+
+			tt.prepare()
+
+			var output bytes.Buffer
+			opts := []Option{
+				WithAPIUrl(testURL),
+				WithAuthOpts(&AuthOpts{KeystoneToken: testToken}),
+				WithCustomHTTPClient(httpClient),
+				WithLogger(slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+			}
+			if tt.bodyLogging {
+				opts = append(opts, WithBodyLogging())
+			}
+			client, err := New(opts...)
+			require.NoError(err)
+
+			_ = tt.call(context.Background(), client)
+
+			for _, line := range tt.expectedLines {
+				assert.Contains(output.String(), line)
+			}
+			assert.NotContains(output.String(), testToken)
+			assert.NotContains(output.String(), testPassword)
+			assert.NotContains(output.String(), testSecretKey)
+		})
+	}
+}
+
+func TestLogValueRedactsSecrets(t *testing.T) {
+	assert := assert.New(t)
+
+	var output bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&output, nil))
+
+	password := testPassword
+	logger.Info("test",
+		"create", serviceusers.CreateRequest{Name: "test", Password: testPassword},
+		"update", serviceusers.UpdateRequest{Password: testPassword},
+		"patch", serviceusers.PatchRequest{Password: &password},
+		"credential", s3credentials.CreateResponse{SecretKey: testSecretKey},
+	)
+
+	assert.Contains(output.String(), `"password":"[REDACTED]"`)
+	assert.Contains(output.String(), `"secret_key":"[REDACTED]"`)
+	assert.Contains(output.String(), `"patch":{"password":"[REDACTED]"}`)
+	assert.NotContains(output.String(), testPassword)
+	assert.NotContains(output.String(), testSecretKey)
+}
+
+func TestAuthOptsLogValueRedactsSecrets(t *testing.T) {
+	assert := assert.New(t)
+
+	var output bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&output, nil))
+
+	auth := AuthOpts{KeystoneToken: testToken, UserName: "test", Password: testPassword, AccountID: "123"}
+	logger.Info("test",
+		"auth", auth,
+		"config", Config{APIUrl: testURL, Auth: &auth},
+		"empty", Config{},
+		"file", ConfigFile{Profiles: map[string]Config{"default": {Auth: &auth}}},
+	)
+
+	assert.Contains(output.String(), `"user_name":"test"`)
+	assert.Contains(output.String(), `"password":"[REDACTED]"`)
+	assert.Contains(output.String(), `"keystone_token":"[REDACTED]"`)
+	assert.Contains(output.String(), `"config":{"api_url":"`+testURL+`"`)
+	assert.Contains(output.String(), `"file":{"default_profile":"","profiles":{"default":{`)
+	assert.NotContains(output.String(), testToken)
+	assert.NotContains(output.String(), testPassword)
+}
@@ -0,0 +1,676 @@
+package iam
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/service/federations/saml"
+	"github.com/selectel/iam-go/v2/service/federations/saml/certificates"
+	"github.com/selectel/iam-go/v2/service/federations/saml/groupmappings"
+	"github.com/selectel/iam-go/v2/service/groups"
+	"github.com/selectel/iam-go/v2/service/principals"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/serviceusers"
+)
+
+const (
+	testUserID          = "123"
+	testGroupID         = "456"
+	testFederationID    = "789"
+	testCertificateID   = "012"
+	testAccessKey       = "345"
+	testExternalGroupID = "678"
+)
+
+var errTestMiddleware = errors.New("test middleware error")
+
+// recordingMiddleware records operations and responds with an empty JSON object without sending requests.
+func recordingMiddleware(operations *[]iammiddleware.Operation) iammiddleware.Middleware {
+	return func(_ iammiddleware.Handler) iammiddleware.Handler {
+		return func(request *http.Request, operation iammiddleware.Operation) (*http.Response, error) {
+			*operations = append(*operations, operation)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader("{}")),
+				Request:    request,
+			}, nil
+		}
+	}
+}
+
+func newMiddlewareTestClient(t *testing.T, middlewares ...iammiddleware.Middleware) *Client {
+	t.Helper()
+
+	client, err := New(
+		WithAPIUrl(testURL),
+		WithAuthOpts(&AuthOpts{KeystoneToken: testToken}),
+		WithMiddleware(middlewares...),
+	)
+	require.NoError(t, err)
+
+	return client
+}
+
+//nolint:funlen // This is a test function.
+func TestMiddlewareOperations(t *testing.T) {
+	testRoles := []roles.Role{{RoleName: "member", Scope: "account"}}
+
+	tests := []struct {
+		name     string
+		call     func(ctx context.Context, c *Client) error
+		expected iammiddleware.Operation
+	}{
+		{
+			name: "Test users.AssignRoles",
+			call: func(ctx context.Context, c *Client) error {
+				return c.Users.AssignRoles(ctx, testUserID, testRoles)
+			},
+			expected: iammiddleware.Operation{
+				Service:      "users",
+				Name:         "AssignRoles",
+				ResourceIDs:  map[string]string{"user_id": testUserID},
+				Method:       http.MethodPut,
+				PathTemplate: "iam/v1/users/{user_id}/roles",
+				Path:         "iam/v1/users/123/roles",
+			},
+		},
+		{
+			name: "Test users.List",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.Users.List(ctx)
+				return err
+			},
+			expected: iammiddleware.Operation{
+				Service:      "users",
+				Name:         "List",
+				Method:       http.MethodGet,
+				PathTemplate: "iam/v1/users",
+				Path:         "iam/v1/users",
+			},
+		},
+		{
+			name: "Test serviceusers.UnassignRoles",
+			call: func(ctx context.Context, c *Client) error {
+				return c.ServiceUsers.UnassignRoles(ctx, testUserID, testRoles)
+			},
+			expected: iammiddleware.Operation{
+				Service:      "serviceusers",
+				Name:         "UnassignRoles",
+				ResourceIDs:  map[string]string{"user_id": testUserID},
+				Method:       http.MethodDelete,
+				PathTemplate: "iam/v1/service_users/{user_id}/roles",
+				Path:         "iam/v1/service_users/123/roles",
+			},
+		},
+		{
+			name: "Test serviceusers.Update",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.ServiceUsers.Update(ctx, testUserID, serviceusers.UpdateRequest{Name: "test"})
+				return err
+			},
+			expected: iammiddleware.Operation{
+				Service:      "serviceusers",
+				Name:         "Update",
+				ResourceIDs:  map[string]string{"user_id": testUserID},
+				Method:       http.MethodPatch,
+				PathTemplate: "iam/v1/service_users/{user_id}",
+				Path:         "iam/v1/service_users/123",
+			},
+		},
+		{
+			name: "Test serviceusers.Patch",
+			call: func(ctx context.Context, c *Client) error {
+				name := "test"
+				_, err := c.ServiceUsers.Patch(ctx, testUserID, serviceusers.PatchRequest{Name: &name})
+				return err
+			},
+			expected: iammiddleware.Operation{
+				Service:      "serviceusers",
+				Name:         "Patch",
+				ResourceIDs:  map[string]string{"user_id": testUserID},
+				Method:       http.MethodPatch,
+				PathTemplate: "iam/v1/service_users/{user_id}",
+				Path:         "iam/v1/service_users/123",
+			},
+		},
+		{
+			name: "Test groups.AddUsers",
+			call: func(ctx context.Context, c *Client) error {
+				return c.Groups.AddUsers(ctx, testGroupID, []iamid.KeystoneID{testUserID})
+			},
+			expected: iammiddleware.Operation{
+				Service:      "groups",
+				Name:         "AddUsers",
+				ResourceIDs:  map[string]string{"group_id": testGroupID},
+				Method:       http.MethodPut,
+				PathTemplate: "iam/v1/groups/{group_id}/users",
+				Path:         "iam/v1/groups/456/users",
+			},
+		},
+		{
+			name: "Test groups.Create",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.Groups.Create(ctx, groups.CreateRequest{Name: "test"})
+				return err
+			},
+			expected: iammiddleware.Operation{
+				Service:      "groups",
+				Name:         "Create",
+				Method:       http.MethodPost,
+				PathTemplate: "iam/v1/groups",
+				Path:         "iam/v1/groups",
+			},
+		},
+		{
+			name: "Test roles.List",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.Roles.List(ctx)
+				return err
+			},
+			expected: iammiddleware.Operation{
+				Service:      "roles",
+				Name:         "List",
+				Method:       http.MethodGet,
+				PathTemplate: "iam/v1/roles",
+				Path:         "iam/v1/roles",
+			},
+		},
+		{
+			name: "Test s3credentials.Delete",
+			call: func(ctx context.Context, c *Client) error {
+				return c.S3Credentials.Delete(ctx, testUserID, testAccessKey)
+			},
+			expected: iammiddleware.Operation{
+				Service:      "s3credentials",
+				Name:         "Delete",
+				ResourceIDs:  map[string]string{"user_id": testUserID, "access_key": testAccessKey},
+				Method:       http.MethodDelete,
+				PathTemplate: "iam/v1/service_users/{user_id}/credentials/{access_key}",
+				Path:         "iam/v1/service_users/123/credentials/345",
+			},
+		},
+		{
+			name: "Test saml.Preview",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.SAMLFederations.Preview(ctx, testFederationID)
+				return err
+			},
+			expected: iammiddleware.Operation{
+				Service:      "saml",
+				Name:         "Preview",
+				ResourceIDs:  map[string]string{"federation_id": testFederationID},
+				Method:       http.MethodGet,
+				PathTemplate: "v1/federations/saml/{federation_id}/preview",
+				Path:         "v1/federations/saml/789/preview",
+			},
+		},
+		{
+			name: "Test saml.Update",
+			call: func(ctx context.Context, c *Client) error {
+				return c.SAMLFederations.Update(ctx, testFederationID, saml.UpdateRequest{})
+			},
+			expected: iammiddleware.Operation{
+				Service:      "saml",
+				Name:         "Update",
+				ResourceIDs:  map[string]string{"federation_id": testFederationID},
+				Method:       http.MethodPatch,
+				PathTemplate: "v1/federations/saml/{federation_id}",
+				Path:         "v1/federations/saml/789",
+			},
+		},
+		{
+			name: "Test certificates.Update",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.SAMLFederations.Certificates.Update(
+					ctx, testFederationID, testCertificateID, certificates.UpdateRequest{},
+				)
+				return err
+			},
+			expected: iammiddleware.Operation{
+				Service:      "certificates",
+				Name:         "Update",
+				ResourceIDs:  map[string]string{"federation_id": testFederationID, "certificate_id": testCertificateID},
+				Method:       http.MethodPatch,
+				PathTemplate: "v1/federations/saml/{federation_id}/certificates/{certificate_id}",
+				Path:         "v1/federations/saml/789/certificates/012",
+			},
+		},
+		{
+			name: "Test groupmappings.Update",
+			call: func(ctx context.Context, c *Client) error {
+				return c.SAMLFederations.GroupMappings.Update(
+					ctx, testFederationID, groupmappings.GroupMappingsRequest{},
+				)
+			},
+			expected: iammiddleware.Operation{
+				Service:      "groupmappings",
+				Name:         "Update",
+				ResourceIDs:  map[string]string{"federation_id": testFederationID},
+				Method:       http.MethodPut,
+				PathTemplate: "v1/federations/saml/{federation_id}/group-mappings",
+				Path:         "v1/federations/saml/789/group-mappings",
+			},
+		},
+		{
+			name: "Test groupmappings.Exists",
+			call: func(ctx context.Context, c *Client) error {
+				_, err := c.SAMLFederations.GroupMappings.Exists(
+					ctx, testFederationID, testGroupID, testExternalGroupID,
+				)
+				return err
+			},
+			expected: iammiddleware.Operation{
+				Service: "groupmappings",
+				Name:    "Exists",
+				ResourceIDs: map[string]string{
+					"federation_id":     testFederationID,
+					"group_id":          testGroupID,
+					"external_group_id": testExternalGroupID,
+				},
+				Method: http.MethodHead,
+				PathTemplate: "v1/federations/saml/{federation_id}/group-mappings/{group_id}" +
+					"/external-groups/{external_group_id}",
+				Path: "v1/federations/saml/789/group-mappings/456/external-groups/678",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var operations []iammiddleware.Operation
+			client := newMiddlewareTestClient(t, recordingMiddleware(&operations))
+
+			err := tt.call(context.Background(), client)
+
+			require.NoError(err)
+			require.Len(operations, 1)
+			assert.Equal(tt.expected, operations[0])
+		})
+	}
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var calls []string
+	setHeader := func(next iammiddleware.Handler) iammiddleware.Handler {
+		return func(request *http.Request, operation iammiddleware.Operation) (*http.Response, error) {
+			calls = append(calls, "setHeader")
+			request.Header.Set("X-Request-Source", "test")
+			return next(request, operation)
+		}
+	}
+	checkHeader := func(next iammiddleware.Handler) iammiddleware.Handler {
+		return func(request *http.Request, operation iammiddleware.Operation) (*http.Response, error) {
+			calls = append(calls, "checkHeader")
+			assert.Equal("test", request.Header.Get("X-Request-Source"))
+			assert.Equal(testToken, request.Header.Get("X-Auth-Token"))
+			return next(request, operation)
+		}
+	}
+
+	var operations []iammiddleware.Operation
+	client := newMiddlewareTestClient(t, setHeader, checkHeader, recordingMiddleware(&operations))
+
+	_, err := client.Users.Get(context.Background(), testUserID)
+
+	require.NoError(err)
+	assert.Equal([]string{"setHeader", "checkHeader"}, calls)
+	assert.Len(operations, 1)
+}
+
+func TestMiddlewareError(t *testing.T) {
+	require := require.New(t)
+
+	failing := func(_ iammiddleware.Handler) iammiddleware.Handler {
+		return func(_ *http.Request, _ iammiddleware.Operation) (*http.Response, error) {
+			return nil, errTestMiddleware
+		}
+	}
+	client := newMiddlewareTestClient(t, failing)
+
+	_, err := client.Users.Get(context.Background(), testUserID)
+
+	require.ErrorIs(err, iamerrors.ErrInternalAppError)
+	require.ErrorIs(err, errTestMiddleware)
+}
+
+func TestMiddlewareNoResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *http.Response
+	}{
+		{
+			name: "Test middleware returning no response",
+		},
+		{
+			name:     "Test middleware returning response without body",
+			response: &http.Response{StatusCode: http.StatusOK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			shortCircuit := func(_ iammiddleware.Handler) iammiddleware.Handler {
+				return func(_ *http.Request, _ iammiddleware.Operation) (*http.Response, error) {
+					return tt.response, nil
+				}
+			}
+			client := newMiddlewareTestClient(t, shortCircuit)
+
+			_, err := client.Users.Get(context.Background(), testUserID)
+
+			require.ErrorIs(err, iamerrors.ErrInternalAppError)
+		})
+	}
+}
+
+// roundTripperFunc is an http.RoundTripper implemented by a function.
+type roundTripperFunc func(request *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+func TestOperationFromContext(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var (
+		operation iammiddleware.Operation
+		ok        bool
+	)
+	transport := roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		operation, ok = iammiddleware.OperationFromContext(request.Context())
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("{}")),
+			Request:    request,
+		}, nil
+	})
+	client, err := New(
+		WithAPIUrl(testURL),
+		WithAuthOpts(&AuthOpts{KeystoneToken: testToken}),
+		WithCustomHTTPClient(&http.Client{Transport: transport}),
+	)
+	require.NoError(err)
+
+	_, err = client.Users.Get(context.Background(), testUserID)
+
+	require.NoError(err)
+	require.True(ok)
+	assert.Equal("users.Get", operation.String())
+	assert.Equal("iam/v1/users/{user_id}", operation.PathTemplate)
+
+	_, ok = iammiddleware.OperationFromContext(context.Background())
+	assert.False(ok)
+}
+
+func TestRequestOptions(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var header http.Header
+	checkHeader := func(next iammiddleware.Handler) iammiddleware.Handler {
+		return func(request *http.Request, operation iammiddleware.Operation) (*http.Response, error) {
+			header = request.Header.Clone()
+			return next(request, operation)
+		}
+	}
+	var operations []iammiddleware.Operation
+	client := newMiddlewareTestClient(t, checkHeader, recordingMiddleware(&operations))
+
+	err := client.Groups.AddUsers(context.Background(), testGroupID, []iamid.KeystoneID{testUserID},
+		iamrequest.WithHeader("X-Request-Source", "test"),
+		iamrequest.WithIdempotencyKey("key"),
+		iamrequest.WithToken("another-token"),
+	)
+
+	require.NoError(err)
+	assert.Equal("test", header.Get("X-Request-Source"))
+	assert.Equal("key", header.Get(iamrequest.IdempotencyKeyHeader))
+	assert.Equal("another-token", header.Get("X-Auth-Token"))
+}
+
+// subRequest describes a request sent by a method, which sends several requests.
+type subRequest struct {
+	operation      string
+	idempotencyKey string
+}
+
+// scriptedMiddleware records requests and their deadlines and responds with the body set for the operation
+// or with an empty object.
+func scriptedMiddleware(
+	requests *[]subRequest, deadlines *[]time.Time, bodies map[string]string,
+) iammiddleware.Middleware {
+	return func(_ iammiddleware.Handler) iammiddleware.Handler {
+		return func(request *http.Request, operation iammiddleware.Operation) (*http.Response, error) {
+			*requests = append(*requests, subRequest{
+				operation:      operation.String(),
+				idempotencyKey: request.Header.Get(iamrequest.IdempotencyKeyHeader),
+			})
+			deadline, _ := request.Context().Deadline()
+			*deadlines = append(*deadlines, deadline)
+
+			body, ok := bodies[operation.String()]
+			if !ok {
+				body = "{}"
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Request:    request,
+			}, nil
+		}
+	}
+}
+
+func TestCompositeRequestOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		bodies   map[string]string
+		call     func(ctx context.Context, c *Client, opts ...iamrequest.Option) error
+		expected []subRequest
+	}{
+		{
+			name: "Test groups.Modify",
+			call: func(ctx context.Context, c *Client, opts ...iamrequest.Option) error {
+				_, err := c.Groups.Modify(ctx, testGroupID, func(group *groups.Group) error {
+					group.Name = "renamed"
+					return nil
+				}, opts...)
+				return err
+			},
+			expected: []subRequest{
+				{operation: "groups.Get"},
+				{operation: "groups.Patch", idempotencyKey: "key/patch"},
+			},
+		},
+		{
+			name:   "Test groups.Ensure",
+			bodies: map[string]string{"groups.List": `{"groups":[{"id":"456","name":"developers"}]}`},
+			call: func(ctx context.Context, c *Client, opts ...iamrequest.Option) error {
+				input := groups.CreateRequest{Name: "developers", Description: "Developers"}
+				_, err := c.Groups.Ensure(ctx, input, opts...)
+				return err
+			},
+			expected: []subRequest{
+				{operation: "groups.List"},
+				{operation: "groups.Patch", idempotencyKey: "key/patch"},
+			},
+		},
+		{
+			name:   "Test serviceusers.Ensure",
+			bodies: map[string]string{"serviceusers.List": `{"users":[{"id":"123","name":"robot"}]}`},
+			call: func(ctx context.Context, c *Client, opts ...iamrequest.Option) error {
+				enabled := true
+				_, err := c.ServiceUsers.Ensure(ctx, serviceusers.EnsureRequest{
+					Name: "robot", Enabled: &enabled, Roles: []roles.Role{{RoleName: "member", Scope: "account"}},
+				}, opts...)
+				return err
+			},
+			expected: []subRequest{
+				{operation: "serviceusers.List"},
+				{operation: "serviceusers.Patch", idempotencyKey: "key/patch"},
+				{operation: "serviceusers.AssignRoles", idempotencyKey: "key/assign"},
+			},
+		},
+		{
+			name: "Test users.SetRoles",
+			bodies: map[string]string{
+				"users.Get": `{"id":"123","roles":[{"role_name":"reader","scope":"account"}]}`,
+			},
+			call: func(ctx context.Context, c *Client, opts ...iamrequest.Option) error {
+				member := roles.Role{RoleName: "member", Scope: "account"}
+				_, err := c.Users.SetRoles(ctx, testUserID, []roles.Role{member}, opts...)
+				return err
+			},
+			expected: []subRequest{
+				{operation: "users.Get"},
+				{operation: "users.AssignRoles", idempotencyKey: "key/assign"},
+				{operation: "users.UnassignRoles", idempotencyKey: "key/unassign"},
+			},
+		},
+		{
+			name:   "Test groups.SetMembers",
+			bodies: map[string]string{"groups.Get": `{"id":"456","users":[{"id":"123","keystone_id":"123"}]}`},
+			call: func(ctx context.Context, c *Client, opts ...iamrequest.Option) error {
+				_, err := c.Groups.SetMembers(ctx, testGroupID, []iamid.KeystoneID{"321"}, opts...)
+				return err
+			},
+			expected: []subRequest{
+				{operation: "groups.Get"},
+				{operation: "groups.AddUsers", idempotencyKey: "key/add"},
+				{operation: "groups.DeleteUsers", idempotencyKey: "key/delete"},
+			},
+		},
+		{
+			name:   "Test groups.AddMembers",
+			bodies: map[string]string{"serviceusers.List": `{"users":[{"id":"321","name":"robot"}]}`},
+			call: func(ctx context.Context, c *Client, opts ...iamrequest.Option) error {
+				members := []principals.Ref{principals.ServiceUserName("robot")}
+				return c.Groups.AddMembers(ctx, testGroupID, members, opts...)
+			},
+			expected: []subRequest{
+				{operation: "users.List"},
+				{operation: "serviceusers.List"},
+				{operation: "groups.AddUsers", idempotencyKey: "key/add"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var (
+				requests  []subRequest
+				deadlines []time.Time
+			)
+			client := newMiddlewareTestClient(t, scriptedMiddleware(&requests, &deadlines, tt.bodies))
+
+			err := tt.call(context.Background(), client,
+				iamrequest.WithIdempotencyKey("key"),
+				iamrequest.WithTimeout(time.Minute),
+			)
+
+			require.NoError(err)
+			assert.Equal(tt.expected, requests)
+			// The timeout limits the whole call, so all requests share the same deadline.
+			for _, deadline := range deadlines {
+				assert.False(deadline.IsZero())
+				assert.Equal(deadlines[0], deadline)
+			}
+		})
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	plan := &iamrequest.Plan{}
+	var operations []iammiddleware.Operation
+	client, err := New(
+		WithAPIUrl(testURL),
+		WithAuthOpts(&AuthOpts{KeystoneToken: testToken}),
+		WithMiddleware(recordingMiddleware(&operations)),
+		WithDryRun(plan),
+	)
+	require.NoError(err)
+	ctx := context.Background()
+
+	_, err = client.Groups.Get(ctx, testGroupID)
+	require.NoError(err)
+	require.NoError(client.Groups.AddUsers(ctx, testGroupID, []iamid.KeystoneID{testUserID}))
+	err = client.SAMLFederations.GroupMappings.Delete(ctx, testFederationID, testGroupID, testExternalGroupID)
+	require.NoError(err)
+	created, err := client.Groups.Create(ctx, groups.CreateRequest{Name: "developers"})
+	require.NoError(err)
+	assert.Empty(created.ID)
+
+	err = client.Users.AssignRoles(ctx, "", []roles.Role{{RoleName: "member", Scope: "account"}})
+	require.ErrorIs(err, iamerrors.ErrUserIDRequired)
+
+	require.Len(operations, 1)
+	assert.Equal("groups.Get", operations[0].String())
+
+	calls := plan.Calls()
+	require.Len(calls, 3)
+	assert.Equal("groups.AddUsers", calls[0].Operation.String())
+	assert.Equal(map[string]interface{}{"keystone_ids": []interface{}{testUserID}}, calls[0].Payload)
+	assert.Equal("groupmappings.Delete", calls[1].Operation.String())
+	assert.Nil(calls[1].Payload)
+	assert.Equal("groups.Create", calls[2].Operation.String())
+}
+
+func TestDryRunRedactsPassword(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	plan := &iamrequest.Plan{}
+	client, err := New(
+		WithAPIUrl(testURL),
+		WithAuthOpts(&AuthOpts{KeystoneToken: testToken}),
+		WithDryRun(plan),
+	)
+	require.NoError(err)
+	ctx := context.Background()
+
+	password := testPassword
+	_, err = client.ServiceUsers.Create(ctx, serviceusers.CreateRequest{Name: "robot", Password: testPassword})
+	require.NoError(err)
+	_, err = client.ServiceUsers.Update(ctx, testUserID, serviceusers.UpdateRequest{Password: testPassword})
+	require.NoError(err)
+	_, err = client.ServiceUsers.Patch(ctx, testUserID, serviceusers.PatchRequest{Password: &password})
+	require.NoError(err)
+
+	calls := plan.Calls()
+	require.Len(calls, 3)
+	for _, call := range calls {
+		assert.NotContains(call.String(), testPassword)
+		assert.Contains(call.String(), `"password":"[REDACTED]"`)
+	}
+}
@@ -0,0 +1,67 @@
+package certificates
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+)
+
+// Fingerprint returns the hex encoded SHA-256 fingerprint of a PEM encoded X.509 certificate.
+// It returns an empty string, if data isn't a PEM encoded X.509 certificate.
+func Fingerprint(data string) string {
+	der := certificateDER(data)
+	if der == nil {
+		return ""
+	}
+
+	fingerprint := sha256.Sum256(der)
+	return hex.EncodeToString(fingerprint[:])
+}
+
+// matches reports whether the Certificate has the given data.
+//
+// The format of fingerprints returned by the IAM API isn't documented, so a fingerprint is compared
+// only if its length is the one of a hex encoded SHA-256 or SHA-1 digest. Otherwise, or if fingerprints differ,
+// the DER contents of PEM blocks are compared, so the line wrapping of data doesn't matter.
+func (c Certificate) matches(data string) bool {
+	der := certificateDER(data)
+	if der == nil {
+		return strings.TrimSpace(c.Data) == strings.TrimSpace(data)
+	}
+
+	switch fingerprint := normalizeFingerprint(c.Fingerprint); len(fingerprint) {
+	case hex.EncodedLen(sha256.Size):
+		if digest := sha256.Sum256(der); fingerprint == hex.EncodeToString(digest[:]) {
+			return true
+		}
+	case hex.EncodedLen(sha1.Size):
+		//nolint:gosec // SHA-1 is used only to compare a fingerprint returned by the IAM API.
+		if digest := sha1.Sum(der); fingerprint == hex.EncodeToString(digest[:]) {
+			return true
+		}
+	}
+	return bytes.Equal(certificateDER(c.Data), der)
+}
+
+// certificateDER returns the DER contents of a PEM encoded X.509 certificate,
+// or nil, if data isn't a PEM encoded X.509 certificate.
+func certificateDER(data string) []byte {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil
+	}
+	return certificate.Raw
+}
+
+// normalizeFingerprint converts the fingerprint to the format returned by Fingerprint, e.g. "AB:CD" to "abcd".
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+}
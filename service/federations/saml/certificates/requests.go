@@ -4,14 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/url"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/internal/client"
 )
 
-const apiVersion = "v1"
+const (
+	apiVersion  = "v1"
+	serviceName = "certificates"
+)
 
 // Service is used to communicate with the Federations Certificates API.
 type Service struct {
@@ -26,37 +33,61 @@ func New(baseClient *client.BaseClient) *Service {
 }
 
 // List returns a list of Certificates for the Federation.
-func (s *Service) List(ctx context.Context, federationID string) (*ListResponse, error) {
-	if federationID == "" {
-		return nil, iamerrors.Error{Err: iamerrors.ErrFederationIDRequired, Desc: "No federationID was provided."}
+func (s *Service) List(
+	ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option,
+) (*ListResponse, error) {
+	var certificates ListResponse
+	err := s.list(ctx, federationID, client.DecodeJSON(&certificates), opts)
+	if err != nil {
+		return nil, err
 	}
+	return &certificates, nil
+}
 
-	path, err := url.JoinPath(apiVersion, "federations", "saml", federationID, "certificates")
-	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+// ForEach calls fn for every Certificate of the Federation without loading the whole list into memory.
+// If fn returns an error, ForEach stops and returns it.
+func (s *Service) ForEach(
+	ctx context.Context, federationID iamid.FederationID, fn func(Certificate) error, opts ...iamrequest.Option,
+) error {
+	return s.list(ctx, federationID, client.ForEachJSON("certificates", fn), opts)
+}
+
+func (s *Service) list(
+	ctx context.Context, federationID iamid.FederationID, decode func(io.Reader) error, opts []iamrequest.Option,
+) error {
+	if federationID == "" {
+		return iamerrors.Error{Err: iamerrors.ErrFederationIDRequired, Desc: "No federationID was provided."}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodGet,
-		Path:   path,
-	})
+	path, err := url.JoinPath(apiVersion, "federations", "saml", string(federationID), "certificates")
 	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
+		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	var certificates ListResponse
-	err = client.UnmarshalJSON(response, &certificates)
+	err = s.baseClient.DoRequestDecode(ctx, client.DoRequestInput{
+		Body:    nil,
+		Method:  http.MethodGet,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "List",
+			ResourceIDs:  map[string]string{"federation_id": string(federationID)},
+			PathTemplate: "v1/federations/saml/{federation_id}/certificates",
+		},
+	}, decode)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestDecode already wraps the error.
+		return err
 	}
 
-	return &certificates, nil
+	return nil
 }
 
 // Get returns an info of Certificate with certificateID.
-func (s *Service) Get(ctx context.Context, federationID, certificateID string) (*GetResponse, error) {
+func (s *Service) Get(
+	ctx context.Context, federationID iamid.FederationID, certificateID iamid.CertificateID, opts ...iamrequest.Option,
+) (*GetResponse, error) {
 	if federationID == "" {
 		return nil, iamerrors.Error{Err: iamerrors.ErrFederationIDRequired, Desc: "No federationID was provided."}
 	}
@@ -67,37 +98,51 @@ func (s *Service) Get(ctx context.Context, federationID, certificateID string) (
 		}
 	}
 
-	path, err := url.JoinPath(apiVersion, "federations", "saml", federationID, "certificates", certificateID)
+	path, err := url.JoinPath(
+		apiVersion,
+		"federations",
+		"saml",
+		string(federationID),
+		"certificates",
+		string(certificateID),
+	)
 	if err != nil {
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodGet,
-		Path:   path,
-	})
-	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
-	}
-
 	var certificate GetResponse
-	err = client.UnmarshalJSON(response, &certificate)
+	err = s.baseClient.DoRequestJSON(ctx, client.DoRequestInput{
+		Body:    nil,
+		Method:  http.MethodGet,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service: serviceName,
+			Name:    "Get",
+			ResourceIDs: map[string]string{
+				"federation_id":  string(federationID),
+				"certificate_id": string(certificateID),
+			},
+			PathTemplate: "v1/federations/saml/{federation_id}/certificates/{certificate_id}",
+		},
+	}, &certificate)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestJSON already wraps the error.
+		return nil, err
 	}
 
 	return &certificate, nil
 }
 
 // Create creates a new Certificate for the Federation.
-func (s *Service) Create(ctx context.Context, federationID string, input CreateRequest) (*CreateResponse, error) {
+func (s *Service) Create(
+	ctx context.Context, federationID iamid.FederationID, input CreateRequest, opts ...iamrequest.Option,
+) (*CreateResponse, error) {
 	if federationID == "" {
 		return nil, iamerrors.Error{Err: iamerrors.ErrFederationIDRequired, Desc: "No federationID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "federations", "saml", federationID, "certificates")
+	path, err := url.JoinPath(apiVersion, "federations", "saml", string(federationID), "certificates")
 	if err != nil {
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
@@ -107,20 +152,22 @@ func (s *Service) Create(ctx context.Context, federationID string, input CreateR
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: http.MethodPost,
-		Path:   path,
-	})
-	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
-	}
-
 	var createdCertificate CreateResponse
-	err = client.UnmarshalJSON(response, &createdCertificate)
+	err = s.baseClient.DoRequestJSON(ctx, client.DoRequestInput{
+		Body:    bytes.NewReader(body),
+		Method:  http.MethodPost,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Create",
+			ResourceIDs:  map[string]string{"federation_id": string(federationID)},
+			PathTemplate: "v1/federations/saml/{federation_id}/certificates",
+		},
+	}, &createdCertificate)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestJSON already wraps the error.
+		return nil, err
 	}
 
 	return &createdCertificate, nil
@@ -128,7 +175,71 @@ func (s *Service) Create(ctx context.Context, federationID string, input CreateR
 
 // Update updates the Certificate with certificateID.
 func (s *Service) Update(
-	ctx context.Context, federationID, certificateID string, input UpdateRequest,
+	ctx context.Context,
+	federationID iamid.FederationID,
+	certificateID iamid.CertificateID,
+	input UpdateRequest,
+	opts ...iamrequest.Option,
+) (*UpdateResponse, error) {
+	return s.update(ctx, "Update", federationID, certificateID, input, opts)
+}
+
+// Patch updates only the fields of the Certificate with certificateID which are set in the input.
+func (s *Service) Patch(
+	ctx context.Context,
+	federationID iamid.FederationID,
+	certificateID iamid.CertificateID,
+	input PatchRequest,
+	opts ...iamrequest.Option,
+) (*UpdateResponse, error) {
+	return s.update(ctx, "Patch", federationID, certificateID, input, opts)
+}
+
+// Modify fetches the Certificate with certificateID, applies mutate to it and sends the changed fields with Patch.
+// Only Name and Description are compared, so changes of other fields are ignored.
+// If mutate changes nothing, no update request is sent and the current Certificate is returned.
+// An error returned by mutate is returned as is.
+func (s *Service) Modify(
+	ctx context.Context,
+	federationID iamid.FederationID,
+	certificateID iamid.CertificateID,
+	mutate func(*Certificate) error,
+	opts ...iamrequest.Option,
+) (*UpdateResponse, error) {
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	current, err := s.Get(ctx, federationID, certificateID, calls.Read()...)
+	if err != nil {
+		return nil, err
+	}
+
+	modified := current.Certificate
+	if err := mutate(&modified); err != nil {
+		return nil, err
+	}
+
+	var patch PatchRequest
+	if modified.Name != current.Name {
+		patch.Name = &modified.Name
+	}
+	if modified.Description != current.Description {
+		patch.Description = &modified.Description
+	}
+	if patch == (PatchRequest{}) {
+		return &UpdateResponse{Certificate: current.Certificate}, nil
+	}
+
+	return s.Patch(ctx, federationID, certificateID, patch, calls.Write("patch")...)
+}
+
+func (s *Service) update(
+	ctx context.Context,
+	operation string,
+	federationID iamid.FederationID,
+	certificateID iamid.CertificateID,
+	input interface{},
+	opts []iamrequest.Option,
 ) (*UpdateResponse, error) {
 	if federationID == "" {
 		return nil, iamerrors.Error{Err: iamerrors.ErrFederationIDRequired, Desc: "No federationID was provided."}
@@ -140,7 +251,14 @@ func (s *Service) Update(
 		}
 	}
 
-	path, err := url.JoinPath(apiVersion, "federations", "saml", federationID, "certificates", certificateID)
+	path, err := url.JoinPath(
+		apiVersion,
+		"federations",
+		"saml",
+		string(federationID),
+		"certificates",
+		string(certificateID),
+	)
 	if err != nil {
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
@@ -150,27 +268,34 @@ func (s *Service) Update(
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: http.MethodPatch,
-		Path:   path,
-	})
-	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
-	}
-
 	var updatedCertificate UpdateResponse
-	err = client.UnmarshalJSON(response, &updatedCertificate)
+	err = s.baseClient.DoRequestJSON(ctx, client.DoRequestInput{
+		Body:    bytes.NewReader(body),
+		Method:  http.MethodPatch,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service: serviceName,
+			Name:    operation,
+			ResourceIDs: map[string]string{
+				"federation_id":  string(federationID),
+				"certificate_id": string(certificateID),
+			},
+			PathTemplate: "v1/federations/saml/{federation_id}/certificates/{certificate_id}",
+		},
+	}, &updatedCertificate)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestJSON already wraps the error.
+		return nil, err
 	}
 
 	return &updatedCertificate, nil
 }
 
 // Delete deletes the Certificate with certificateID.
-func (s *Service) Delete(ctx context.Context, federationID, certificateID string) error {
+func (s *Service) Delete(
+	ctx context.Context, federationID iamid.FederationID, certificateID iamid.CertificateID, opts ...iamrequest.Option,
+) error {
 	if federationID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrFederationIDRequired, Desc: "No federationID was provided."}
 	}
@@ -181,15 +306,32 @@ func (s *Service) Delete(ctx context.Context, federationID, certificateID string
 		}
 	}
 
-	path, err := url.JoinPath(apiVersion, "federations", "saml", federationID, "certificates", certificateID)
+	path, err := url.JoinPath(
+		apiVersion,
+		"federations",
+		"saml",
+		string(federationID),
+		"certificates",
+		string(certificateID),
+	)
 	if err != nil {
 		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodDelete,
-		Path:   path,
+		Body:    nil,
+		Method:  http.MethodDelete,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service: serviceName,
+			Name:    "Delete",
+			ResourceIDs: map[string]string{
+				"federation_id":  string(federationID),
+				"certificate_id": string(certificateID),
+			},
+			PathTemplate: "v1/federations/saml/{federation_id}/certificates/{certificate_id}",
+		},
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -198,3 +340,46 @@ func (s *Service) Delete(ctx context.Context, federationID, certificateID string
 
 	return nil
 }
+
+// Ensure makes sure that the Federation has a Certificate with the data from the input.
+// An existing Certificate is looked up by the fingerprint or the contents of the data and its name and description
+// are updated, if they differ. A missing Certificate is created.
+func (s *Service) Ensure(
+	ctx context.Context, federationID iamid.FederationID, input CreateRequest, opts ...iamrequest.Option,
+) (*EnsureResponse, error) {
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	current, err := s.List(ctx, federationID, calls.Read()...)
+	if err != nil {
+		return nil, err
+	}
+	for _, certificate := range current.Certificates {
+		if !certificate.matches(input.Data) {
+			continue
+		}
+
+		var patch PatchRequest
+		if certificate.Name != input.Name {
+			patch.Name = &input.Name
+		}
+		if certificate.Description != input.Description {
+			patch.Description = &input.Description
+		}
+		if patch == (PatchRequest{}) {
+			return &EnsureResponse{Certificate: certificate}, nil
+		}
+
+		if _, err := s.Patch(ctx, federationID, certificate.ID, patch, calls.Write("patch")...); err != nil {
+			return nil, err
+		}
+		certificate.Name, certificate.Description = input.Name, input.Description
+		return &EnsureResponse{Certificate: certificate, Updated: true}, nil
+	}
+
+	created, err := s.Create(ctx, federationID, input, calls.Write("create")...)
+	if err != nil {
+		return nil, err
+	}
+	return &EnsureResponse{Certificate: created.Certificate, Created: true}, nil
+}
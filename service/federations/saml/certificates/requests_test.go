@@ -2,16 +2,19 @@ package certificates
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
-	"github.com/selectel/iam-go/service/federations/saml/certificates/testdata"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/federations/saml/certificates/testdata"
 )
 
 const (
@@ -90,6 +93,80 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestForEach(t *testing.T) {
+	tests := []struct {
+		name          string
+		prepare       func()
+		expectedItems []Certificate
+		expectedError error
+	}{
+		{
+			name: "ok",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+certificatesURL, func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusOK, testdata.TestListCertificatesResponse)
+						return resp, nil
+					})
+			},
+			expectedItems: []Certificate{
+				{
+					ID:           "123",
+					AccountID:    "123",
+					FederationID: "123",
+					Name:         "test_name",
+					Description:  "test_description",
+					NotBefore:    "2021-01-01T00:00:00Z",
+					NotAfter:     "2022-01-01T00:00:00Z",
+					Fingerprint:  "test_fingerprint",
+					Data:         "test_data",
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "error",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+certificatesURL, func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusForbidden, testdata.TestDoRequestErr)
+						return resp, nil
+					})
+			},
+			expectedItems: nil,
+			expectedError: iamerrors.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			certificatesAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+			httpmock.ActivateNonDefault(certificatesAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			tt.prepare()
+
+			ctx := context.Background()
+			var actual []Certificate
+			err := certificatesAPI.ForEach(ctx, "123", func(item Certificate) error {
+				actual = append(actual, item)
+				return nil
+			})
+
+			require.ErrorIs(err, tt.expectedError)
+
+			assert.Equal(tt.expectedItems, actual)
+		})
+	}
+}
+
 func TestGet(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -365,3 +442,168 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestModify(t *testing.T) {
+	errMutate := errors.New("mutate failed")
+
+	tests := []struct {
+		name          string
+		mutate        func(*Certificate) error
+		expectedBody  string
+		expectedError error
+	}{
+		{
+			name: "Test Modify sends changed fields",
+			mutate: func(certificate *Certificate) error {
+				certificate.Name = "new_name"
+				certificate.Data = "ignored"
+				return nil
+			},
+			expectedBody: `{"name":"new_name"}`,
+		},
+		{
+			name: "Test Modify without changes",
+			mutate: func(certificate *Certificate) error {
+				certificate.Description = "test_description"
+				return nil
+			},
+		},
+		{
+			name: "Test Modify returns mutate error",
+			mutate: func(*Certificate) error {
+				return errMutate
+			},
+			expectedError: errMutate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			certificatesAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(certificatesAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+certificatesURL+"/123",
+				httpmock.NewStringResponder(http.StatusOK, testdata.TestGetCertificateResponse))
+
+			var body []byte
+			httpmock.RegisterResponder(
+				http.MethodPatch, testdata.TestURL+certificatesURL+"/123",
+				func(r *http.Request) (*http.Response, error) {
+					body, _ = io.ReadAll(r.Body)
+					return httpmock.NewStringResponse(http.StatusOK, testdata.TestGetCertificateResponse), nil
+				})
+
+			_, err := certificatesAPI.Modify(context.Background(), "123", "123", tt.mutate)
+
+			require.ErrorIs(err, tt.expectedError)
+			if tt.expectedBody == "" {
+				assert.Nil(body)
+			} else {
+				assert.JSONEq(tt.expectedBody, string(body))
+			}
+		})
+	}
+}
+
+//nolint:funlen // This is a test function.
+func TestEnsure(t *testing.T) {
+	tests := []struct {
+		name            string
+		listed          []Certificate
+		input           CreateRequest
+		expectedMethod  string
+		expectedBody    string
+		expectedCreated bool
+		expectedUpdated bool
+	}{
+		{
+			name: "Test Ensure finds Certificate by fingerprint",
+			listed: []Certificate{
+				{ID: "123", Name: "test_name", Fingerprint: testdata.TestCertificateFingerprint},
+			},
+			input: CreateRequest{Name: "test_name", Data: testdata.TestCertificateData},
+		},
+		{
+			name: "Test Ensure finds Certificate by SHA-1 fingerprint",
+			listed: []Certificate{
+				{ID: "123", Name: "test_name", Fingerprint: testdata.TestCertificateSHA1Fingerprint},
+			},
+			input: CreateRequest{Name: "test_name", Data: testdata.TestCertificateData},
+		},
+		{
+			name: "Test Ensure finds Certificate by data, if fingerprint has unknown format",
+			listed: []Certificate{{
+				ID:          "123",
+				Name:        "test_name",
+				Fingerprint: "test_fingerprint",
+				Data:        strings.ReplaceAll(testdata.TestCertificateData, "\n", "\r\n"),
+			}},
+			input: CreateRequest{Name: "test_name", Data: testdata.TestCertificateData},
+		},
+		{
+			name:            "Test Ensure updates drifted name",
+			listed:          []Certificate{{ID: "123", Name: "old_name", Data: "test_data"}},
+			input:           CreateRequest{Name: "test_name", Data: "test_data\n"},
+			expectedMethod:  http.MethodPatch,
+			expectedBody:    `{"name":"test_name"}`,
+			expectedUpdated: true,
+		},
+		{
+			name:            "Test Ensure creates missing Certificate",
+			listed:          []Certificate{{ID: "123", Name: "test_name", Fingerprint: "other"}},
+			input:           CreateRequest{Name: "test_name", Data: testdata.TestCertificateData},
+			expectedMethod:  http.MethodPost,
+			expectedCreated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			certificatesAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(certificatesAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+certificatesURL,
+				httpmock.NewJsonResponderOrPanic(http.StatusOK, ListResponse{Certificates: tt.listed}))
+
+			var method string
+			var body []byte
+			responder := func(r *http.Request) (*http.Response, error) {
+				method = r.Method
+				body, _ = io.ReadAll(r.Body)
+				return httpmock.NewStringResponse(http.StatusOK, testdata.TestGetCertificateResponse), nil
+			}
+			httpmock.RegisterResponder(http.MethodPatch, testdata.TestURL+certificatesURL+"/123", responder)
+			httpmock.RegisterResponder(http.MethodPost, testdata.TestURL+certificatesURL, responder)
+
+			actual, err := certificatesAPI.Ensure(context.Background(), "123", tt.input)
+
+			require.NoError(err)
+			assert.Equal(tt.expectedMethod, method)
+			if tt.expectedBody != "" {
+				assert.JSONEq(tt.expectedBody, string(body))
+			}
+			assert.Equal(tt.expectedCreated, actual.Created)
+			assert.Equal(tt.expectedUpdated, actual.Updated)
+			assert.Equal(tt.expectedCreated || tt.expectedUpdated, actual.Changed())
+			assert.Equal(tt.input.Name, actual.Name)
+		})
+	}
+}
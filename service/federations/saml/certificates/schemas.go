@@ -1,16 +1,18 @@
 package certificates
 
+import "github.com/selectel/iam-go/v2/iamid"
+
 // Certificate represents a Federation Certificate.
 type Certificate struct {
-	ID           string `json:"id"`
-	AccountID    string `json:"account_id"`
-	FederationID string `json:"federation_id"`
-	Name         string `json:"name"`
-	Description  string `json:"description"`
-	NotBefore    string `json:"not_before"`
-	NotAfter     string `json:"not_after"`
-	Fingerprint  string `json:"fingerprint"`
-	Data         string `json:"data"`
+	ID           iamid.CertificateID `json:"id"`
+	AccountID    string              `json:"account_id"`
+	FederationID iamid.FederationID  `json:"federation_id"`
+	Name         string              `json:"name"`
+	Description  string              `json:"description"`
+	NotBefore    string              `json:"not_before"`
+	NotAfter     string              `json:"not_after"`
+	Fingerprint  string              `json:"fingerprint"`
+	Data         string              `json:"data"`
 }
 
 // ListResponse represents all certificates for the specified Federation.
@@ -33,6 +35,22 @@ type UpdateResponse struct {
 	Certificate
 }
 
+// EnsureResponse represents a Federation Certificate after Ensure method.
+type EnsureResponse struct {
+	Certificate
+
+	// Created is true, if the Certificate didn't exist and was created.
+	Created bool
+
+	// Updated is true, if the Certificate existed and its name or description were updated.
+	Updated bool
+}
+
+// Changed reports whether Ensure has created or updated the Certificate.
+func (r EnsureResponse) Changed() bool {
+	return r.Created || r.Updated
+}
+
 // CreateRequest is used to set options for Create method.
 type CreateRequest struct {
 	Name        string `json:"name"`
@@ -45,3 +63,10 @@ type UpdateRequest struct {
 	Name        string  `json:"name,omitempty"`
 	Description *string `json:"description,omitempty"`
 }
+
+// PatchRequest is used to set options for Patch method.
+// Only the fields which are not nil are sent, the others keep their current values.
+type PatchRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
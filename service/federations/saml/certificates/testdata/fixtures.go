@@ -49,3 +49,21 @@ const TestDoRequestErr = `{
 	"code": "REQUEST_FORBIDDEN",
 	"message": "You don't have permission to do this"
 }`
+
+const TestCertificateData = `-----BEGIN CERTIFICATE-----
+MIIBgTCCASegAwIBAgIUUvQ8R2CT3YJKn4CHySLOVBMpqIkwCgYIKoZIzj0EAwIw
+FjEUMBIGA1UEAwwLaWFtLWdvIHRlc3QwHhcNMjYxMDE3MjMzNjE5WhcNMzYxMDE0
+MjMzNjE5WjAWMRQwEgYDVQQDDAtpYW0tZ28gdGVzdDBZMBMGByqGSM49AgEGCCqG
+SM49AwEHA0IABFXszduc39+e3hvXr/EZTY69DVuSeBBOVUKGwNaLRJOJWAukxser
+mLIbkhCLxctqEZOjxFH25qdui0QnfkFe9VmjUzBRMB0GA1UdDgQWBBSUEKOmW+bh
+2/aqqLw2cPFaD/4gbDAfBgNVHSMEGDAWgBSUEKOmW+bh2/aqqLw2cPFaD/4gbDAP
+BgNVHRMBAf8EBTADAQH/MAoGCCqGSM49BAMCA0gAMEUCIQCmPGy5BoqAE/cMRrWI
+V6QPGIfKl3fa4HCnb5qQ2EcbTwIgDWrGLTJ/4INqaJ16gDMImx7bTyTiQiFRQnoU
+P+TQ/Mg=
+-----END CERTIFICATE-----
+`
+
+const TestCertificateFingerprint = "6D:09:93:E8:69:9E:41:A4:34:EF:1B:95:B3:A6:F6:DD:" +
+	"D6:92:FB:AC:8D:0A:F9:BC:06:CF:6E:D4:BF:46:A5:9D"
+
+const TestCertificateSHA1Fingerprint = "6B:E0:13:6E:F4:E8:85:60:FB:13:28:1C:59:08:81:22:5A:1C:93:6C"
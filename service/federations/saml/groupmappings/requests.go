@@ -5,14 +5,21 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/url"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/internal/client"
 )
 
-const apiVersion = "v1"
+const (
+	apiVersion  = "v1"
+	serviceName = "groupmappings"
+)
 
 // Service is used to communicate with the Federations Group Mappings API.
 type Service struct {
@@ -27,44 +34,66 @@ func New(baseClient *client.BaseClient) *Service {
 }
 
 // List returns a list of mappings for the Federation.
-func (s *Service) List(ctx context.Context, federationID string) (*GroupMappingsResponse, error) {
-	if federationID == "" {
-		return nil, iamerrors.Error{Err: iamerrors.ErrFederationIDRequired, Desc: "No federationID was provided."}
+func (s *Service) List(
+	ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option,
+) (*GroupMappingsResponse, error) {
+	var mappings GroupMappingsResponse
+	err := s.list(ctx, federationID, client.DecodeJSON(&mappings), opts)
+	if err != nil {
+		return nil, err
 	}
+	return &mappings, nil
+}
 
-	path, err := url.JoinPath(apiVersion, "federations", "saml", federationID, "group-mappings")
-	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+// ForEach calls fn for every mapping of the Federation without loading the whole list into memory.
+// If fn returns an error, ForEach stops and returns it.
+func (s *Service) ForEach(
+	ctx context.Context, federationID iamid.FederationID, fn func(GroupMapping) error, opts ...iamrequest.Option,
+) error {
+	return s.list(ctx, federationID, client.ForEachJSON("group_mappings", fn), opts)
+}
+
+func (s *Service) list(
+	ctx context.Context, federationID iamid.FederationID, decode func(io.Reader) error, opts []iamrequest.Option,
+) error {
+	if federationID == "" {
+		return iamerrors.Error{Err: iamerrors.ErrFederationIDRequired, Desc: "No federationID was provided."}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodGet,
-		Path:   path,
-	})
+	path, err := url.JoinPath(apiVersion, "federations", "saml", string(federationID), "group-mappings")
 	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
+		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	var mappings GroupMappingsResponse
-	err = client.UnmarshalJSON(response, &mappings)
+	err = s.baseClient.DoRequestDecode(ctx, client.DoRequestInput{
+		Body:    nil,
+		Method:  http.MethodGet,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "List",
+			ResourceIDs:  map[string]string{"federation_id": string(federationID)},
+			PathTemplate: "v1/federations/saml/{federation_id}/group-mappings",
+		},
+	}, decode)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestDecode already wraps the error.
+		return err
 	}
 
-	return &mappings, nil
+	return nil
 }
 
 // Update updates mappings for the Federation.
 func (s *Service) Update(
-	ctx context.Context, federationID string, input GroupMappingsRequest,
+	ctx context.Context, federationID iamid.FederationID, input GroupMappingsRequest, opts ...iamrequest.Option,
 ) error {
 	if federationID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrFederationIDRequired, Desc: "No federationID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "federations", "saml", federationID, "group-mappings")
+	path, err := url.JoinPath(apiVersion, "federations", "saml", string(federationID), "group-mappings")
 	if err != nil {
 		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
@@ -75,9 +104,16 @@ func (s *Service) Update(
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: http.MethodPut,
-		Path:   path,
+		Body:    bytes.NewReader(body),
+		Method:  http.MethodPut,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Update",
+			ResourceIDs:  map[string]string{"federation_id": string(federationID)},
+			PathTemplate: "v1/federations/saml/{federation_id}/group-mappings",
+		},
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -89,7 +125,11 @@ func (s *Service) Update(
 
 // Add creates mapping between internal and external group.
 func (s *Service) Add(
-	ctx context.Context, federationID, groupID, externalGroupID string,
+	ctx context.Context,
+	federationID iamid.FederationID,
+	groupID iamid.GroupID,
+	externalGroupID string,
+	opts ...iamrequest.Option,
 ) error {
 	path, err := buildExternalGroupMappingPath(federationID, groupID, externalGroupID)
 	if err != nil {
@@ -97,9 +137,11 @@ func (s *Service) Add(
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodPut,
-		Path:   path,
+		Body:      nil,
+		Method:    http.MethodPut,
+		Path:      path,
+		Options:   opts,
+		Operation: externalGroupMappingOperation("Add", federationID, groupID, externalGroupID),
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -111,7 +153,11 @@ func (s *Service) Add(
 
 // Delete deletes mapping between internal and external group.
 func (s *Service) Delete(
-	ctx context.Context, federationID, groupID, externalGroupID string,
+	ctx context.Context,
+	federationID iamid.FederationID,
+	groupID iamid.GroupID,
+	externalGroupID string,
+	opts ...iamrequest.Option,
 ) error {
 	path, err := buildExternalGroupMappingPath(federationID, groupID, externalGroupID)
 	if err != nil {
@@ -119,9 +165,11 @@ func (s *Service) Delete(
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodDelete,
-		Path:   path,
+		Body:      nil,
+		Method:    http.MethodDelete,
+		Path:      path,
+		Options:   opts,
+		Operation: externalGroupMappingOperation("Delete", federationID, groupID, externalGroupID),
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -133,7 +181,11 @@ func (s *Service) Delete(
 
 // Exists checks that internal and external groups are mapped.
 func (s *Service) Exists(
-	ctx context.Context, federationID, groupID, externalGroupID string,
+	ctx context.Context,
+	federationID iamid.FederationID,
+	groupID iamid.GroupID,
+	externalGroupID string,
+	opts ...iamrequest.Option,
 ) (bool, error) {
 	path, err := buildExternalGroupMappingPath(federationID, groupID, externalGroupID)
 	if err != nil {
@@ -141,14 +193,18 @@ func (s *Service) Exists(
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodHead,
-		Path:   path,
+		Body:      nil,
+		Method:    http.MethodHead,
+		Path:      path,
+		Options:   opts,
+		Operation: externalGroupMappingOperation("Exists", federationID, groupID, externalGroupID),
 	})
 	if err != nil {
+		// Responses to HEAD requests may have no body, so the status code is checked as well.
 		if errors.Is(err, iamerrors.ErrFederationNotFound) ||
 			errors.Is(err, iamerrors.ErrGroupNotFound) ||
-			errors.Is(err, iamerrors.ErrUserOrGroupNotFound) {
+			errors.Is(err, iamerrors.ErrUserOrGroupNotFound) ||
+			iamerrors.IsNotFound(err) {
 			return false, nil
 		}
 
@@ -160,7 +216,7 @@ func (s *Service) Exists(
 }
 
 func buildExternalGroupMappingPath(
-	federationID, groupID, externalGroupID string,
+	federationID iamid.FederationID, groupID iamid.GroupID, externalGroupID string,
 ) (string, error) {
 	if federationID == "" {
 		return "", iamerrors.Error{Err: iamerrors.ErrFederationIDRequired, Desc: "No federationID was provided."}
@@ -176,9 +232,9 @@ func buildExternalGroupMappingPath(
 		apiVersion,
 		"federations",
 		"saml",
-		federationID,
+		string(federationID),
 		"group-mappings",
-		groupID,
+		string(groupID),
 		"external-groups",
 		externalGroupID,
 	)
@@ -188,3 +244,19 @@ func buildExternalGroupMappingPath(
 
 	return path, nil
 }
+
+func externalGroupMappingOperation(
+	name string, federationID iamid.FederationID, groupID iamid.GroupID, externalGroupID string,
+) iammiddleware.Operation {
+	return iammiddleware.Operation{
+		Service: serviceName,
+		Name:    name,
+		ResourceIDs: map[string]string{
+			"federation_id":     string(federationID),
+			"group_id":          string(groupID),
+			"external_group_id": externalGroupID,
+		},
+		PathTemplate: "v1/federations/saml/{federation_id}/group-mappings/{group_id}" +
+			"/external-groups/{external_group_id}",
+	}
+}
@@ -9,9 +9,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
-	"github.com/selectel/iam-go/service/federations/saml/testdata"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/federations/saml/testdata"
 )
 
 const (
@@ -86,6 +86,75 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestForEach(t *testing.T) {
+	tests := []struct {
+		name          string
+		prepare       func()
+		expectedItems []GroupMapping
+		expectedError error
+	}{
+		{
+			name: "ok",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+federationGroupMappingsURL,
+					func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusOK, testdata.TestGroupMappingsResponse)
+						return resp, nil
+					})
+			},
+			expectedItems: []GroupMapping{
+				{
+					InternalGroupID: "456",
+					ExternalGroupID: "external-group",
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "error",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+federationGroupMappingsURL,
+					func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusForbidden, testdata.TestDoRequestErr)
+						return resp, nil
+					})
+			},
+			expectedItems: nil,
+			expectedError: iamerrors.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			api := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(api.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			tt.prepare()
+
+			ctx := context.Background()
+			var actual []GroupMapping
+			err := api.ForEach(ctx, "123", func(item GroupMapping) error {
+				actual = append(actual, item)
+				return nil
+			})
+
+			require.ErrorIs(err, tt.expectedError)
+			assert.Equal(tt.expectedItems, actual)
+		})
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -301,6 +370,19 @@ func TestExists(t *testing.T) {
 			expectedExists: false,
 			expectedError:  nil,
 		},
+		{
+			name: "not found without body",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodHead, testdata.TestURL+federationExternalGroupMappingURL,
+					func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusNotFound, "")
+						return resp, nil
+					})
+			},
+			expectedExists: false,
+			expectedError:  nil,
+		},
 		{
 			name: "error",
 			prepare: func() {
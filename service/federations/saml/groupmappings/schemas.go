@@ -1,9 +1,11 @@
 package groupmappings
 
+import "github.com/selectel/iam-go/v2/iamid"
+
 // GroupMapping represents mapping between internal and external group.
 type GroupMapping struct {
-	InternalGroupID string `json:"internal_group_id"`
-	ExternalGroupID string `json:"external_group_id"`
+	InternalGroupID iamid.GroupID `json:"internal_group_id"`
+	ExternalGroupID string        `json:"external_group_id"`
 }
 
 // GroupMappingsRequest is used to set options for Update method.
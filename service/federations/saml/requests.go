@@ -5,16 +5,24 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/url"
-
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
-	"github.com/selectel/iam-go/service/federations/saml/certificates"
-	"github.com/selectel/iam-go/service/federations/saml/groupmappings"
+	"strings"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/federations/saml/certificates"
+	"github.com/selectel/iam-go/v2/service/federations/saml/groupmappings"
 )
 
-const apiVersion = "v1"
+const (
+	apiVersion  = "v1"
+	serviceName = "saml"
+)
 
 // Service is used to communicate with the Federations API.
 type Service struct {
@@ -33,34 +41,52 @@ func New(baseClient *client.BaseClient) *Service {
 }
 
 // List returns a list of Federations for the account.
-func (s *Service) List(ctx context.Context) (*ListResponse, error) {
-	path, err := url.JoinPath(apiVersion, "federations", "saml")
+func (s *Service) List(ctx context.Context, opts ...iamrequest.Option) (*ListResponse, error) {
+	var federations ListResponse
+	err := s.list(ctx, client.DecodeJSON(&federations), opts)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		return nil, err
 	}
+	return &federations, nil
+}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodGet,
-		Path:   path,
-	})
+// ForEach calls fn for every Federation of the account without loading the whole list into memory.
+// If fn returns an error, ForEach stops and returns it.
+func (s *Service) ForEach(ctx context.Context, fn func(Federation) error, opts ...iamrequest.Option) error {
+	return s.list(ctx, client.ForEachJSON("federations", fn), opts)
+}
+
+func (s *Service) list(ctx context.Context, decode func(io.Reader) error, opts []iamrequest.Option) error {
+	path, err := url.JoinPath(apiVersion, "federations", "saml")
 	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
+		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	var federations ListResponse
-	err = client.UnmarshalJSON(response, &federations)
+	err = s.baseClient.DoRequestDecode(ctx, client.DoRequestInput{
+		Body:    nil,
+		Method:  http.MethodGet,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "List",
+			PathTemplate: "v1/federations/saml",
+		},
+	}, decode)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestDecode already wraps the error.
+		return err
 	}
-	return &federations, nil
+
+	return nil
 }
 
 // Get returns an info of Federation with federationID.
-func (s *Service) Get(ctx context.Context, federationID string) (*GetResponse, error) {
+func (s *Service) Get(
+	ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option,
+) (*GetResponse, error) {
 	var federation GetResponse
-	err := s.getFederationResource(ctx, federationID, nil, &federation)
+	err := s.getFederationResource(ctx, "Get", federationID, nil, &federation, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -68,23 +94,33 @@ func (s *Service) Get(ctx context.Context, federationID string) (*GetResponse, e
 }
 
 // Exists checks that Federation with federationID exists.
-func (s *Service) Exists(ctx context.Context, federationID string) (bool, error) {
+func (s *Service) Exists(
+	ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option,
+) (bool, error) {
 	if federationID == "" {
 		return false, iamerrors.Error{Err: iamerrors.ErrFederationIDRequired, Desc: "No federationID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "federations", "saml", federationID)
+	path, err := url.JoinPath(apiVersion, "federations", "saml", string(federationID))
 	if err != nil {
 		return false, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodHead,
-		Path:   path,
+		Body:    nil,
+		Method:  http.MethodHead,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Exists",
+			ResourceIDs:  map[string]string{"federation_id": string(federationID)},
+			PathTemplate: "v1/federations/saml/{federation_id}",
+		},
 	})
 	if err != nil {
-		if errors.Is(err, iamerrors.ErrFederationNotFound) {
+		// Responses to HEAD requests may have no body, so the status code is checked as well.
+		if errors.Is(err, iamerrors.ErrFederationNotFound) || iamerrors.IsNotFound(err) {
 			return false, nil
 		}
 
@@ -96,9 +132,11 @@ func (s *Service) Exists(ctx context.Context, federationID string) (bool, error)
 }
 
 // Preview returns preview information of Federation using federationID or alias.
-func (s *Service) Preview(ctx context.Context, federationID string) (*FederationPreview, error) {
+func (s *Service) Preview(
+	ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option,
+) (*FederationPreview, error) {
 	var preview FederationPreview
-	err := s.getFederationResource(ctx, federationID, []string{"preview"}, &preview)
+	err := s.getFederationResource(ctx, "Preview", federationID, []string{"preview"}, &preview, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -107,67 +145,141 @@ func (s *Service) Preview(ctx context.Context, federationID string) (*Federation
 }
 
 // Create creates a new Federation.
-func (s *Service) Create(ctx context.Context, input CreateRequest) (*CreateResponse, error) {
+func (s *Service) Create(ctx context.Context, input CreateRequest, opts ...iamrequest.Option) (*CreateResponse, error) {
+	if err := validateCreateRequest(input); err != nil {
+		return nil, err
+	}
+
+	path, err := url.JoinPath(apiVersion, "federations", "saml")
+	if err != nil {
+		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+	}
+
+	body, err := json.Marshal(input)
+	if err != nil {
+		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+	}
+
+	var federation CreateResponse
+	err = s.baseClient.DoRequestJSON(ctx, client.DoRequestInput{
+		Body:    bytes.NewReader(body),
+		Method:  http.MethodPost,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Create",
+			PathTemplate: "v1/federations/saml",
+		},
+	}, &federation)
+	if err != nil {
+		//nolint:wrapcheck // DoRequestJSON already wraps the error.
+		return nil, err
+	}
+	return &federation, nil
+}
+
+// validateCreateRequest checks the attributes required to create a Federation.
+func validateCreateRequest(input CreateRequest) error {
 	if input.Name == "" {
-		return nil, iamerrors.Error{
+		return iamerrors.Error{
 			Err:  iamerrors.ErrFederationNameRequired,
 			Desc: "No Name for Federation was provided.",
 		}
 	}
 	if input.Issuer == "" {
-		return nil, iamerrors.Error{
+		return iamerrors.Error{
 			Err:  iamerrors.ErrFederationIssuerRequired,
 			Desc: "No Issuer for Federation was provided.",
 		}
 	}
 	if input.SSOUrl == "" {
-		return nil, iamerrors.Error{
+		return iamerrors.Error{
 			Err:  iamerrors.ErrFederationSSOURLRequired,
 			Desc: "No SSO URL for Federation was provided.",
 		}
 	}
 	if input.SessionMaxAgeHours == 0 {
-		return nil, iamerrors.Error{
+		return iamerrors.Error{
 			Err:  iamerrors.ErrFederationMaxAgeHoursRequired,
 			Desc: "No Max Age Hours for Federation was provided.",
 		}
 	}
+	return nil
+}
 
-	path, err := url.JoinPath(apiVersion, "federations", "saml")
+// Update updates existing Federation.
+func (s *Service) Update(
+	ctx context.Context, federationID iamid.FederationID, input UpdateRequest, opts ...iamrequest.Option,
+) error {
+	return s.update(ctx, "Update", federationID, input, opts)
+}
+
+// Patch updates only the fields of existing Federation which are set in the input.
+func (s *Service) Patch(
+	ctx context.Context, federationID iamid.FederationID, input PatchRequest, opts ...iamrequest.Option,
+) error {
+	return s.update(ctx, "Patch", federationID, input, opts)
+}
+
+// Modify fetches a Federation, applies mutate to it and sends the changed fields with Patch.
+// ID and AccountID can't be changed, so their changes are ignored.
+// If mutate changes nothing, no update request is sent.
+// An error returned by mutate is returned as is.
+func (s *Service) Modify(
+	ctx context.Context, federationID iamid.FederationID, mutate func(*Federation) error, opts ...iamrequest.Option,
+) error {
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	current, err := s.Get(ctx, federationID, calls.Read()...)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		return err
 	}
 
-	body, err := json.Marshal(input)
-	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+	modified := current.Federation
+	if err := mutate(&modified); err != nil {
+		return err
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: http.MethodPost,
-		Path:   path,
-	})
-	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
+	patch := diffFederations(current.Federation, modified)
+	if patch == (PatchRequest{}) {
+		return nil
 	}
 
-	var federation CreateResponse
-	err = client.UnmarshalJSON(response, &federation)
-	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+	return s.Patch(ctx, federationID, patch, calls.Write("patch")...)
+}
+
+func diffFederations(current, modified Federation) PatchRequest {
+	var patch PatchRequest
+	patchField(&patch.Name, current.Name, modified.Name)
+	patchField(&patch.Description, current.Description, modified.Description)
+	patchField(&patch.Alias, current.Alias, modified.Alias)
+	patchField(&patch.Issuer, current.Issuer, modified.Issuer)
+	patchField(&patch.SSOUrl, current.SSOUrl, modified.SSOUrl)
+	patchField(&patch.SignAuthnRequests, current.SignAuthnRequests, modified.SignAuthnRequests)
+	patchField(&patch.ForceAuthn, current.ForceAuthn, modified.ForceAuthn)
+	patchField(&patch.SessionMaxAgeHours, current.SessionMaxAgeHours, modified.SessionMaxAgeHours)
+	patchField(&patch.AutoUsersCreation, current.AutoUsersCreation, modified.AutoUsersCreation)
+	patchField(&patch.EnableGroupMapping, current.EnableGroupMapping, modified.EnableGroupMapping)
+	return patch
+}
+
+// patchField sets the field of PatchRequest, if the value was changed.
+func patchField[T comparable](field **T, current, modified T) {
+	if current != modified {
+		*field = &modified
 	}
-	return &federation, nil
 }
 
-// Update updates existing Federation.
-func (s *Service) Update(ctx context.Context, federationID string, input UpdateRequest) error {
+func (s *Service) update(
+	ctx context.Context, operation string, federationID iamid.FederationID, input interface{}, opts []iamrequest.Option,
+) error {
 	if federationID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrFederationIDRequired, Desc: "No federationID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "federations", "saml", federationID)
+	path, err := url.JoinPath(apiVersion, "federations", "saml", string(federationID))
 	if err != nil {
 		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
@@ -178,9 +290,16 @@ func (s *Service) Update(ctx context.Context, federationID string, input UpdateR
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: http.MethodPatch,
-		Path:   path,
+		Body:    bytes.NewReader(body),
+		Method:  http.MethodPatch,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         operation,
+			ResourceIDs:  map[string]string{"federation_id": string(federationID)},
+			PathTemplate: "v1/federations/saml/{federation_id}",
+		},
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -191,20 +310,27 @@ func (s *Service) Update(ctx context.Context, federationID string, input UpdateR
 }
 
 // Delete deletes a Federation from the account.
-func (s *Service) Delete(ctx context.Context, federationID string) error {
+func (s *Service) Delete(ctx context.Context, federationID iamid.FederationID, opts ...iamrequest.Option) error {
 	if federationID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrFederationIDRequired, Desc: "No federationID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "federations", "saml", federationID)
+	path, err := url.JoinPath(apiVersion, "federations", "saml", string(federationID))
 	if err != nil {
 		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodDelete,
-		Path:   path,
+		Body:    nil,
+		Method:  http.MethodDelete,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Delete",
+			ResourceIDs:  map[string]string{"federation_id": string(federationID)},
+			PathTemplate: "v1/federations/saml/{federation_id}",
+		},
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -215,33 +341,78 @@ func (s *Service) Delete(ctx context.Context, federationID string) error {
 }
 
 func (s *Service) getFederationResource(
-	ctx context.Context, federationID string, segments []string, output interface{},
+	ctx context.Context, operation string, federationID iamid.FederationID, segments []string, output interface{},
+	opts []iamrequest.Option,
 ) error {
 	if federationID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrFederationIDRequired, Desc: "No federationID was provided."}
 	}
 
-	pathSegments := append([]string{apiVersion, "federations", "saml", federationID}, segments...)
+	pathSegments := append([]string{apiVersion, "federations", "saml", string(federationID)}, segments...)
 
 	path, err := url.JoinPath(pathSegments[0], pathSegments[1:]...)
 	if err != nil {
 		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
-
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodGet,
-		Path:   path,
-	})
+	templateSegments := append([]string{apiVersion, "federations", "saml", "{federation_id}"}, segments...)
+	pathTemplate := strings.Join(templateSegments, "/")
+
+	err = s.baseClient.DoRequestJSON(ctx, client.DoRequestInput{
+		Body:    nil,
+		Method:  http.MethodGet,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         operation,
+			ResourceIDs:  map[string]string{"federation_id": string(federationID)},
+			PathTemplate: pathTemplate,
+		},
+	}, output)
 	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
+		//nolint:wrapcheck // DoRequestJSON already wraps the error.
 		return err
 	}
 
-	err = client.UnmarshalJSON(response, output)
+	return nil
+}
+
+// Ensure makes sure that a Federation exists and has the attributes from the input.
+// An existing Federation is looked up by the alias, if it is set, or by the name otherwise.
+// Ensure creates a missing Federation and updates the attributes of an existing one, if they differ.
+// The input must have all attributes required by Create, even if the Federation exists.
+func (s *Service) Ensure(ctx context.Context, input CreateRequest, opts ...iamrequest.Option) (*EnsureResponse, error) {
+	// Blank attributes would be sent as changes to an existing Federation.
+	if err := validateCreateRequest(input); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	current, err := s.List(ctx, calls.Read()...)
 	if err != nil {
-		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		return nil, err
 	}
+	for _, federation := range current.Federations {
+		if !federation.matches(input) {
+			continue
+		}
 
-	return nil
+		desired := federation.withAttributes(input)
+		patch := diffFederations(federation, desired)
+		if patch == (PatchRequest{}) {
+			return &EnsureResponse{Federation: federation}, nil
+		}
+		if err := s.Patch(ctx, federation.ID, patch, calls.Write("patch")...); err != nil {
+			return nil, err
+		}
+		return &EnsureResponse{Federation: desired, Updated: true}, nil
+	}
+
+	created, err := s.Create(ctx, input, calls.Write("create")...)
+	if err != nil {
+		return nil, err
+	}
+	return &EnsureResponse{Federation: created.Federation, Created: true}, nil
 }
@@ -2,6 +2,8 @@ package saml
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"testing"
 
@@ -9,9 +11,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
-	"github.com/selectel/iam-go/service/federations/saml/testdata"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/federations/saml/testdata"
 )
 
 const (
@@ -97,6 +99,81 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestForEach(t *testing.T) {
+	tests := []struct {
+		name          string
+		prepare       func()
+		expectedItems []Federation
+		expectedError error
+	}{
+		{
+			name: "ok",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+federationsURL, func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusOK, testdata.TestListFederationsResponse)
+						return resp, nil
+					})
+			},
+			expectedItems: []Federation{
+				{
+					ID:                 "123",
+					AccountID:          "123",
+					Name:               "test_name",
+					Description:        "test_description",
+					Issuer:             "test_issuer",
+					SSOUrl:             "test_sso_url",
+					SignAuthnRequests:  true,
+					ForceAuthn:         true,
+					SessionMaxAgeHours: 1,
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "error",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+federationsURL, func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusForbidden, testdata.TestDoRequestErr)
+						return resp, nil
+					})
+			},
+			expectedItems: nil,
+			expectedError: iamerrors.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			federationsAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(federationsAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			tt.prepare()
+
+			ctx := context.Background()
+			var actual []Federation
+			err := federationsAPI.ForEach(ctx, func(item Federation) error {
+				actual = append(actual, item)
+				return nil
+			})
+
+			require.ErrorIs(err, tt.expectedError)
+
+			assert.Equal(tt.expectedItems, actual)
+		})
+	}
+}
+
 func TestGet(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -432,6 +509,19 @@ func TestExists(t *testing.T) {
 			expectedExists: false,
 			expectedError:  nil,
 		},
+		{
+			name: "not found without body",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodHead, testdata.TestURL+federationsIDURL,
+					func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusNotFound, "")
+						return resp, nil
+					})
+			},
+			expectedExists: false,
+			expectedError:  nil,
+		},
 		{
 			name: "error",
 			prepare: func() {
@@ -536,3 +626,176 @@ func TestPreview(t *testing.T) {
 		})
 	}
 }
+
+func TestModify(t *testing.T) {
+	errMutate := errors.New("mutate failed")
+
+	tests := []struct {
+		name          string
+		mutate        func(*Federation) error
+		expectedBody  string
+		expectedError error
+	}{
+		{
+			name: "Test Modify sends changed fields",
+			mutate: func(federation *Federation) error {
+				federation.ForceAuthn = false
+				federation.SessionMaxAgeHours = 24
+				return nil
+			},
+			expectedBody: `{"force_authn":false,"session_max_age_hours":24}`,
+		},
+		{
+			name: "Test Modify without changes",
+			mutate: func(federation *Federation) error {
+				federation.Alias = "test_alias"
+				return nil
+			},
+		},
+		{
+			name: "Test Modify returns mutate error",
+			mutate: func(*Federation) error {
+				return errMutate
+			},
+			expectedError: errMutate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			federationsAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(federationsAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+federationsIDURL,
+				httpmock.NewStringResponder(http.StatusOK, testdata.TestGetFederationResponse))
+
+			var body []byte
+			httpmock.RegisterResponder(
+				http.MethodPatch, testdata.TestURL+federationsIDURL,
+				func(r *http.Request) (*http.Response, error) {
+					body, _ = io.ReadAll(r.Body)
+					return httpmock.NewStringResponse(http.StatusNoContent, ""), nil
+				})
+
+			err := federationsAPI.Modify(context.Background(), "123", tt.mutate)
+
+			require.ErrorIs(err, tt.expectedError)
+			if tt.expectedBody == "" {
+				assert.Nil(body)
+			} else {
+				assert.JSONEq(tt.expectedBody, string(body))
+			}
+		})
+	}
+}
+
+//nolint:funlen // This is a test function.
+func TestEnsure(t *testing.T) {
+	existing := Federation{
+		ID:                 "123",
+		Name:               "test_name",
+		Alias:              "test_alias",
+		Issuer:             "test_issuer",
+		SSOUrl:             "test_sso_url",
+		SessionMaxAgeHours: 1,
+	}
+	input := CreateRequest{
+		Name:               "test_name",
+		Issuer:             "test_issuer",
+		SSOUrl:             "test_sso_url",
+		SessionMaxAgeHours: 1,
+	}
+	renamed := input
+	renamed.Name = "new_name"
+	renamed.Alias = "test_alias"
+	renamed.ForceAuthn = true
+
+	tests := []struct {
+		name            string
+		input           CreateRequest
+		expectedMethod  string
+		expectedBody    string
+		expectedCreated bool
+		expectedUpdated bool
+		expectedError   error
+	}{
+		{
+			name:  "Test Ensure finds Federation by name",
+			input: input,
+		},
+		{
+			name:            "Test Ensure finds Federation by alias and updates it",
+			input:           renamed,
+			expectedMethod:  http.MethodPatch,
+			expectedBody:    `{"name":"new_name","force_authn":true}`,
+			expectedUpdated: true,
+		},
+		{
+			name: "Test Ensure creates missing Federation",
+			input: CreateRequest{
+				Name: "new_name", Issuer: "new_issuer", SSOUrl: "new_url", SessionMaxAgeHours: 1,
+			},
+			expectedMethod:  http.MethodPost,
+			expectedCreated: true,
+		},
+		{
+			name:          "Test Ensure doesn't blank attributes of existing Federation",
+			input:         CreateRequest{Name: "test_name", Description: "x"},
+			expectedError: iamerrors.ErrFederationIssuerRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			federationsAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(federationsAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+federationsURL,
+				httpmock.NewJsonResponderOrPanic(http.StatusOK, ListResponse{Federations: []Federation{existing}}))
+
+			var method string
+			var body []byte
+			responder := func(r *http.Request) (*http.Response, error) {
+				method = r.Method
+				body, _ = io.ReadAll(r.Body)
+				return httpmock.NewStringResponse(http.StatusOK, testdata.TestCreateFederationResponse), nil
+			}
+			httpmock.RegisterResponder(http.MethodPatch, testdata.TestURL+federationsIDURL, responder)
+			httpmock.RegisterResponder(http.MethodPost, testdata.TestURL+federationsURL, responder)
+
+			actual, err := federationsAPI.Ensure(context.Background(), tt.input)
+
+			assert.Equal(tt.expectedMethod, method)
+			if tt.expectedError != nil {
+				require.ErrorIs(err, tt.expectedError)
+				assert.Nil(actual)
+				return
+			}
+			require.NoError(err)
+			if tt.expectedBody != "" {
+				assert.JSONEq(tt.expectedBody, string(body))
+			}
+			assert.Equal(tt.expectedCreated, actual.Created)
+			assert.Equal(tt.expectedUpdated, actual.Updated)
+			assert.Equal(tt.expectedCreated || tt.expectedUpdated, actual.Changed())
+		})
+	}
+}
@@ -1,19 +1,48 @@
 package saml
 
+import "github.com/selectel/iam-go/v2/iamid"
+
 // Federation represents basic information about Federation.
 type Federation struct {
-	AccountID          string `json:"account_id"`
-	ID                 string `json:"id"`
-	Name               string `json:"name"`
-	Description        string `json:"description"`
-	Alias              string `json:"alias"`
-	Issuer             string `json:"issuer"`
-	SSOUrl             string `json:"sso_url"`
-	SignAuthnRequests  bool   `json:"sign_authn_requests"`
-	ForceAuthn         bool   `json:"force_authn"`
-	SessionMaxAgeHours int    `json:"session_max_age_hours"`
-	AutoUsersCreation  bool   `json:"auto_users_creation"`
-	EnableGroupMapping bool   `json:"enable_group_mappings"` //nolint:tagliatelle
+	AccountID          string             `json:"account_id"`
+	ID                 iamid.FederationID `json:"id"`
+	Name               string             `json:"name"`
+	Description        string             `json:"description"`
+	Alias              string             `json:"alias"`
+	Issuer             string             `json:"issuer"`
+	SSOUrl             string             `json:"sso_url"`
+	SignAuthnRequests  bool               `json:"sign_authn_requests"`
+	ForceAuthn         bool               `json:"force_authn"`
+	SessionMaxAgeHours int                `json:"session_max_age_hours"`
+	AutoUsersCreation  bool               `json:"auto_users_creation"`
+	EnableGroupMapping bool               `json:"enable_group_mappings"` //nolint:tagliatelle
+}
+
+// matches reports whether the Federation is the one described by the input:
+// their aliases are equal, if the input has one, or their names otherwise.
+func (f Federation) matches(input CreateRequest) bool {
+	if input.Alias != "" {
+		return f.Alias == input.Alias
+	}
+	return f.Name == input.Name
+}
+
+// withAttributes returns a copy of the Federation with the attributes from the input.
+// The alias is kept, if the input has none.
+func (f Federation) withAttributes(input CreateRequest) Federation {
+	f.Name = input.Name
+	f.Description = input.Description
+	if input.Alias != "" {
+		f.Alias = input.Alias
+	}
+	f.Issuer = input.Issuer
+	f.SSOUrl = input.SSOUrl
+	f.SignAuthnRequests = input.SignAuthnRequests
+	f.ForceAuthn = input.ForceAuthn
+	f.SessionMaxAgeHours = input.SessionMaxAgeHours
+	f.AutoUsersCreation = input.AutoUsersCreation
+	f.EnableGroupMapping = input.EnableGroupMapping
+	return f
 }
 
 // ListResponse represents all federations in account.
@@ -59,10 +88,41 @@ type UpdateRequest struct {
 	EnableGroupMapping *bool   `json:"enable_group_mappings,omitempty"` //nolint:tagliatelle
 }
 
+// PatchRequest is used to set options for Patch method.
+// Only the fields which are not nil are sent, the others keep their current values.
+type PatchRequest struct {
+	Name               *string `json:"name,omitempty"`
+	Description        *string `json:"description,omitempty"`
+	Alias              *string `json:"alias,omitempty"`
+	Issuer             *string `json:"issuer,omitempty"`
+	SSOUrl             *string `json:"sso_url,omitempty"`
+	SignAuthnRequests  *bool   `json:"sign_authn_requests,omitempty"`
+	ForceAuthn         *bool   `json:"force_authn,omitempty"`
+	SessionMaxAgeHours *int    `json:"session_max_age_hours,omitempty"`
+	AutoUsersCreation  *bool   `json:"auto_users_creation,omitempty"`
+	EnableGroupMapping *bool   `json:"enable_group_mappings,omitempty"` //nolint:tagliatelle
+}
+
+// EnsureResponse represents a Federation after Ensure method.
+type EnsureResponse struct {
+	Federation
+
+	// Created is true, if the Federation didn't exist and was created.
+	Created bool
+
+	// Updated is true, if the Federation existed and its attributes were updated.
+	Updated bool
+}
+
+// Changed reports whether Ensure has created or updated the Federation.
+func (r EnsureResponse) Changed() bool {
+	return r.Created || r.Updated
+}
+
 // FederationPreview represents preview information about Federation.
 type FederationPreview struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Alias       string `json:"alias"`
+	ID          iamid.FederationID `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Alias       string             `json:"alias"`
 }
@@ -4,84 +4,114 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/url"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
-	"github.com/selectel/iam-go/service/roles"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/internal/diff"
+	"github.com/selectel/iam-go/v2/service/principals"
+	"github.com/selectel/iam-go/v2/service/roles"
 )
 
-const apiVersion = "iam/v1"
+const (
+	apiVersion  = "iam/v1"
+	serviceName = "groups"
+)
 
 // Service is used to communicate with the Groups API.
 type Service struct {
+	// Principals resolves references to members of Groups used by AddMembers and DeleteMembers.
+	Principals *principals.Resolver
 	baseClient *client.BaseClient
 }
 
 // New Initialises Service with the given client.
 func New(baseClient *client.BaseClient) *Service {
 	return &Service{
+		Principals: principals.New(baseClient),
 		baseClient: baseClient,
 	}
 }
 
 // List returns a list of Groups for the account.
-func (s *Service) List(ctx context.Context) (*ListResponse, error) {
-	path, err := url.JoinPath(apiVersion, "groups")
+func (s *Service) List(ctx context.Context, opts ...iamrequest.Option) (*ListResponse, error) {
+	var groups ListResponse
+	err := s.list(ctx, client.DecodeJSON(&groups), opts)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		return nil, err
 	}
+	return &groups, nil
+}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodGet,
-		Path:   path,
-	})
+// ForEach calls fn for every Group of the account without loading the whole list into memory.
+// If fn returns an error, ForEach stops and returns it.
+func (s *Service) ForEach(ctx context.Context, fn func(Group) error, opts ...iamrequest.Option) error {
+	return s.list(ctx, client.ForEachJSON("groups", fn), opts)
+}
+
+func (s *Service) list(ctx context.Context, decode func(io.Reader) error, opts []iamrequest.Option) error {
+	path, err := url.JoinPath(apiVersion, "groups")
 	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
+		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	var groups ListResponse
-	err = client.UnmarshalJSON(response, &groups)
+	err = s.baseClient.DoRequestDecode(ctx, client.DoRequestInput{
+		Body:    nil,
+		Method:  http.MethodGet,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "List",
+			PathTemplate: "iam/v1/groups",
+		},
+	}, decode)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestDecode already wraps the error.
+		return err
 	}
-	return &groups, nil
+
+	return nil
 }
 
 // Get returns an info of Group with groupID.
-func (s *Service) Get(ctx context.Context, groupID string) (*GetResponse, error) {
+func (s *Service) Get(ctx context.Context, groupID iamid.GroupID, opts ...iamrequest.Option) (*GetResponse, error) {
 	if groupID == "" {
 		return nil, iamerrors.Error{Err: iamerrors.ErrGroupIDRequired, Desc: "No groupID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "groups", groupID)
+	path, err := url.JoinPath(apiVersion, "groups", string(groupID))
 	if err != nil {
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodGet,
-		Path:   path,
-	})
-	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
-	}
-
 	var group GetResponse
-	err = client.UnmarshalJSON(response, &group)
+	err = s.baseClient.DoRequestJSON(ctx, client.DoRequestInput{
+		Body:    nil,
+		Method:  http.MethodGet,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Get",
+			ResourceIDs:  map[string]string{"group_id": string(groupID)},
+			PathTemplate: "iam/v1/groups/{group_id}",
+		},
+	}, &group)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestJSON already wraps the error.
+		return nil, err
 	}
 	return &group, nil
 }
 
 // Create creates a new Group.
-func (s *Service) Create(ctx context.Context, input CreateRequest) (*CreateResponse, error) {
+func (s *Service) Create(ctx context.Context, input CreateRequest, opts ...iamrequest.Option) (*CreateResponse, error) {
 	if input.Name == "" {
 		return nil, iamerrors.Error{Err: iamerrors.ErrGroupNameRequired, Desc: "No Name for Group was provided."}
 	}
@@ -96,31 +126,85 @@ func (s *Service) Create(ctx context.Context, input CreateRequest) (*CreateRespo
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: http.MethodPost,
-		Path:   path,
-	})
+	var group CreateResponse
+	err = s.baseClient.DoRequestJSON(ctx, client.DoRequestInput{
+		Body:    bytes.NewReader(body),
+		Method:  http.MethodPost,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Create",
+			PathTemplate: "iam/v1/groups",
+		},
+	}, &group)
 	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
+		//nolint:wrapcheck // DoRequestJSON already wraps the error.
 		return nil, err
 	}
+	return &group, nil
+}
 
-	var group CreateResponse
-	err = client.UnmarshalJSON(response, &group)
+// Update updates exists Group.
+func (s *Service) Update(
+	ctx context.Context, groupID iamid.GroupID, input UpdateRequest, opts ...iamrequest.Option,
+) (*UpdateResponse, error) {
+	return s.update(ctx, "Update", groupID, input, opts)
+}
+
+// Patch updates only the fields of a Group which are set in the input.
+func (s *Service) Patch(
+	ctx context.Context, groupID iamid.GroupID, input PatchRequest, opts ...iamrequest.Option,
+) (*UpdateResponse, error) {
+	return s.update(ctx, "Patch", groupID, input, opts)
+}
+
+// Modify fetches a Group, applies mutate to it and sends the changed fields with Patch.
+// Only Name and Description are compared, so changes of other fields are ignored.
+// If mutate changes nothing, no update request is sent and the current Group is returned.
+// An error returned by mutate is returned as is.
+func (s *Service) Modify(
+	ctx context.Context, groupID iamid.GroupID, mutate func(*Group) error, opts ...iamrequest.Option,
+) (*UpdateResponse, error) {
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	current, err := s.Get(ctx, groupID, calls.Read()...)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		return nil, err
 	}
-	return &group, nil
+
+	modified := current.Group
+	if err := mutate(&modified); err != nil {
+		return nil, err
+	}
+
+	var patch PatchRequest
+	if modified.Name != current.Name {
+		patch.Name = &modified.Name
+	}
+	if modified.Description != current.Description {
+		patch.Description = &modified.Description
+	}
+	if patch == (PatchRequest{}) {
+		return &UpdateResponse{
+			Group:        current.Group,
+			ServiceUsers: current.ServiceUsers,
+			Users:        current.Users,
+		}, nil
+	}
+
+	return s.Patch(ctx, groupID, patch, calls.Write("patch")...)
 }
 
-// Update updates exists Group.
-func (s *Service) Update(ctx context.Context, groupID string, input UpdateRequest) (*UpdateResponse, error) {
+func (s *Service) update(
+	ctx context.Context, operation string, groupID iamid.GroupID, input interface{}, opts []iamrequest.Option,
+) (*UpdateResponse, error) {
 	if groupID == "" {
 		return nil, iamerrors.Error{Err: iamerrors.ErrGroupIDRequired, Desc: "No groupID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "groups", groupID)
+	path, err := url.JoinPath(apiVersion, "groups", string(groupID))
 	if err != nil {
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
@@ -130,39 +214,48 @@ func (s *Service) Update(ctx context.Context, groupID string, input UpdateReques
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: http.MethodPatch,
-		Path:   path,
-	})
-	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
-	}
-
 	var group UpdateResponse
-	err = client.UnmarshalJSON(response, &group)
+	err = s.baseClient.DoRequestJSON(ctx, client.DoRequestInput{
+		Body:    bytes.NewReader(body),
+		Method:  http.MethodPatch,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         operation,
+			ResourceIDs:  map[string]string{"group_id": string(groupID)},
+			PathTemplate: "iam/v1/groups/{group_id}",
+		},
+	}, &group)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestJSON already wraps the error.
+		return nil, err
 	}
 	return &group, nil
 }
 
 // Delete deletes a Group from the account.
-func (s *Service) Delete(ctx context.Context, groupID string) error {
+func (s *Service) Delete(ctx context.Context, groupID iamid.GroupID, opts ...iamrequest.Option) error {
 	if groupID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrGroupIDRequired, Desc: "No groupID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "groups", groupID)
+	path, err := url.JoinPath(apiVersion, "groups", string(groupID))
 	if err != nil {
 		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodDelete,
-		Path:   path,
+		Body:    nil,
+		Method:  http.MethodDelete,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Delete",
+			ResourceIDs:  map[string]string{"group_id": string(groupID)},
+			PathTemplate: "iam/v1/groups/{group_id}",
+		},
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -173,7 +266,9 @@ func (s *Service) Delete(ctx context.Context, groupID string) error {
 }
 
 // AssignRoles adds new roles for a Group with the given groupID.
-func (s *Service) AssignRoles(ctx context.Context, groupID string, roles []roles.Role) error {
+func (s *Service) AssignRoles(
+	ctx context.Context, groupID iamid.GroupID, roles []roles.Role, opts ...iamrequest.Option,
+) error {
 	if groupID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrGroupIDRequired, Desc: "No groupID was provided."}
 	}
@@ -182,11 +277,13 @@ func (s *Service) AssignRoles(ctx context.Context, groupID string, roles []roles
 		return iamerrors.Error{Err: iamerrors.ErrGroupRolesRequired, Desc: "No roles for Group was provided."}
 	}
 
-	return s.manageRoles(ctx, http.MethodPut, groupID, roles)
+	return s.manageRoles(ctx, "AssignRoles", http.MethodPut, groupID, roles, opts)
 }
 
 // UnassignRoles removes roles from a Group with the given groupID.
-func (s *Service) UnassignRoles(ctx context.Context, groupID string, roles []roles.Role) error {
+func (s *Service) UnassignRoles(
+	ctx context.Context, groupID iamid.GroupID, roles []roles.Role, opts ...iamrequest.Option,
+) error {
 	if groupID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrGroupIDRequired, Desc: "No groupID was provided."}
 	}
@@ -194,11 +291,45 @@ func (s *Service) UnassignRoles(ctx context.Context, groupID string, roles []rol
 		return iamerrors.Error{Err: iamerrors.ErrGroupRolesRequired, Desc: "No roles for Group was provided."}
 	}
 
-	return s.manageRoles(ctx, http.MethodDelete, groupID, roles)
+	return s.manageRoles(ctx, "UnassignRoles", http.MethodDelete, groupID, roles, opts)
+}
+
+// SetRoles makes the Group with the given groupID have exactly the given roles. It fetches the current roles,
+// assigns the missing ones first and then unassigns the extra ones. Roles are compared by role name, scope and project.
+// If assigning or unassigning fails, the error is returned together with the Change holding the roles
+// which were already assigned.
+func (s *Service) SetRoles(
+	ctx context.Context, groupID iamid.GroupID, desired []roles.Role, opts ...iamrequest.Option,
+) (*roles.Change, error) {
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	current, err := s.Get(ctx, groupID, calls.Read()...)
+	if err != nil {
+		return nil, err
+	}
+
+	var change roles.Change
+	assigned, unassigned := roles.Diff(current.Roles, desired)
+	if len(assigned) > 0 {
+		if err := s.AssignRoles(ctx, groupID, assigned, calls.Write("assign")...); err != nil {
+			return &change, err
+		}
+		change.Assigned = assigned
+	}
+	if len(unassigned) > 0 {
+		if err := s.UnassignRoles(ctx, groupID, unassigned, calls.Write("unassign")...); err != nil {
+			return &change, err
+		}
+		change.Unassigned = unassigned
+	}
+	return &change, nil
 }
 
-func (s *Service) manageRoles(ctx context.Context, method string, groupID string, roles []roles.Role) error {
-	path, err := url.JoinPath(apiVersion, "groups", groupID, "roles")
+func (s *Service) manageRoles(
+	ctx context.Context, operation, method string, groupID iamid.GroupID, roles []roles.Role, opts []iamrequest.Option,
+) error {
+	path, err := url.JoinPath(apiVersion, "groups", string(groupID), "roles")
 	if err != nil {
 		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
@@ -210,9 +341,16 @@ func (s *Service) manageRoles(ctx context.Context, method string, groupID string
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: method,
-		Path:   path,
+		Body:    bytes.NewReader(body),
+		Method:  method,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         operation,
+			ResourceIDs:  map[string]string{"group_id": string(groupID)},
+			PathTemplate: "iam/v1/groups/{group_id}/roles",
+		},
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -223,7 +361,9 @@ func (s *Service) manageRoles(ctx context.Context, method string, groupID string
 }
 
 // AddUsers adds new users to a Group with the given groupID.
-func (s *Service) AddUsers(ctx context.Context, groupID string, usersKeystoneIDs []string) error {
+func (s *Service) AddUsers(
+	ctx context.Context, groupID iamid.GroupID, usersKeystoneIDs []iamid.KeystoneID, opts ...iamrequest.Option,
+) error {
 	if groupID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrGroupIDRequired, Desc: "No groupID was provided."}
 	}
@@ -232,11 +372,13 @@ func (s *Service) AddUsers(ctx context.Context, groupID string, usersKeystoneIDs
 		return iamerrors.Error{Err: iamerrors.ErrGroupUserIDsRequired, Desc: "No users for Group was provided."}
 	}
 
-	return s.manageUsers(ctx, http.MethodPut, groupID, usersKeystoneIDs)
+	return s.manageUsers(ctx, "AddUsers", http.MethodPut, groupID, usersKeystoneIDs, opts)
 }
 
 // DeleteUsers removes users from a Group with the given groupID.
-func (s *Service) DeleteUsers(ctx context.Context, groupID string, usersKeystoneIDs []string) error {
+func (s *Service) DeleteUsers(
+	ctx context.Context, groupID iamid.GroupID, usersKeystoneIDs []iamid.KeystoneID, opts ...iamrequest.Option,
+) error {
 	if groupID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrGroupIDRequired, Desc: "No groupID was provided."}
 	}
@@ -244,11 +386,97 @@ func (s *Service) DeleteUsers(ctx context.Context, groupID string, usersKeystone
 		return iamerrors.Error{Err: iamerrors.ErrGroupUserIDsRequired, Desc: "No users for Group was provided."}
 	}
 
-	return s.manageUsers(ctx, http.MethodDelete, groupID, usersKeystoneIDs)
+	return s.manageUsers(ctx, "DeleteUsers", http.MethodDelete, groupID, usersKeystoneIDs, opts)
+}
+
+// AddMembers adds Panel Users and Service Users to a Group with the given groupID.
+// Unlike AddUsers, it accepts any references to principals, e.g. User IDs or Service User names,
+// and resolves them into Keystone IDs with Principals.
+func (s *Service) AddMembers(
+	ctx context.Context, groupID iamid.GroupID, members []principals.Ref, opts ...iamrequest.Option,
+) error {
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	keystoneIDs, err := s.resolveMembers(ctx, groupID, members, calls.Read())
+	if err != nil {
+		return err
+	}
+	return s.AddUsers(ctx, groupID, keystoneIDs, calls.Write("add")...)
 }
 
-func (s *Service) manageUsers(ctx context.Context, method string, groupID string, usersKeystoneIDs []string) error {
-	path, err := url.JoinPath(apiVersion, "groups", groupID, "users")
+// DeleteMembers removes Panel Users and Service Users from a Group with the given groupID.
+// Unlike DeleteUsers, it accepts any references to principals, e.g. User IDs or Service User names,
+// and resolves them into Keystone IDs with Principals.
+func (s *Service) DeleteMembers(
+	ctx context.Context, groupID iamid.GroupID, members []principals.Ref, opts ...iamrequest.Option,
+) error {
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	keystoneIDs, err := s.resolveMembers(ctx, groupID, members, calls.Read())
+	if err != nil {
+		return err
+	}
+	return s.DeleteUsers(ctx, groupID, keystoneIDs, calls.Write("delete")...)
+}
+
+func (s *Service) resolveMembers(
+	ctx context.Context, groupID iamid.GroupID, members []principals.Ref, opts []iamrequest.Option,
+) ([]iamid.KeystoneID, error) {
+	if groupID == "" {
+		return nil, iamerrors.Error{Err: iamerrors.ErrGroupIDRequired, Desc: "No groupID was provided."}
+	}
+	if len(members) == 0 {
+		return nil, iamerrors.Error{Err: iamerrors.ErrGroupUserIDsRequired, Desc: "No users for Group was provided."}
+	}
+
+	//nolint:wrapcheck // Resolve already wraps the error.
+	return s.Principals.Resolve(ctx, members, opts...)
+}
+
+// SetMembers makes the Group with the given groupID have exactly the given members.
+// Members are identified by Keystone IDs of Panel Users and IDs of Service Users.
+// It fetches the current members, adds the missing ones first and then deletes the extra ones.
+// If adding or deleting fails, the error is returned together with the MembersChange holding the members
+// which were already added.
+func (s *Service) SetMembers(
+	ctx context.Context, groupID iamid.GroupID, usersKeystoneIDs []iamid.KeystoneID, opts ...iamrequest.Option,
+) (*MembersChange, error) {
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	current, err := s.Get(ctx, groupID, calls.Read()...)
+	if err != nil {
+		return nil, err
+	}
+
+	var change MembersChange
+	added, deleted := diff.Diff(current.memberIDs(), usersKeystoneIDs)
+	if len(added) > 0 {
+		if err := s.AddUsers(ctx, groupID, added, calls.Write("add")...); err != nil {
+			return &change, err
+		}
+		change.Added = added
+	}
+	if len(deleted) > 0 {
+		if err := s.DeleteUsers(ctx, groupID, deleted, calls.Write("delete")...); err != nil {
+			return &change, err
+		}
+		change.Deleted = deleted
+	}
+	return &change, nil
+}
+
+func (s *Service) manageUsers(
+	ctx context.Context,
+	operation,
+	method string,
+	groupID iamid.GroupID,
+	usersKeystoneIDs []iamid.KeystoneID,
+	opts []iamrequest.Option,
+) error {
+	path, err := url.JoinPath(apiVersion, "groups", string(groupID), "users")
 	if err != nil {
 		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
@@ -260,9 +488,16 @@ func (s *Service) manageUsers(ctx context.Context, method string, groupID string
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: method,
-		Path:   path,
+		Body:    bytes.NewReader(body),
+		Method:  method,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         operation,
+			ResourceIDs:  map[string]string{"group_id": string(groupID)},
+			PathTemplate: "iam/v1/groups/{group_id}/users",
+		},
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -271,3 +506,40 @@ func (s *Service) manageUsers(ctx context.Context, method string, groupID string
 
 	return nil
 }
+
+// Ensure makes sure that a Group with the given name exists and has the given description.
+// It creates a missing Group and updates the description of an existing one, if it differs.
+func (s *Service) Ensure(ctx context.Context, input CreateRequest, opts ...iamrequest.Option) (*EnsureResponse, error) {
+	if input.Name == "" {
+		return nil, iamerrors.Error{Err: iamerrors.ErrGroupNameRequired, Desc: "No Name for Group was provided."}
+	}
+
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	current, err := s.List(ctx, calls.Read()...)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range current.Groups {
+		if group.Name != input.Name {
+			continue
+		}
+		if group.Description == input.Description {
+			return &EnsureResponse{Group: group}, nil
+		}
+
+		patch := PatchRequest{Description: &input.Description}
+		if _, err := s.Patch(ctx, group.ID, patch, calls.Write("patch")...); err != nil {
+			return nil, err
+		}
+		group.Description = input.Description
+		return &EnsureResponse{Group: group, Updated: true}, nil
+	}
+
+	created, err := s.Create(ctx, input, calls.Write("create")...)
+	if err != nil {
+		return nil, err
+	}
+	return &EnsureResponse{Group: created.Group, Created: true}, nil
+}
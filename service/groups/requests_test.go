@@ -2,6 +2,8 @@ package groups
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"testing"
 
@@ -9,11 +11,14 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
-	"github.com/selectel/iam-go/service/groups/testdata"
-	"github.com/selectel/iam-go/service/roles"
-	"github.com/selectel/iam-go/service/users"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/groups/testdata"
+	"github.com/selectel/iam-go/v2/service/principals"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/serviceusers"
+	"github.com/selectel/iam-go/v2/service/users"
 )
 
 const (
@@ -97,9 +102,81 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestForEach(t *testing.T) {
+	tests := []struct {
+		name          string
+		prepare       func()
+		expectedItems []Group
+		expectedError error
+	}{
+		{
+			name: "ok",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+groupsURL, func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusOK, testdata.TestListGroupsResponse)
+						return resp, nil
+					})
+			},
+			expectedItems: []Group{
+				{
+					ID:          "123",
+					Name:        "test_name",
+					Description: "test_description",
+					Roles: []roles.Role{
+						{Scope: AccountScope, RoleName: Member},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "error",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+groupsURL, func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusForbidden, testdata.TestDoRequestErr)
+						return resp, nil
+					})
+			},
+			expectedItems: nil,
+			expectedError: iamerrors.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			groupsAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(groupsAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			tt.prepare()
+
+			ctx := context.Background()
+			var actual []Group
+			err := groupsAPI.ForEach(ctx, func(item Group) error {
+				actual = append(actual, item)
+				return nil
+			})
+
+			require.ErrorIs(err, tt.expectedError)
+
+			assert.Equal(tt.expectedItems, actual)
+		})
+	}
+}
+
 func TestGet(t *testing.T) {
 	type args struct {
-		groupID string
+		groupID iamid.GroupID
 	}
 	tests := []struct {
 		name             string
@@ -193,7 +270,7 @@ func TestGet(t *testing.T) {
 
 func TestDelete(t *testing.T) {
 	type args struct {
-		groupID string
+		groupID iamid.GroupID
 	}
 	tests := []struct {
 		name          string
@@ -338,7 +415,7 @@ func TestCreate(t *testing.T) {
 
 func TestUpdate(t *testing.T) {
 	type args struct {
-		groupID     string
+		groupID     iamid.GroupID
 		name        string
 		description *string
 	}
@@ -424,7 +501,7 @@ func TestUpdate(t *testing.T) {
 
 func TestAssignRoles(t *testing.T) {
 	type args struct {
-		groupID string
+		groupID iamid.GroupID
 		roles   []roles.Role
 	}
 	tests := []struct {
@@ -494,7 +571,7 @@ func TestAssignRoles(t *testing.T) {
 
 func TestUnassignRoles(t *testing.T) {
 	type args struct {
-		groupID string
+		groupID iamid.GroupID
 		roles   []roles.Role
 	}
 	tests := []struct {
@@ -564,8 +641,8 @@ func TestUnassignRoles(t *testing.T) {
 
 func TestAddUsers(t *testing.T) {
 	type args struct {
-		groupID  string
-		usersIDs []string
+		groupID  iamid.GroupID
+		usersIDs []iamid.KeystoneID
 	}
 	tests := []struct {
 		name          string
@@ -577,7 +654,7 @@ func TestAddUsers(t *testing.T) {
 			name: "ok",
 			args: args{
 				groupID:  "123",
-				usersIDs: []string{"user123"},
+				usersIDs: []iamid.KeystoneID{"user123"},
 			},
 			prepare: func() {
 				httpmock.RegisterResponder(
@@ -592,7 +669,7 @@ func TestAddUsers(t *testing.T) {
 			name: "error",
 			args: args{
 				groupID:  "123",
-				usersIDs: []string{"user123"},
+				usersIDs: []iamid.KeystoneID{"user123"},
 			},
 			prepare: func() {
 				httpmock.RegisterResponder(
@@ -630,8 +707,8 @@ func TestAddUsers(t *testing.T) {
 
 func TestDeleteUsers(t *testing.T) {
 	type args struct {
-		groupID  string
-		usersIDs []string
+		groupID  iamid.GroupID
+		usersIDs []iamid.KeystoneID
 	}
 	tests := []struct {
 		name          string
@@ -643,7 +720,7 @@ func TestDeleteUsers(t *testing.T) {
 			name: "ok",
 			args: args{
 				groupID:  "123",
-				usersIDs: []string{"user123"},
+				usersIDs: []iamid.KeystoneID{"user123"},
 			},
 			prepare: func() {
 				httpmock.RegisterResponder(
@@ -658,7 +735,7 @@ func TestDeleteUsers(t *testing.T) {
 			name: "error",
 			args: args{
 				groupID:  "123",
-				usersIDs: []string{"user123"},
+				usersIDs: []iamid.KeystoneID{"user123"},
 			},
 			prepare: func() {
 				httpmock.RegisterResponder(
@@ -693,3 +770,365 @@ func TestDeleteUsers(t *testing.T) {
 		})
 	}
 }
+
+func TestModify(t *testing.T) {
+	errMutate := errors.New("mutate failed")
+
+	tests := []struct {
+		name          string
+		mutate        func(*Group) error
+		expectedBody  string
+		expectedError error
+	}{
+		{
+			name: "Test Modify sends changed fields",
+			mutate: func(group *Group) error {
+				group.Description = "new_description"
+				return nil
+			},
+			expectedBody: `{"description":"new_description"}`,
+		},
+		{
+			name: "Test Modify without changes",
+			mutate: func(group *Group) error {
+				group.Name = "test_name"
+				return nil
+			},
+		},
+		{
+			name: "Test Modify returns mutate error",
+			mutate: func(*Group) error {
+				return errMutate
+			},
+			expectedError: errMutate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			groupsAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(groupsAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+groupsIDURL,
+				httpmock.NewStringResponder(http.StatusOK, testdata.TestGetGroupResponse))
+
+			var body []byte
+			httpmock.RegisterResponder(
+				http.MethodPatch, testdata.TestURL+groupsIDURL,
+				func(r *http.Request) (*http.Response, error) {
+					body, _ = io.ReadAll(r.Body)
+					return httpmock.NewStringResponse(http.StatusOK, testdata.TestUpdateGroupResponse), nil
+				})
+
+			_, err := groupsAPI.Modify(context.Background(), "123", tt.mutate)
+
+			require.ErrorIs(err, tt.expectedError)
+			if tt.expectedBody == "" {
+				assert.Nil(body)
+			} else {
+				assert.JSONEq(tt.expectedBody, string(body))
+			}
+		})
+	}
+}
+
+//nolint:funlen // This is a test function.
+func TestEnsure(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           CreateRequest
+		expectedMethod  string
+		expectedBody    string
+		expectedCreated bool
+		expectedUpdated bool
+		expectedError   error
+	}{
+		{
+			name:  "Test Ensure finds existing Group",
+			input: CreateRequest{Name: "test_name", Description: "test_description"},
+		},
+		{
+			name:            "Test Ensure updates drifted description",
+			input:           CreateRequest{Name: "test_name", Description: "new_description"},
+			expectedMethod:  http.MethodPatch,
+			expectedBody:    `{"description":"new_description"}`,
+			expectedUpdated: true,
+		},
+		{
+			name:            "Test Ensure creates missing Group",
+			input:           CreateRequest{Name: "new_name"},
+			expectedMethod:  http.MethodPost,
+			expectedBody:    `{"name":"new_name"}`,
+			expectedCreated: true,
+		},
+		{
+			name:          "Test Ensure without name",
+			input:         CreateRequest{},
+			expectedError: iamerrors.ErrGroupNameRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			groupsAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(groupsAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+groupsURL,
+				httpmock.NewStringResponder(http.StatusOK, testdata.TestListGroupsResponse))
+
+			var method string
+			var body []byte
+			responder := func(r *http.Request) (*http.Response, error) {
+				method = r.Method
+				body, _ = io.ReadAll(r.Body)
+				return httpmock.NewStringResponse(http.StatusOK, testdata.TestCreateGroupResponse), nil
+			}
+			httpmock.RegisterResponder(http.MethodPatch, testdata.TestURL+groupsIDURL, responder)
+			httpmock.RegisterResponder(http.MethodPost, testdata.TestURL+groupsURL, responder)
+
+			actual, err := groupsAPI.Ensure(context.Background(), tt.input)
+
+			require.ErrorIs(err, tt.expectedError)
+			assert.Equal(tt.expectedMethod, method)
+			if tt.expectedBody != "" {
+				assert.JSONEq(tt.expectedBody, string(body))
+			}
+			if tt.expectedError != nil {
+				return
+			}
+			assert.Equal(tt.expectedCreated, actual.Created)
+			assert.Equal(tt.expectedUpdated, actual.Updated)
+			assert.Equal(tt.expectedCreated || tt.expectedUpdated, actual.Changed())
+		})
+	}
+}
+
+//nolint:funlen // This is a test function.
+func TestSetRoles(t *testing.T) {
+	member := roles.Role{Scope: AccountScope, RoleName: Member}
+	reader := roles.Role{Scope: AccountScope, RoleName: "reader"}
+
+	tests := []struct {
+		name             string
+		desired          []roles.Role
+		getStatus        int
+		expectedRequests []string
+		expectedChange   *roles.Change
+		expectedError    error
+	}{
+		{
+			name:           "Test SetRoles without changes",
+			desired:        []roles.Role{member},
+			getStatus:      http.StatusOK,
+			expectedChange: &roles.Change{},
+		},
+		{
+			name:      "Test SetRoles assigns and unassigns roles",
+			desired:   []roles.Role{reader},
+			getStatus: http.StatusOK,
+			expectedRequests: []string{
+				`PUT {"roles":[{"role_name":"reader","scope":"account"}]}`,
+				`DELETE {"roles":[{"role_name":"member","scope":"account"}]}`,
+			},
+			expectedChange: &roles.Change{Assigned: []roles.Role{reader}, Unassigned: []roles.Role{member}},
+		},
+		{
+			name:          "Test SetRoles returns Get error",
+			desired:       []roles.Role{reader},
+			getStatus:     http.StatusForbidden,
+			expectedError: iamerrors.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			groupsAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(groupsAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			getResponse := testdata.TestGetGroupResponse
+			if tt.getStatus != http.StatusOK {
+				getResponse = testdata.TestDoRequestErr
+			}
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+groupsIDURL,
+				httpmock.NewStringResponder(tt.getStatus, getResponse))
+
+			var requests []string
+			responder := func(r *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(r.Body)
+				requests = append(requests, r.Method+" "+string(body))
+				return httpmock.NewStringResponse(http.StatusOK, ""), nil
+			}
+			httpmock.RegisterResponder(http.MethodPut, testdata.TestURL+rolesURL, responder)
+			httpmock.RegisterResponder(http.MethodDelete, testdata.TestURL+rolesURL, responder)
+
+			actual, err := groupsAPI.SetRoles(context.Background(), "123", tt.desired)
+
+			require.ErrorIs(err, tt.expectedError)
+			assert.Equal(tt.expectedRequests, requests)
+			assert.Equal(tt.expectedChange, actual)
+		})
+	}
+}
+
+//nolint:funlen // This is a test function.
+func TestSetMembers(t *testing.T) {
+	const (
+		userID        = "2b573185f23a40c88cbb59ed74dba683"
+		serviceUserID = "c1f50a57fc95438aafe1e2fe87a781c2"
+	)
+
+	tests := []struct {
+		name             string
+		desired          []iamid.KeystoneID
+		expectedRequests []string
+		expectedChange   *MembersChange
+	}{
+		{
+			name:           "Test SetMembers without changes",
+			desired:        []iamid.KeystoneID{serviceUserID, userID},
+			expectedChange: &MembersChange{},
+		},
+		{
+			name:    "Test SetMembers adds and deletes members",
+			desired: []iamid.KeystoneID{userID, "new"},
+			expectedRequests: []string{
+				`PUT {"keystone_ids":["new"]}`,
+				`DELETE {"keystone_ids":["` + serviceUserID + `"]}`,
+			},
+			expectedChange: &MembersChange{
+				Added:   []iamid.KeystoneID{"new"},
+				Deleted: []iamid.KeystoneID{serviceUserID},
+			},
+		},
+		{
+			name: "Test SetMembers deletes all members",
+			expectedRequests: []string{
+				`DELETE {"keystone_ids":["` + userID + `","` + serviceUserID + `"]}`,
+			},
+			expectedChange: &MembersChange{Deleted: []iamid.KeystoneID{userID, serviceUserID}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			groupsAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(groupsAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+groupsIDURL,
+				httpmock.NewStringResponder(http.StatusOK, testdata.TestGetGroupResponse))
+
+			var requests []string
+			responder := func(r *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(r.Body)
+				requests = append(requests, r.Method+" "+string(body))
+				return httpmock.NewStringResponse(http.StatusOK, ""), nil
+			}
+			httpmock.RegisterResponder(http.MethodPut, testdata.TestURL+usersURL, responder)
+			httpmock.RegisterResponder(http.MethodDelete, testdata.TestURL+usersURL, responder)
+
+			actual, err := groupsAPI.SetMembers(context.Background(), "123", tt.desired)
+
+			require.NoError(err)
+			assert.Equal(tt.expectedRequests, requests)
+			assert.Equal(tt.expectedChange, actual)
+		})
+	}
+}
+
+func TestAddMembers(t *testing.T) {
+	tests := []struct {
+		name             string
+		members          []principals.Ref
+		expectedRequests []string
+		expectedError    error
+	}{
+		{
+			name:             "Test AddMembers resolves references",
+			members:          []principals.Ref{principals.UserID("999000_33333"), principals.ServiceUserName("backup")},
+			expectedRequests: []string{`PUT {"keystone_ids":["2b573185f23a40c88cbb59ed74dba683","0f1e2d"]}`},
+		},
+		{
+			name:          "Test AddMembers with unknown reference",
+			members:       []principals.Ref{principals.ServiceUserName("unknown")},
+			expectedError: iamerrors.ErrPrincipalNotFound,
+		},
+		{
+			name:          "Test AddMembers without members",
+			expectedError: iamerrors.ErrGroupUserIDsRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			groupsAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(groupsAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+"iam/v1/users",
+				httpmock.NewJsonResponderOrPanic(http.StatusOK, users.ListResponse{Users: []users.User{
+					{ID: "999000_33333", KeystoneID: "2b573185f23a40c88cbb59ed74dba683"},
+				}}))
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+"iam/v1/service_users",
+				httpmock.NewJsonResponderOrPanic(http.StatusOK, serviceusers.ListResponse{
+					Users: []serviceusers.ServiceUser{{ID: "0f1e2d", Name: "backup"}},
+				}))
+
+			var requests []string
+			httpmock.RegisterResponder(http.MethodPut, testdata.TestURL+usersURL,
+				func(r *http.Request) (*http.Response, error) {
+					body, _ := io.ReadAll(r.Body)
+					requests = append(requests, r.Method+" "+string(body))
+					return httpmock.NewStringResponse(http.StatusOK, ""), nil
+				})
+
+			err := groupsAPI.AddMembers(context.Background(), "123", tt.members)
+
+			require.ErrorIs(err, tt.expectedError)
+			assert.Equal(tt.expectedRequests, requests)
+		})
+	}
+}
@@ -1,8 +1,9 @@
 package groups
 
 import (
-	"github.com/selectel/iam-go/service/roles"
-	"github.com/selectel/iam-go/service/users"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/users"
 )
 
 // ListResponse represents all Groups in account.
@@ -12,10 +13,10 @@ type ListResponse struct {
 
 // Group represents basic information about a Group.
 type Group struct {
-	ID          string       `json:"id"`
-	Name        string       `json:"name"`
-	Description string       `json:"description"`
-	Roles       []roles.Role `json:"roles"`
+	ID          iamid.GroupID `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Roles       []roles.Role  `json:"roles"`
 }
 
 // GetResponse represents a Group of users.
@@ -39,19 +40,61 @@ type UpdateResponse struct {
 	Users        []User        `json:"users"`
 }
 
+// EnsureResponse represents a Group after Ensure method.
+type EnsureResponse struct {
+	Group
+
+	// Created is true, if the Group didn't exist and was created.
+	Created bool
+
+	// Updated is true, if the Group existed and its attributes were updated.
+	Updated bool
+}
+
+// Changed reports whether Ensure has created or updated the Group.
+func (r EnsureResponse) Changed() bool {
+	return r.Created || r.Updated
+}
+
+// memberIDs returns Keystone IDs of Panel Users and IDs of Service Users in the Group.
+func (r GetResponse) memberIDs() []iamid.KeystoneID {
+	ids := make([]iamid.KeystoneID, 0, len(r.Users)+len(r.ServiceUsers))
+	for _, user := range r.Users {
+		ids = append(ids, user.KeystoneID)
+	}
+	for _, serviceUser := range r.ServiceUsers {
+		ids = append(ids, iamid.KeystoneIDOfServiceUser(serviceUser.ID))
+	}
+	return ids
+}
+
+// MembersChange represents members added and deleted by SetMembers method.
+type MembersChange struct {
+	// Added contains IDs of members which were missing and were added.
+	Added []iamid.KeystoneID
+
+	// Deleted contains IDs of members which were not desired and were deleted.
+	Deleted []iamid.KeystoneID
+}
+
+// Changed reports whether any member was added or deleted.
+func (c MembersChange) Changed() bool {
+	return len(c.Added) > 0 || len(c.Deleted) > 0
+}
+
 // ServiceUser represents a Selectel Service User in Group.
 type ServiceUser struct {
-	ID      string `json:"id"`
-	Enabled bool   `json:"enabled"`
-	Name    string `json:"name"`
+	ID      iamid.UserID `json:"id"`
+	Enabled bool         `json:"enabled"`
+	Name    string       `json:"name"`
 }
 
 // User represents a Selectel Panel User in Group.
 type User struct {
 	AuthType   users.AuthType    `json:"auth_type"`
 	Federation *users.Federation `json:"federation,omitempty"`
-	ID         string            `json:"id"`
-	KeystoneID string            `json:"keystone_id"`
+	ID         iamid.UserID      `json:"id"`
+	KeystoneID iamid.KeystoneID  `json:"keystone_id"`
 }
 
 // CreateRequest is used to set options for Create method.
@@ -66,10 +109,17 @@ type UpdateRequest struct {
 	Description *string `json:"description,omitempty"`
 }
 
+// PatchRequest is used to set options for Patch method.
+// Only the fields which are not nil are sent, the others keep their current values.
+type PatchRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
 type manageRolesRequest struct {
 	Roles []roles.Role `json:"roles"`
 }
 
 type manageUsersRequest struct {
-	KeystoneIds []string `json:"keystone_ids"`
+	KeystoneIds []iamid.KeystoneID `json:"keystone_ids"`
 }
@@ -0,0 +1,3 @@
+// Package principals resolves references to members of Groups, e.g. User IDs, Service User names
+// or external IDs of federated Users, into Keystone IDs, which are expected by the Groups API.
+package principals
@@ -0,0 +1,123 @@
+package principals
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/serviceusers"
+	"github.com/selectel/iam-go/v2/service/users"
+)
+
+// Resolver resolves references to Panel Users and Service Users into their Keystone IDs.
+//
+// It builds an index of all Users and Service Users of the account on the first call and caches it.
+// If a reference matches no principal, the index is rebuilt once, so recently created principals are found too.
+// A call with a token from iamrequest.WithToken may concern another account, so it builds its own index,
+// which isn't cached.
+// Resolver is safe for concurrent use.
+type Resolver struct {
+	users        *users.Service
+	serviceUsers *serviceusers.Service
+
+	mu    sync.Mutex
+	index index
+}
+
+// New initialises Resolver with the given client.
+func New(baseClient *client.BaseClient) *Resolver {
+	return &Resolver{
+		users:        users.New(baseClient),
+		serviceUsers: serviceusers.New(baseClient),
+	}
+}
+
+// Resolve returns Keystone IDs of the principals matching refs, in the same order.
+// It returns iamerrors.ErrPrincipalNotFound, if a reference matches no principal,
+// and iamerrors.ErrPrincipalAmbiguous, if a reference matches several principals.
+func (r *Resolver) Resolve(ctx context.Context, refs []Ref, opts ...iamrequest.Option) ([]iamid.KeystoneID, error) {
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+	opts = calls.Read()
+
+	if iamrequest.NewOptions(opts...).Token != "" {
+		idx, err := r.build(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		return idx.resolve(refs)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cached := r.index != nil
+	if !cached {
+		if err := r.rebuild(ctx, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	keystoneIDs, err := r.index.resolve(refs)
+	if !cached || !errors.Is(err, iamerrors.ErrPrincipalNotFound) {
+		return keystoneIDs, err
+	}
+
+	if err := r.rebuild(ctx, opts); err != nil {
+		return nil, err
+	}
+	return r.index.resolve(refs)
+}
+
+// Reset drops the cached index, so it is rebuilt on the next call of Resolve.
+func (r *Resolver) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.index = nil
+}
+
+func (r *Resolver) rebuild(ctx context.Context, opts []iamrequest.Option) error {
+	idx, err := r.build(ctx, opts)
+	if err != nil {
+		return err
+	}
+	r.index = idx
+	return nil
+}
+
+func (r *Resolver) build(ctx context.Context, opts []iamrequest.Option) (index, error) {
+	idx := make(index)
+
+	err := r.users.ForEach(ctx, func(user users.User) error {
+		idx.add(UserID(user.ID), user.KeystoneID)
+		idx.add(KeystoneID(user.KeystoneID), user.KeystoneID)
+		if user.Federation != nil {
+			idx.add(ExternalID(user.Federation.ID, user.Federation.ExternalID), user.KeystoneID)
+			idx.add(ExternalID("", user.Federation.ExternalID), user.KeystoneID)
+		}
+		return nil
+	}, opts...)
+	if err != nil {
+		//nolint:wrapcheck // ForEach already wraps the error.
+		return nil, err
+	}
+
+	err = r.serviceUsers.ForEach(ctx, func(user serviceusers.ServiceUser) error {
+		keystoneID := iamid.KeystoneIDOfServiceUser(user.ID)
+		idx.add(UserID(user.ID), keystoneID)
+		idx.add(KeystoneID(keystoneID), keystoneID)
+		idx.add(ServiceUserName(user.Name), keystoneID)
+		return nil
+	}, opts...)
+	if err != nil {
+		//nolint:wrapcheck // ForEach already wraps the error.
+		return nil, err
+	}
+
+	return idx, nil
+}
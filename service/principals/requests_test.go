@@ -0,0 +1,193 @@
+package principals
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/principals/testdata"
+)
+
+const (
+	usersURL        = "iam/v1/users"
+	serviceUsersURL = "iam/v1/service_users"
+)
+
+//nolint:funlen // This is a test function.
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name          string
+		refs          []Ref
+		expectedIDs   []iamid.KeystoneID
+		expectedError error
+	}{
+		{
+			name: "Test Resolve all kinds of references",
+			refs: []Ref{
+				UserID("999000_11111"),
+				KeystoneID("4d5e6f"),
+				ExternalID("fed2", "alice"),
+				ServiceUserName("backup"),
+				KeystoneID("0f1e2d"),
+				Any("999000_22222"),
+				UserID("0f1e2d"),
+			},
+			expectedIDs: []iamid.KeystoneID{"1a2b3c", "4d5e6f", "7a8b9c", "0f1e2d", "0f1e2d", "4d5e6f", "0f1e2d"},
+		},
+		{
+			name:          "Test Resolve external ID of several Federations",
+			refs:          []Ref{ExternalID("", "alice")},
+			expectedError: iamerrors.ErrPrincipalAmbiguous,
+		},
+		{
+			name:          "Test Resolve ambiguous reference of any kind",
+			refs:          []Ref{Any("1a2b3c")},
+			expectedError: iamerrors.ErrPrincipalAmbiguous,
+		},
+		{
+			name:          "Test Resolve unknown principal",
+			refs:          []Ref{ServiceUserName("unknown")},
+			expectedError: iamerrors.ErrPrincipalNotFound,
+		},
+		{
+			name:          "Test Resolve reference of a wrong kind",
+			refs:          []Ref{UserID("1a2b3c")},
+			expectedError: iamerrors.ErrPrincipalNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			baseClient := &client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			}
+			resolver := New(baseClient)
+
+			httpmock.ActivateNonDefault(baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+usersURL,
+				httpmock.NewStringResponder(http.StatusOK, testdata.TestListUsersResponse))
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+serviceUsersURL,
+				httpmock.NewStringResponder(http.StatusOK, testdata.TestListServiceUsersResponse))
+
+			actual, err := resolver.Resolve(context.Background(), tt.refs)
+
+			require.ErrorIs(err, tt.expectedError)
+			assert.Equal(tt.expectedIDs, actual)
+		})
+	}
+}
+
+func TestResolveCache(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	baseClient := &client.BaseClient{
+		HTTPClient: &http.Client{},
+		APIUrl:     testdata.TestURL,
+		AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+	}
+	resolver := New(baseClient)
+
+	httpmock.ActivateNonDefault(baseClient.HTTPClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+usersURL,
+		httpmock.NewStringResponder(http.StatusOK, testdata.TestListUsersResponse))
+	httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+serviceUsersURL,
+		httpmock.NewStringResponder(http.StatusOK, testdata.TestListServiceUsersResponse))
+	ctx := context.Background()
+
+	_, err := resolver.Resolve(ctx, []Ref{ServiceUserName("backup")})
+	require.NoError(err)
+	_, err = resolver.Resolve(ctx, []Ref{UserID("999000_11111")})
+	require.NoError(err)
+	assert.Equal(2, httpmock.GetTotalCallCount(), "the index is cached")
+
+	_, err = resolver.Resolve(ctx, []Ref{ServiceUserName("unknown")})
+	require.ErrorIs(err, iamerrors.ErrPrincipalNotFound)
+	assert.Equal(4, httpmock.GetTotalCallCount(), "the index is rebuilt once on a miss")
+
+	resolver.Reset()
+	_, err = resolver.Resolve(ctx, []Ref{ServiceUserName("backup")})
+	require.NoError(err)
+	assert.Equal(6, httpmock.GetTotalCallCount(), "the index is rebuilt after Reset")
+}
+
+func TestResolvePerCallToken(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	baseClient := &client.BaseClient{
+		HTTPClient: &http.Client{},
+		APIUrl:     testdata.TestURL,
+		AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+	}
+	resolver := New(baseClient)
+
+	httpmock.ActivateNonDefault(baseClient.HTTPClient)
+	defer httpmock.DeactivateAndReset()
+
+	var tokens []string
+	responder := func(body string) httpmock.Responder {
+		return func(r *http.Request) (*http.Response, error) {
+			tokens = append(tokens, r.Header.Get("X-Auth-Token"))
+			return httpmock.NewStringResponse(http.StatusOK, body), nil
+		}
+	}
+	httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+usersURL,
+		responder(testdata.TestListUsersResponse))
+	httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+serviceUsersURL,
+		responder(testdata.TestListServiceUsersResponse))
+
+	ctx := context.Background()
+	refs := []Ref{ServiceUserName("backup")}
+
+	_, err := resolver.Resolve(ctx, refs)
+	require.NoError(err)
+	_, err = resolver.Resolve(ctx, refs, iamrequest.WithToken("other"))
+	require.NoError(err)
+	_, err = resolver.Resolve(ctx, refs, iamrequest.WithToken("other"))
+	require.NoError(err)
+	_, err = resolver.Resolve(ctx, refs)
+	require.NoError(err)
+
+	assert.Equal([]string{
+		testdata.TestToken, testdata.TestToken,
+		"other", "other",
+		"other", "other",
+	}, tokens, "the index of a per-call token is neither cached nor replaces the cached one")
+}
+
+func TestResolveError(t *testing.T) {
+	baseClient := &client.BaseClient{
+		HTTPClient: &http.Client{},
+		APIUrl:     testdata.TestURL,
+		AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+	}
+	resolver := New(baseClient)
+
+	httpmock.ActivateNonDefault(baseClient.HTTPClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+usersURL,
+		httpmock.NewStringResponder(http.StatusForbidden, testdata.TestDoRequestErr))
+
+	_, err := resolver.Resolve(context.Background(), []Ref{UserID("999000_11111")})
+
+	require.ErrorIs(t, err, iamerrors.ErrForbidden)
+}
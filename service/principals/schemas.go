@@ -0,0 +1,114 @@
+package principals
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+)
+
+// Kind represents a kind of reference to a principal.
+type Kind string
+
+const (
+	// KindAny matches every other kind of reference. The reference is ambiguous,
+	// if its value is e.g. a name of one Service User and an ID of another one.
+	KindAny Kind = "reference"
+
+	// KindUserID matches an ID of a Panel User or a Service User, e.g. users.User.ID or serviceusers.ServiceUser.ID.
+	KindUserID Kind = "user ID"
+
+	// KindKeystoneID matches a Keystone ID of a Panel User or an ID of a Service User,
+	// which is its Keystone ID as well.
+	KindKeystoneID Kind = "keystone ID"
+
+	// KindServiceUserName matches a name of a Service User.
+	KindServiceUserName Kind = "service user name"
+
+	// KindExternalID matches an external ID of a federated Panel User.
+	KindExternalID Kind = "external ID"
+)
+
+// Ref represents a reference to a member of a Group: a Panel User or a Service User.
+type Ref struct {
+	Kind  Kind
+	Value string
+
+	// FederationID limits a reference of KindExternalID to Users of the Federation. It is optional.
+	FederationID iamid.FederationID
+}
+
+// Any returns a reference, which matches a principal by any kind of reference.
+func Any(value string) Ref {
+	return Ref{Kind: KindAny, Value: value}
+}
+
+// UserID returns a reference to a Panel User or a Service User with the given ID.
+func UserID(id iamid.UserID) Ref {
+	return Ref{Kind: KindUserID, Value: string(id)}
+}
+
+// KeystoneID returns a reference to a Panel User or a Service User with the given Keystone ID.
+func KeystoneID(id iamid.KeystoneID) Ref {
+	return Ref{Kind: KindKeystoneID, Value: string(id)}
+}
+
+// ServiceUserName returns a reference to a Service User with the given name.
+func ServiceUserName(name string) Ref {
+	return Ref{Kind: KindServiceUserName, Value: name}
+}
+
+// ExternalID returns a reference to a federated Panel User with the given external ID.
+// federationID may be empty, if external IDs are unique across Federations of the account.
+func ExternalID(federationID iamid.FederationID, externalID string) Ref {
+	return Ref{Kind: KindExternalID, Value: externalID, FederationID: federationID}
+}
+
+// String returns a description of the reference, e.g. `service user name "backup"`.
+func (r Ref) String() string {
+	if r.FederationID != "" {
+		return fmt.Sprintf("%s %q of federation %q", r.Kind, r.Value, r.FederationID)
+	}
+	return fmt.Sprintf("%s %q", r.Kind, r.Value)
+}
+
+// index maps references to Keystone IDs of matching principals.
+type index map[Ref][]iamid.KeystoneID
+
+// add makes the reference match the principal with keystoneID. It also adds the reference of KindAny.
+func (idx index) add(ref Ref, keystoneID iamid.KeystoneID) {
+	if ref.Value == "" || keystoneID == "" {
+		return
+	}
+
+	for _, key := range []Ref{ref, Any(ref.Value)} {
+		if !slices.Contains(idx[key], keystoneID) {
+			idx[key] = append(idx[key], keystoneID)
+		}
+	}
+}
+
+// resolve returns Keystone IDs of principals matching refs. Each reference has to match exactly one principal.
+func (idx index) resolve(refs []Ref) ([]iamid.KeystoneID, error) {
+	keystoneIDs := make([]iamid.KeystoneID, 0, len(refs))
+	for _, ref := range refs {
+		matches := idx[ref]
+		switch len(matches) {
+		case 0:
+			return nil, iamerrors.Error{
+				Err:  iamerrors.ErrPrincipalNotFound,
+				Desc: fmt.Sprintf("No User or Service User matches %s.", ref),
+			}
+		case 1:
+			keystoneIDs = append(keystoneIDs, matches[0])
+		default:
+			return nil, iamerrors.Error{
+				Err:  iamerrors.ErrPrincipalAmbiguous,
+				Desc: fmt.Sprintf("Several principals match %s: %s.", ref, strings.Join(iamid.Strings(matches), ", ")),
+			}
+		}
+	}
+	return keystoneIDs, nil
+}
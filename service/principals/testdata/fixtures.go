@@ -0,0 +1,59 @@
+package testdata
+
+const (
+	TestToken = "test-token"
+	TestURL   = "http://example.org/"
+)
+
+const TestListUsersResponse = `{
+	"users": [
+		{
+			"auth_type": "local",
+			"id": "999000_11111",
+			"keystone_id": "1a2b3c",
+			"roles": []
+		},
+		{
+			"auth_type": "federated",
+			"federation": {
+				"id": "fed1",
+				"external_id": "alice"
+			},
+			"id": "999000_22222",
+			"keystone_id": "4d5e6f",
+			"roles": []
+		},
+		{
+			"auth_type": "federated",
+			"federation": {
+				"id": "fed2",
+				"external_id": "alice"
+			},
+			"id": "999000_33333",
+			"keystone_id": "7a8b9c",
+			"roles": []
+		}
+	]
+}`
+
+const TestListServiceUsersResponse = `{
+	"users": [
+		{
+			"name": "backup",
+			"enabled": true,
+			"id": "0f1e2d",
+			"roles": []
+		},
+		{
+			"name": "1a2b3c",
+			"enabled": true,
+			"id": "3c4b5a",
+			"roles": []
+		}
+	]
+}`
+
+const TestDoRequestErr = `{
+	"code": "REQUEST_FORBIDDEN",
+	"message": "You don't have permission to do this"
+}`
@@ -0,0 +1,24 @@
+package roles
+
+import "github.com/selectel/iam-go/v2/internal/diff"
+
+// Diff compares the current roles with the desired ones. Roles are compared as values,
+// by role name, scope and project. It returns the roles which are missing from current
+// and the roles which are present in current but not in desired. Duplicates are ignored.
+func Diff(current, desired []Role) (missing, extra []Role) {
+	return diff.Diff(current, desired)
+}
+
+// Change represents roles assigned and unassigned by SetRoles methods.
+type Change struct {
+	// Assigned contains roles which were missing and were assigned.
+	Assigned []Role
+
+	// Unassigned contains roles which were not desired and were unassigned.
+	Unassigned []Role
+}
+
+// Changed reports whether any role was assigned or unassigned.
+func (c Change) Changed() bool {
+	return len(c.Assigned) > 0 || len(c.Unassigned) > 0
+}
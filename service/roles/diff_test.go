@@ -0,0 +1,55 @@
+package roles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	member := Role{Scope: "account", RoleName: "member"}
+	reader := Role{Scope: "account", RoleName: "reader"}
+	projectMember := Role{Scope: "project", RoleName: "member", ProjectID: "123"}
+
+	tests := []struct {
+		name            string
+		current         []Role
+		desired         []Role
+		expectedMissing []Role
+		expectedExtra   []Role
+	}{
+		{
+			name:    "Test Diff without changes",
+			current: []Role{member, projectMember},
+			desired: []Role{projectMember, member},
+		},
+		{
+			name:            "Test Diff with missing and extra roles",
+			current:         []Role{member, reader},
+			desired:         []Role{member, projectMember},
+			expectedMissing: []Role{projectMember},
+			expectedExtra:   []Role{reader},
+		},
+		{
+			name:            "Test Diff compares projects",
+			current:         []Role{{Scope: "project", RoleName: "member", ProjectID: "456"}},
+			desired:         []Role{projectMember},
+			expectedMissing: []Role{projectMember},
+			expectedExtra:   []Role{{Scope: "project", RoleName: "member", ProjectID: "456"}},
+		},
+		{
+			name:            "Test Diff ignores duplicates",
+			desired:         []Role{member, member},
+			expectedMissing: []Role{member},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing, extra := Diff(tt.current, tt.desired)
+
+			assert.Equal(t, tt.expectedMissing, missing)
+			assert.Equal(t, tt.expectedExtra, extra)
+		})
+	}
+}
@@ -2,14 +2,20 @@ package roles
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/url"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/internal/client"
 )
 
-const apiVersion = "iam/v1"
+const (
+	apiVersion  = "iam/v1"
+	serviceName = "roles"
+)
 
 // Service is used to communicate with the Roles API.
 type Service struct {
@@ -24,26 +30,42 @@ func New(baseClient *client.BaseClient) *Service {
 }
 
 // List returns a list of roles available for assignment.
-func (s *Service) List(ctx context.Context) (*ListResponse, error) {
-	path, err := url.JoinPath(apiVersion, "roles")
+func (s *Service) List(ctx context.Context, opts ...iamrequest.Option) (*ListResponse, error) {
+	var roles ListResponse
+	err := s.list(ctx, client.DecodeJSON(&roles), opts)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		return nil, err
 	}
+	return &roles, nil
+}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodGet,
-		Path:   path,
-	})
+// ForEach calls fn for every role available for assignment without loading the whole list into memory.
+// If fn returns an error, ForEach stops and returns it.
+func (s *Service) ForEach(ctx context.Context, fn func(AvailableRole) error, opts ...iamrequest.Option) error {
+	return s.list(ctx, client.ForEachJSON("roles", fn), opts)
+}
+
+func (s *Service) list(ctx context.Context, decode func(io.Reader) error, opts []iamrequest.Option) error {
+	path, err := url.JoinPath(apiVersion, "roles")
 	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
+		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	var roles ListResponse
-	err = client.UnmarshalJSON(response, &roles)
+	err = s.baseClient.DoRequestDecode(ctx, client.DoRequestInput{
+		Body:    nil,
+		Method:  http.MethodGet,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "List",
+			PathTemplate: "iam/v1/roles",
+		},
+	}, decode)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestDecode already wraps the error.
+		return err
 	}
-	return &roles, nil
+
+	return nil
 }
@@ -9,9 +9,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
-	"github.com/selectel/iam-go/service/roles/testdata"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/roles/testdata"
 )
 
 const rolesURL = "iam/v1/roles"
@@ -83,3 +83,75 @@ func TestList(t *testing.T) {
 		})
 	}
 }
+
+func TestForEach(t *testing.T) {
+	tests := []struct {
+		name          string
+		prepare       func()
+		expectedItems []AvailableRole
+		expectedError error
+	}{
+		{
+			name: "return roles list",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+rolesURL, func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusOK, testdata.TestListRolesResponse)
+						return resp, nil
+					})
+			},
+			expectedItems: []AvailableRole{
+				{
+					AvailableInOnboarding: true,
+					Category:              "general",
+					Description:           "Test role",
+					ID:                    "role-id",
+					Scopes:                []string{"account"},
+					SubjectTypes:          []string{"user"},
+					Deprecated:            false,
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "return error",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+rolesURL, func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusForbidden, testdata.TestDoRequestErr)
+						return resp, nil
+					})
+			},
+			expectedItems: nil,
+			expectedError: iamerrors.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			rolesAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(rolesAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			tt.prepare()
+
+			ctx := context.Background()
+			var actual []AvailableRole
+			err := rolesAPI.ForEach(ctx, func(item AvailableRole) error {
+				actual = append(actual, item)
+				return nil
+			})
+
+			require.ErrorIs(err, tt.expectedError)
+			assert.Equal(tt.expectedItems, actual)
+		})
+	}
+}
@@ -1,10 +1,12 @@
 package roles
 
+import "github.com/selectel/iam-go/v2/iamid"
+
 // Role represents a scope/role pair used when managing assignments for users, groups and service users.
 type Role struct {
-	ProjectID string `json:"project_id,omitempty"`
-	RoleName  string `json:"role_name"`
-	Scope     string `json:"scope"`
+	ProjectID iamid.ProjectID `json:"project_id,omitempty"`
+	RoleName  string          `json:"role_name"`
+	Scope     string          `json:"scope"`
 }
 
 // AvailableRole describes a role that can be assigned via IAM.
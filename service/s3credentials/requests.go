@@ -4,14 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/url"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/internal/client"
 )
 
-const apiVersion = "iam/v1"
+const (
+	apiVersion  = "iam/v1"
+	serviceName = "s3credentials"
+)
 
 // Service is used to communicate with the S3 Credentials API.
 type Service struct {
@@ -26,36 +33,59 @@ func New(baseClient *client.BaseClient) *Service {
 }
 
 // List returns a list of S3 Credentials for the given user.
-func (s *Service) List(ctx context.Context, userID string) (*ListResponse, error) {
-	if userID == "" {
-		return nil, iamerrors.Error{Err: iamerrors.ErrUserIDRequired, Desc: "No userID was provided."}
+func (s *Service) List(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) (*ListResponse, error) {
+	var credentials ListResponse
+	err := s.list(ctx, userID, client.DecodeJSON(&credentials), opts)
+	if err != nil {
+		return nil, err
 	}
+	return &credentials, nil
+}
 
-	path, err := url.JoinPath(apiVersion, "service_users", userID, "credentials")
-	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+// ForEach calls fn for every S3 Credential of the given user without loading the whole list into memory.
+// If fn returns an error, ForEach stops and returns it.
+func (s *Service) ForEach(
+	ctx context.Context, userID iamid.UserID, fn func(Credential) error, opts ...iamrequest.Option,
+) error {
+	return s.list(ctx, userID, client.ForEachJSON("credentials", fn), opts)
+}
+
+func (s *Service) list(
+	ctx context.Context, userID iamid.UserID, decode func(io.Reader) error, opts []iamrequest.Option,
+) error {
+	if userID == "" {
+		return iamerrors.Error{Err: iamerrors.ErrUserIDRequired, Desc: "No userID was provided."}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodGet,
-		Path:   path,
-	})
+	path, err := url.JoinPath(apiVersion, "service_users", string(userID), "credentials")
 	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
+		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	var credentials ListResponse
-	err = client.UnmarshalJSON(response, &credentials)
+	err = s.baseClient.DoRequestDecode(ctx, client.DoRequestInput{
+		Body:    nil,
+		Method:  http.MethodGet,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "List",
+			ResourceIDs:  map[string]string{"user_id": string(userID)},
+			PathTemplate: "iam/v1/service_users/{user_id}/credentials",
+		},
+	}, decode)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestDecode already wraps the error.
+		return err
 	}
-	return &credentials, nil
+
+	return nil
 }
 
 // Create creates a new S3 Credentials for the given user.
-func (s *Service) Create(ctx context.Context, userID, name, projectID string) (*CreateResponse, error) {
+func (s *Service) Create(
+	ctx context.Context, userID iamid.UserID, name string, projectID iamid.ProjectID, opts ...iamrequest.Option,
+) (*CreateResponse, error) {
 	if userID == "" {
 		return nil, iamerrors.Error{Err: iamerrors.ErrUserIDRequired, Desc: "No userID was provided."}
 	}
@@ -66,7 +96,7 @@ func (s *Service) Create(ctx context.Context, userID, name, projectID string) (*
 		return nil, iamerrors.Error{Err: iamerrors.ErrProjectIDRequired, Desc: "No projectID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "service_users", userID, "credentials")
+	path, err := url.JoinPath(apiVersion, "service_users", string(userID), "credentials")
 	if err != nil {
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
@@ -76,26 +106,30 @@ func (s *Service) Create(ctx context.Context, userID, name, projectID string) (*
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: http.MethodPost,
-		Path:   path,
-	})
-	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
-	}
-
 	var createdCredential CreateResponse
-	err = client.UnmarshalJSON(response, &createdCredential)
+	err = s.baseClient.DoRequestJSON(ctx, client.DoRequestInput{
+		Body:    bytes.NewReader(body),
+		Method:  http.MethodPost,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Create",
+			ResourceIDs:  map[string]string{"user_id": string(userID)},
+			PathTemplate: "iam/v1/service_users/{user_id}/credentials",
+		},
+	}, &createdCredential)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestJSON already wraps the error.
+		return nil, err
 	}
 	return &createdCredential, nil
 }
 
 // Delete deletes an S3 Credentials for the given user.
-func (s *Service) Delete(ctx context.Context, userID, accessKey string) error {
+func (s *Service) Delete(
+	ctx context.Context, userID iamid.UserID, accessKey iamid.AccessKey, opts ...iamrequest.Option,
+) error {
 	if userID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrUserIDRequired, Desc: "No userID was provided."}
 	}
@@ -103,14 +137,21 @@ func (s *Service) Delete(ctx context.Context, userID, accessKey string) error {
 		return iamerrors.Error{Err: iamerrors.ErrCredentialAccessKeyRequired, Desc: "No accessKey was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "service_users", userID, "credentials", accessKey)
+	path, err := url.JoinPath(apiVersion, "service_users", string(userID), "credentials", string(accessKey))
 	if err != nil {
 		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodDelete,
-		Path:   path,
+		Body:    nil,
+		Method:  http.MethodDelete,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Delete",
+			ResourceIDs:  map[string]string{"user_id": string(userID), "access_key": string(accessKey)},
+			PathTemplate: "iam/v1/service_users/{user_id}/credentials/{access_key}",
+		},
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -9,9 +9,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
-	"github.com/selectel/iam-go/service/s3credentials/testdata"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/s3credentials/testdata"
 )
 
 const (
@@ -21,7 +22,7 @@ const (
 
 func TestList(t *testing.T) {
 	type args struct {
-		userID string
+		userID iamid.UserID
 	}
 	tests := []struct {
 		name             string
@@ -94,11 +95,90 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestForEach(t *testing.T) {
+	type args struct {
+		userID iamid.UserID
+	}
+	tests := []struct {
+		name          string
+		args          args
+		prepare       func()
+		expectedItems []Credential
+		expectedError error
+	}{
+		{
+			name: "Test ForEach return output",
+			args: args{
+				userID: "1",
+			},
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+credentialsURL, func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusOK, testdata.TestGetCredentialsResponse)
+						return resp, nil
+					})
+			},
+			expectedItems: []Credential{
+				{
+					Name:      "12345",
+					ProjectID: "test-project",
+					AccessKey: "test-access-key",
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Test ForEach return error",
+			args: args{
+				userID: "1",
+			},
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+credentialsURL, func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusForbidden, testdata.TestDoRequestErr)
+						return resp, nil
+					})
+			},
+			expectedItems: nil,
+			expectedError: iamerrors.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			s3CredAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(s3CredAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			tt.prepare()
+
+			ctx := context.Background()
+			var actualResponse []Credential
+			err := s3CredAPI.ForEach(ctx, tt.args.userID, func(item Credential) error {
+				actualResponse = append(actualResponse, item)
+				return nil
+			})
+
+			require.ErrorIs(err, tt.expectedError)
+
+			assert.Equal(tt.expectedItems, actualResponse)
+		})
+	}
+}
+
 func TestCreate(t *testing.T) {
 	type args struct {
-		userID    string
+		userID    iamid.UserID
 		name      string
-		projectID string
+		projectID iamid.ProjectID
 	}
 	tests := []struct {
 		name             string
@@ -178,8 +258,8 @@ func TestCreate(t *testing.T) {
 
 func TestDelete(t *testing.T) {
 	type args struct {
-		userID    string
-		accessKey string
+		userID    iamid.UserID
+		accessKey iamid.AccessKey
 	}
 	tests := []struct {
 		name          string
@@ -1,5 +1,12 @@
 package s3credentials
 
+import (
+	"log/slog"
+
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/internal/client"
+)
+
 // CreateResponse represents a S3 Credentials for the given user.
 // It contains "secret_key" field, which appears only once and only after creating.
 type CreateResponse struct {
@@ -7,11 +14,21 @@ type CreateResponse struct {
 	SecretKey string `json:"secret_key"`
 }
 
+// LogValue implements slog.LogValuer and redacts SecretKey.
+func (r CreateResponse) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("name", r.Name),
+		slog.String("project_id", string(r.ProjectID)),
+		slog.String("access_key", string(r.AccessKey)),
+		slog.String("secret_key", client.Redacted),
+	)
+}
+
 // Credential represents basic information about a Credential.
 type Credential struct {
-	Name      string `json:"name"`
-	ProjectID string `json:"project_id"`
-	AccessKey string `json:"access_key"`
+	Name      string          `json:"name"`
+	ProjectID iamid.ProjectID `json:"project_id"`
+	AccessKey iamid.AccessKey `json:"access_key"`
 }
 
 // ListResponse represents an S3 Credentials for the given user.
@@ -20,6 +37,6 @@ type ListResponse struct {
 }
 
 type createRequest struct {
-	Name      string `json:"name,omitempty"`
-	ProjectID string `json:"project_id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	ProjectID iamid.ProjectID `json:"project_id,omitempty"`
 }
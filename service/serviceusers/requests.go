@@ -4,15 +4,22 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/url"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
-	"github.com/selectel/iam-go/service/roles"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/roles"
 )
 
-const apiVersion = "iam/v1"
+const (
+	apiVersion  = "iam/v1"
+	serviceName = "serviceusers"
+)
 
 // Service is used to communicate with the Service Users API.
 type Service struct {
@@ -27,61 +34,79 @@ func New(baseClient *client.BaseClient) *Service {
 }
 
 // List returns a list of Service Users for the account.
-func (s *Service) List(ctx context.Context) (*ListResponse, error) {
-	path, err := url.JoinPath(apiVersion, "service_users")
+func (s *Service) List(ctx context.Context, opts ...iamrequest.Option) (*ListResponse, error) {
+	var users ListResponse
+	err := s.list(ctx, client.DecodeJSON(&users), opts)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		return nil, err
 	}
+	return &users, nil
+}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodGet,
-		Path:   path,
-	})
+// ForEach calls fn for every Service User of the account without loading the whole list into memory.
+// If fn returns an error, ForEach stops and returns it.
+func (s *Service) ForEach(ctx context.Context, fn func(ServiceUser) error, opts ...iamrequest.Option) error {
+	return s.list(ctx, client.ForEachJSON("users", fn), opts)
+}
+
+func (s *Service) list(ctx context.Context, decode func(io.Reader) error, opts []iamrequest.Option) error {
+	path, err := url.JoinPath(apiVersion, "service_users")
 	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
+		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	var users ListResponse
-	err = client.UnmarshalJSON(response, &users)
+	err = s.baseClient.DoRequestDecode(ctx, client.DoRequestInput{
+		Body:    nil,
+		Method:  http.MethodGet,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "List",
+			PathTemplate: "iam/v1/service_users",
+		},
+	}, decode)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestDecode already wraps the error.
+		return err
 	}
-	return &users, nil
+
+	return nil
 }
 
 // Get returns an info of Service User with a userID.
-func (s *Service) Get(ctx context.Context, userID string) (*GetResponse, error) {
+func (s *Service) Get(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) (*GetResponse, error) {
 	if userID == "" {
 		return nil, iamerrors.Error{Err: iamerrors.ErrUserIDRequired, Desc: "No userID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "service_users", userID)
+	path, err := url.JoinPath(apiVersion, "service_users", string(userID))
 	if err != nil {
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodGet,
-		Path:   path,
-	})
-	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
-	}
-
 	var user GetResponse
-	err = client.UnmarshalJSON(response, &user)
+	err = s.baseClient.DoRequestJSON(ctx, client.DoRequestInput{
+		Body:    nil,
+		Method:  http.MethodGet,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Get",
+			ResourceIDs:  map[string]string{"user_id": string(userID)},
+			PathTemplate: "iam/v1/service_users/{user_id}",
+		},
+	}, &user)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestJSON already wraps the error.
+		return nil, err
 	}
 	return &user, nil
 }
 
 // Create creates a new Service User.
-func (s *Service) Create(ctx context.Context, input CreateRequest) (*CreateResponse, error) {
+func (s *Service) Create(ctx context.Context, input CreateRequest, opts ...iamrequest.Option) (*CreateResponse, error) {
 	if input.Name == "" {
 		return nil, iamerrors.Error{
 			Err: iamerrors.ErrServiceUserNameRequired, Desc: "No name for Service User was provided.",
@@ -108,39 +133,47 @@ func (s *Service) Create(ctx context.Context, input CreateRequest) (*CreateRespo
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: http.MethodPost,
-		Path:   path,
-	})
-	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
-	}
-
 	var createdUser CreateResponse
-	err = client.UnmarshalJSON(response, &createdUser)
+	err = s.baseClient.DoRequestJSON(ctx, client.DoRequestInput{
+		Body:    bytes.NewReader(body),
+		Method:  http.MethodPost,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Create",
+			PathTemplate: "iam/v1/service_users",
+		},
+	}, &createdUser)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestJSON already wraps the error.
+		return nil, err
 	}
 	return &createdUser, nil
 }
 
 // Delete deletes a Service User from the account.
-func (s *Service) Delete(ctx context.Context, userID string) error {
+func (s *Service) Delete(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) error {
 	if userID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrUserIDRequired, Desc: "No userID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "service_users", userID)
+	path, err := url.JoinPath(apiVersion, "service_users", string(userID))
 	if err != nil {
 		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodDelete,
-		Path:   path,
+		Body:    nil,
+		Method:  http.MethodDelete,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Delete",
+			ResourceIDs:  map[string]string{"user_id": string(userID)},
+			PathTemplate: "iam/v1/service_users/{user_id}",
+		},
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -151,45 +184,101 @@ func (s *Service) Delete(ctx context.Context, userID string) error {
 }
 
 // Update updates the info for a Service User with the given userID.
-func (s *Service) Update(ctx context.Context, userID string, input UpdateRequest) (*UpdateResponse, error) {
+//
+// Update always sends Enabled, so a Service User is disabled if Enabled isn't set.
+// Use Patch to change only some fields.
+func (s *Service) Update(
+	ctx context.Context, userID iamid.UserID, input UpdateRequest, opts ...iamrequest.Option,
+) (*UpdateResponse, error) {
+	return s.update(ctx, "Update", userID, &updateRequest{
+		Enabled:  &input.Enabled,
+		Name:     input.Name,
+		Password: input.Password,
+	}, opts)
+}
+
+// Patch updates only the fields of a Service User with the given userID which are set in the input.
+func (s *Service) Patch(
+	ctx context.Context, userID iamid.UserID, input PatchRequest, opts ...iamrequest.Option,
+) (*UpdateResponse, error) {
+	return s.update(ctx, "Patch", userID, input, opts)
+}
+
+// Modify fetches a Service User with the given userID, applies mutate to it and sends the changed fields with Patch.
+// Only Enabled and Name are compared, so changes of other fields are ignored.
+// If mutate changes nothing, no update request is sent and the current Service User is returned.
+// An error returned by mutate is returned as is.
+func (s *Service) Modify(
+	ctx context.Context, userID iamid.UserID, mutate func(*ServiceUser) error, opts ...iamrequest.Option,
+) (*UpdateResponse, error) {
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	current, err := s.Get(ctx, userID, calls.Read()...)
+	if err != nil {
+		return nil, err
+	}
+
+	modified := current.ServiceUser
+	if err := mutate(&modified); err != nil {
+		return nil, err
+	}
+
+	var patch PatchRequest
+	if modified.Enabled != current.Enabled {
+		patch.Enabled = &modified.Enabled
+	}
+	if modified.Name != current.Name {
+		patch.Name = &modified.Name
+	}
+	if patch == (PatchRequest{}) {
+		return &UpdateResponse{ServiceUser: current.ServiceUser, Groups: current.Groups}, nil
+	}
+
+	return s.Patch(ctx, userID, patch, calls.Write("patch")...)
+}
+
+func (s *Service) update(
+	ctx context.Context, operation string, userID iamid.UserID, input interface{}, opts []iamrequest.Option,
+) (*UpdateResponse, error) {
 	if userID == "" {
 		return nil, iamerrors.Error{Err: iamerrors.ErrUserIDRequired, Desc: "No userID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "service_users", userID)
+	path, err := url.JoinPath(apiVersion, "service_users", string(userID))
 	if err != nil {
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	body, err := json.Marshal(&updateRequest{
-		Enabled:  &input.Enabled,
-		Name:     input.Name,
-		Password: input.Password,
-	})
+	body, err := json.Marshal(input)
 	if err != nil {
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: http.MethodPatch,
-		Path:   path,
-	})
-	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
-	}
-
 	var updatedUser UpdateResponse
-	err = client.UnmarshalJSON(response, &updatedUser)
+	err = s.baseClient.DoRequestJSON(ctx, client.DoRequestInput{
+		Body:    bytes.NewReader(body),
+		Method:  http.MethodPatch,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         operation,
+			ResourceIDs:  map[string]string{"user_id": string(userID)},
+			PathTemplate: "iam/v1/service_users/{user_id}",
+		},
+	}, &updatedUser)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestJSON already wraps the error.
+		return nil, err
 	}
 	return &updatedUser, nil
 }
 
 // AssignRoles adds new roles for a Service User with the given userID.
-func (s *Service) AssignRoles(ctx context.Context, userID string, roles []roles.Role) error {
+func (s *Service) AssignRoles(
+	ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option,
+) error {
 	if userID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrUserIDRequired, Desc: "No userID was provided."}
 	}
@@ -200,11 +289,13 @@ func (s *Service) AssignRoles(ctx context.Context, userID string, roles []roles.
 		}
 	}
 
-	return s.manageRoles(ctx, http.MethodPut, userID, roles)
+	return s.manageRoles(ctx, "AssignRoles", http.MethodPut, userID, roles, opts)
 }
 
 // UnassignRoles removes roles from a Service User with the given userID.
-func (s *Service) UnassignRoles(ctx context.Context, userID string, roles []roles.Role) error {
+func (s *Service) UnassignRoles(
+	ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option,
+) error {
 	if userID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrUserIDRequired, Desc: "No userID was provided."}
 	}
@@ -215,11 +306,45 @@ func (s *Service) UnassignRoles(ctx context.Context, userID string, roles []role
 		}
 	}
 
-	return s.manageRoles(ctx, http.MethodDelete, userID, roles)
+	return s.manageRoles(ctx, "UnassignRoles", http.MethodDelete, userID, roles, opts)
 }
 
-func (s *Service) manageRoles(ctx context.Context, method string, userID string, roles []roles.Role) error {
-	path, err := url.JoinPath(apiVersion, "service_users", userID, "roles")
+// SetRoles makes the Service User with the given userID have exactly the given roles. It fetches the current roles,
+// assigns the missing ones first and then unassigns the extra ones. Roles are compared by role name, scope and project.
+// If assigning or unassigning fails, the error is returned together with the Change holding the roles
+// which were already assigned.
+func (s *Service) SetRoles(
+	ctx context.Context, userID iamid.UserID, desired []roles.Role, opts ...iamrequest.Option,
+) (*roles.Change, error) {
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	current, err := s.Get(ctx, userID, calls.Read()...)
+	if err != nil {
+		return nil, err
+	}
+
+	var change roles.Change
+	assigned, unassigned := roles.Diff(current.Roles, desired)
+	if len(assigned) > 0 {
+		if err := s.AssignRoles(ctx, userID, assigned, calls.Write("assign")...); err != nil {
+			return &change, err
+		}
+		change.Assigned = assigned
+	}
+	if len(unassigned) > 0 {
+		if err := s.UnassignRoles(ctx, userID, unassigned, calls.Write("unassign")...); err != nil {
+			return &change, err
+		}
+		change.Unassigned = unassigned
+	}
+	return &change, nil
+}
+
+func (s *Service) manageRoles(
+	ctx context.Context, operation, method string, userID iamid.UserID, roles []roles.Role, opts []iamrequest.Option,
+) error {
+	path, err := url.JoinPath(apiVersion, "service_users", string(userID), "roles")
 	if err != nil {
 		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
@@ -231,9 +356,16 @@ func (s *Service) manageRoles(ctx context.Context, method string, userID string,
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: method,
-		Path:   path,
+		Body:    bytes.NewReader(body),
+		Method:  method,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         operation,
+			ResourceIDs:  map[string]string{"user_id": string(userID)},
+			PathTemplate: "iam/v1/service_users/{user_id}/roles",
+		},
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -242,3 +374,65 @@ func (s *Service) manageRoles(ctx context.Context, method string, userID string,
 
 	return nil
 }
+
+// Ensure makes sure that a Service User with the given name exists, is enabled or disabled according to the input,
+// if Enabled is set, and has all the given roles. It creates a missing Service User, updates Enabled
+// of an existing one and assigns the missing roles to it. Password and GroupIDs are used only to create
+// a Service User, and roles which are not in the input are not unassigned.
+func (s *Service) Ensure(ctx context.Context, input EnsureRequest, opts ...iamrequest.Option) (*EnsureResponse, error) {
+	if input.Name == "" {
+		return nil, iamerrors.Error{
+			Err: iamerrors.ErrServiceUserNameRequired, Desc: "No name for Service User was provided.",
+		}
+	}
+
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	current, err := s.List(ctx, calls.Read()...)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range current.Users {
+		if user.Name == input.Name {
+			return s.ensureExisting(ctx, user, input, calls)
+		}
+	}
+
+	created, err := s.Create(ctx, CreateRequest{
+		Enabled:  input.Enabled != nil && *input.Enabled,
+		Name:     input.Name,
+		Password: input.Password,
+		GroupIDs: input.GroupIDs,
+		Roles:    input.Roles,
+	}, calls.Write("create")...)
+	if err != nil {
+		return nil, err
+	}
+	return &EnsureResponse{ServiceUser: created.ServiceUser, Created: true}, nil
+}
+
+func (s *Service) ensureExisting(
+	ctx context.Context, user ServiceUser, input EnsureRequest, calls client.Composite,
+) (*EnsureResponse, error) {
+	response := EnsureResponse{ServiceUser: user}
+
+	if input.Enabled != nil && user.Enabled != *input.Enabled {
+		if _, err := s.Patch(ctx, user.ID, PatchRequest{Enabled: input.Enabled}, calls.Write("patch")...); err != nil {
+			return nil, err
+		}
+		response.Enabled = *input.Enabled
+		response.Updated = true
+	}
+
+	missing, _ := roles.Diff(user.Roles, input.Roles)
+	if len(missing) > 0 {
+		if err := s.AssignRoles(ctx, user.ID, missing, calls.Write("assign")...); err != nil {
+			return nil, err
+		}
+		response.Roles = append(append([]roles.Role{}, user.Roles...), missing...)
+		response.Updated = true
+	}
+
+	return &response, nil
+}
@@ -2,6 +2,8 @@ package serviceusers
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"testing"
 
@@ -9,10 +11,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
-	"github.com/selectel/iam-go/service/roles"
-	"github.com/selectel/iam-go/service/serviceusers/testdata"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/serviceusers/testdata"
 )
 
 const (
@@ -95,9 +98,81 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestForEach(t *testing.T) {
+	tests := []struct {
+		name          string
+		prepare       func()
+		expectedItems []ServiceUser
+		expectedError error
+	}{
+		{
+			name: "ok",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+serviceUsersURL, func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusOK, testdata.TestListUsersResponse)
+						return resp, nil
+					})
+			},
+			expectedItems: []ServiceUser{
+				{
+					Name:    "test",
+					Enabled: true,
+					ID:      "123",
+					Roles: []roles.Role{
+						{Scope: AccountScope, RoleName: Member},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "error",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+serviceUsersURL, func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusForbidden, testdata.TestDoRequestErr)
+						return resp, nil
+					})
+			},
+			expectedItems: nil,
+			expectedError: iamerrors.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			serviceUsersAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(serviceUsersAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			tt.prepare()
+
+			ctx := context.Background()
+			var actual []ServiceUser
+			err := serviceUsersAPI.ForEach(ctx, func(item ServiceUser) error {
+				actual = append(actual, item)
+				return nil
+			})
+
+			require.ErrorIs(err, tt.expectedError)
+
+			assert.Equal(tt.expectedItems, actual)
+		})
+	}
+}
+
 func TestGet(t *testing.T) {
 	type args struct {
-		userID string
+		userID iamid.UserID
 	}
 	tests := []struct {
 		name             string
@@ -178,7 +253,7 @@ func TestGet(t *testing.T) {
 
 func TestDelete(t *testing.T) {
 	type args struct {
-		userID string
+		userID iamid.UserID
 	}
 	tests := []struct {
 		name          string
@@ -361,7 +436,7 @@ func TestCreate(t *testing.T) {
 
 func TestUpdate(t *testing.T) {
 	type args struct {
-		userID   string
+		userID   iamid.UserID
 		enabled  bool
 		name     string
 		password string
@@ -447,9 +522,159 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestPatch(t *testing.T) {
+	name := "test1"
+	enabled := false
+
+	tests := []struct {
+		name         string
+		input        PatchRequest
+		expectedBody string
+	}{
+		{
+			name:         "Test Patch sends only name",
+			input:        PatchRequest{Name: &name},
+			expectedBody: `{"name":"test1"}`,
+		},
+		{
+			name:         "Test Patch sends false enabled",
+			input:        PatchRequest{Enabled: &enabled},
+			expectedBody: `{"enabled":false}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			serviceUsersAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(serviceUsersAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			var body []byte
+			httpmock.RegisterResponder(
+				http.MethodPatch, testdata.TestURL+serviceUsersIDURL,
+				func(r *http.Request) (*http.Response, error) {
+					body, _ = io.ReadAll(r.Body)
+					return httpmock.NewStringResponse(http.StatusOK, testdata.TestUpdateUserResponse), nil
+				})
+
+			_, err := serviceUsersAPI.Patch(context.Background(), "123", tt.input)
+
+			require.NoError(err)
+			assert.JSONEq(tt.expectedBody, string(body))
+		})
+	}
+}
+
+//nolint:funlen // This is a test function.
+func TestModify(t *testing.T) {
+	errMutate := errors.New("mutate failed")
+
+	tests := []struct {
+		name             string
+		mutate           func(*ServiceUser) error
+		getStatus        int
+		expectedBody     string
+		expectedResponse *UpdateResponse
+		expectedError    error
+	}{
+		{
+			name: "Test Modify sends changed fields",
+			mutate: func(user *ServiceUser) error {
+				user.Name = "test1"
+				return nil
+			},
+			getStatus:    http.StatusOK,
+			expectedBody: `{"name":"test1"}`,
+			expectedResponse: &UpdateResponse{
+				ServiceUser: ServiceUser{Name: "test1", Enabled: true, ID: "123"},
+			},
+		},
+		{
+			name: "Test Modify without changes",
+			mutate: func(user *ServiceUser) error {
+				user.Enabled = true
+				return nil
+			},
+			getStatus: http.StatusOK,
+			expectedResponse: &UpdateResponse{
+				ServiceUser: ServiceUser{
+					Name:    "test",
+					Enabled: true,
+					ID:      "123",
+					Roles:   []roles.Role{{Scope: AccountScope, RoleName: Member}},
+				},
+				Groups: []Group{{ID: "96a60e7b9e9e48308eed46269f9a147b", Name: "123", Roles: []roles.Role{}}},
+			},
+		},
+		{
+			name: "Test Modify returns mutate error",
+			mutate: func(*ServiceUser) error {
+				return errMutate
+			},
+			getStatus:     http.StatusOK,
+			expectedError: errMutate,
+		},
+		{
+			name:          "Test Modify returns Get error",
+			mutate:        func(*ServiceUser) error { return nil },
+			getStatus:     http.StatusForbidden,
+			expectedError: iamerrors.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			serviceUsersAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(serviceUsersAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			getResponse := testdata.TestGetUserResponse
+			if tt.getStatus != http.StatusOK {
+				getResponse = testdata.TestDoRequestErr
+			}
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+serviceUsersIDURL,
+				httpmock.NewStringResponder(tt.getStatus, getResponse))
+
+			var body []byte
+			httpmock.RegisterResponder(
+				http.MethodPatch, testdata.TestURL+serviceUsersIDURL,
+				func(r *http.Request) (*http.Response, error) {
+					body, _ = io.ReadAll(r.Body)
+					return httpmock.NewStringResponse(http.StatusOK, testdata.TestUpdateUserResponse), nil
+				})
+
+			actual, err := serviceUsersAPI.Modify(context.Background(), "123", tt.mutate)
+
+			require.ErrorIs(err, tt.expectedError)
+			assert.Equal(tt.expectedResponse, actual)
+			if tt.expectedBody == "" {
+				assert.Nil(body)
+			} else {
+				assert.JSONEq(tt.expectedBody, string(body))
+			}
+		})
+	}
+}
+
 func TestAssignRoles(t *testing.T) {
 	type args struct {
-		userID string
+		userID iamid.UserID
 		roles  []roles.Role
 	}
 	tests := []struct {
@@ -521,7 +746,7 @@ func TestAssignRoles(t *testing.T) {
 
 func TestUnassignRoles(t *testing.T) {
 	type args struct {
-		userID string
+		userID iamid.UserID
 		roles  []roles.Role
 	}
 	tests := []struct {
@@ -589,3 +814,162 @@ func TestUnassignRoles(t *testing.T) {
 		})
 	}
 }
+
+//nolint:funlen // This is a test function.
+func TestEnsure(t *testing.T) {
+	member := roles.Role{Scope: AccountScope, RoleName: Member}
+	reader := roles.Role{Scope: AccountScope, RoleName: "reader"}
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name             string
+		input            EnsureRequest
+		expectedRequests []string
+		expectedCreated  bool
+		expectedUpdated  bool
+		expectedEnabled  bool
+	}{
+		{
+			name:            "Test Ensure finds existing Service User",
+			input:           EnsureRequest{Name: "test", Enabled: &enabled, Roles: []roles.Role{member}},
+			expectedEnabled: true,
+		},
+		{
+			name:            "Test Ensure keeps Enabled of existing Service User, if it isn't set",
+			input:           EnsureRequest{Name: "test", Roles: []roles.Role{member}},
+			expectedEnabled: true,
+		},
+		{
+			name:  "Test Ensure updates drifted Service User",
+			input: EnsureRequest{Name: "test", Enabled: &disabled, Roles: []roles.Role{member, reader}},
+			expectedRequests: []string{
+				`PATCH {"enabled":false}`,
+				`PUT {"roles":[{"role_name":"reader","scope":"account"}]}`,
+			},
+			expectedUpdated: true,
+		},
+		{
+			name:  "Test Ensure creates missing Service User",
+			input: EnsureRequest{Name: "new", Enabled: &enabled, Password: "password"},
+			expectedRequests: []string{
+				`POST {"enabled":true,"name":"new","password":"password"}`,
+			},
+			expectedCreated: true,
+			expectedEnabled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			serviceUsersAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(serviceUsersAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+serviceUsersURL,
+				httpmock.NewStringResponder(http.StatusOK, testdata.TestListUsersResponse))
+
+			var requests []string
+			responder := func(r *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(r.Body)
+				requests = append(requests, r.Method+" "+string(body))
+				return httpmock.NewStringResponse(http.StatusOK, testdata.TestCreateUserResponse), nil
+			}
+			httpmock.RegisterResponder(http.MethodPost, testdata.TestURL+serviceUsersURL, responder)
+			httpmock.RegisterResponder(http.MethodPatch, testdata.TestURL+serviceUsersIDURL, responder)
+			httpmock.RegisterResponder(http.MethodPut, testdata.TestURL+serviceUsersRolesURL, responder)
+
+			actual, err := serviceUsersAPI.Ensure(context.Background(), tt.input)
+
+			require.NoError(err)
+			assert.Equal(tt.expectedRequests, requests)
+			assert.Equal(tt.expectedCreated, actual.Created)
+			assert.Equal(tt.expectedUpdated, actual.Updated)
+			assert.Equal(tt.expectedCreated || tt.expectedUpdated, actual.Changed())
+			assert.Equal(tt.expectedEnabled, actual.Enabled)
+		})
+	}
+}
+
+//nolint:funlen // This is a test function.
+func TestSetRoles(t *testing.T) {
+	member := roles.Role{Scope: AccountScope, RoleName: Member}
+	reader := roles.Role{Scope: AccountScope, RoleName: "reader"}
+
+	tests := []struct {
+		name             string
+		desired          []roles.Role
+		getStatus        int
+		expectedRequests []string
+		expectedChange   *roles.Change
+		expectedError    error
+	}{
+		{
+			name:           "Test SetRoles without changes",
+			desired:        []roles.Role{member},
+			getStatus:      http.StatusOK,
+			expectedChange: &roles.Change{},
+		},
+		{
+			name:      "Test SetRoles assigns and unassigns roles",
+			desired:   []roles.Role{reader},
+			getStatus: http.StatusOK,
+			expectedRequests: []string{
+				`PUT {"roles":[{"role_name":"reader","scope":"account"}]}`,
+				`DELETE {"roles":[{"role_name":"member","scope":"account"}]}`,
+			},
+			expectedChange: &roles.Change{Assigned: []roles.Role{reader}, Unassigned: []roles.Role{member}},
+		},
+		{
+			name:          "Test SetRoles returns Get error",
+			desired:       []roles.Role{reader},
+			getStatus:     http.StatusForbidden,
+			expectedError: iamerrors.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			serviceUsersAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(serviceUsersAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			getResponse := testdata.TestGetUserResponse
+			if tt.getStatus != http.StatusOK {
+				getResponse = testdata.TestDoRequestErr
+			}
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+serviceUsersIDURL,
+				httpmock.NewStringResponder(tt.getStatus, getResponse))
+
+			var requests []string
+			responder := func(r *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(r.Body)
+				requests = append(requests, r.Method+" "+string(body))
+				return httpmock.NewStringResponse(http.StatusOK, ""), nil
+			}
+			httpmock.RegisterResponder(http.MethodPut, testdata.TestURL+serviceUsersRolesURL, responder)
+			httpmock.RegisterResponder(http.MethodDelete, testdata.TestURL+serviceUsersRolesURL, responder)
+
+			actual, err := serviceUsersAPI.SetRoles(context.Background(), "123", tt.desired)
+
+			require.ErrorIs(err, tt.expectedError)
+			assert.Equal(tt.expectedRequests, requests)
+			assert.Equal(tt.expectedChange, actual)
+		})
+	}
+}
@@ -1,6 +1,12 @@
 package serviceusers
 
-import "github.com/selectel/iam-go/service/roles"
+import (
+	"log/slog"
+
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/roles"
+)
 
 // ListResponse represents all Service Users in account.
 type ListResponse struct {
@@ -9,7 +15,7 @@ type ListResponse struct {
 
 // ServiceUser represents basic information about a Selectel Service User.
 type ServiceUser struct {
-	ID      string       `json:"id"`
+	ID      iamid.UserID `json:"id"`
 	Enabled bool         `json:"enabled"`
 	Name    string       `json:"name"`
 	Roles   []roles.Role `json:"roles"`
@@ -32,12 +38,28 @@ type UpdateResponse struct {
 	Groups []Group `json:"groups"`
 }
 
+// EnsureResponse represents a Selectel Service User after Ensure method.
+type EnsureResponse struct {
+	ServiceUser
+
+	// Created is true, if the Service User didn't exist and was created.
+	Created bool
+
+	// Updated is true, if the Service User existed and its attributes or roles were updated.
+	Updated bool
+}
+
+// Changed reports whether Ensure has created or updated the Service User.
+func (r EnsureResponse) Changed() bool {
+	return r.Created || r.Updated
+}
+
 // Group represents information about the Group the user is a member of.
 type Group struct {
-	ID          string       `json:"id"`
-	Name        string       `json:"name"`
-	Description string       `json:"description"`
-	Roles       []roles.Role `json:"roles"`
+	ID          iamid.GroupID `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Roles       []roles.Role  `json:"roles"`
 }
 
 // CreateRequest is used to set options for Create method.
@@ -45,10 +67,47 @@ type CreateRequest struct {
 	Enabled  bool
 	Name     string
 	Password string
-	GroupIDs []string
+	GroupIDs []iamid.GroupID
 	Roles    []roles.Role
 }
 
+// LogValue implements slog.LogValuer and redacts Password.
+func (r CreateRequest) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Bool("enabled", r.Enabled),
+		slog.String("name", r.Name),
+		slog.String("password", client.Redacted),
+		slog.Any("group_ids", r.GroupIDs),
+		slog.Any("roles", r.Roles),
+	)
+}
+
+// EnsureRequest is used to set options for Ensure method.
+type EnsureRequest struct {
+	// Enabled is set on a created or an existing Service User, if it's not nil.
+	// Otherwise, an existing Service User is left as is and a created one is disabled.
+	Enabled  *bool
+	Name     string
+	Password string
+	GroupIDs []iamid.GroupID
+	Roles    []roles.Role
+}
+
+// LogValue implements slog.LogValuer, omits Enabled, if it's not set, and redacts Password.
+func (r EnsureRequest) LogValue() slog.Value {
+	var attrs []slog.Attr
+	if r.Enabled != nil {
+		attrs = append(attrs, slog.Bool("enabled", *r.Enabled))
+	}
+	attrs = append(attrs,
+		slog.String("name", r.Name),
+		slog.String("password", client.Redacted),
+		slog.Any("group_ids", r.GroupIDs),
+		slog.Any("roles", r.Roles),
+	)
+	return slog.GroupValue(attrs...)
+}
+
 // UpdateRequest is used to set options for Update method.
 type UpdateRequest struct {
 	Enabled  bool
@@ -56,12 +115,44 @@ type UpdateRequest struct {
 	Password string
 }
 
+// LogValue implements slog.LogValuer and redacts Password.
+func (r UpdateRequest) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Bool("enabled", r.Enabled),
+		slog.String("name", r.Name),
+		slog.String("password", client.Redacted),
+	)
+}
+
+// PatchRequest is used to set options for Patch method.
+// Only the fields which are not nil are sent, the others keep their current values.
+type PatchRequest struct {
+	Enabled  *bool   `json:"enabled,omitempty"`
+	Name     *string `json:"name,omitempty"`
+	Password *string `json:"password,omitempty"`
+}
+
+// LogValue implements slog.LogValuer, omits the fields which are not set and redacts Password.
+func (r PatchRequest) LogValue() slog.Value {
+	var attrs []slog.Attr
+	if r.Enabled != nil {
+		attrs = append(attrs, slog.Bool("enabled", *r.Enabled))
+	}
+	if r.Name != nil {
+		attrs = append(attrs, slog.String("name", *r.Name))
+	}
+	if r.Password != nil {
+		attrs = append(attrs, slog.String("password", client.Redacted))
+	}
+	return slog.GroupValue(attrs...)
+}
+
 type createRequest struct {
-	Enabled  bool         `json:"enabled,omitempty"`
-	Name     string       `json:"name,omitempty"`
-	Password string       `json:"password,omitempty"`
-	GroupIds []string     `json:"group_ids,omitempty"`
-	Roles    []roles.Role `json:"roles,omitempty"`
+	Enabled  bool            `json:"enabled,omitempty"`
+	Name     string          `json:"name,omitempty"`
+	Password string          `json:"password,omitempty"`
+	GroupIds []iamid.GroupID `json:"group_ids,omitempty"`
+	Roles    []roles.Role    `json:"roles,omitempty"`
 }
 
 type updateRequest struct {
@@ -4,15 +4,23 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/url"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
-	"github.com/selectel/iam-go/service/roles"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/iammiddleware"
+	"github.com/selectel/iam-go/v2/iamrequest"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/roles"
 )
 
-const apiVersion = "iam/v1"
+const (
+	apiVersion  = "iam/v1"
+	serviceName = "users"
+)
 
 // Service is used to communicate with the Users API.
 type Service struct {
@@ -27,61 +35,79 @@ func New(baseClient *client.BaseClient) *Service {
 }
 
 // List returns a list of Users for the account.
-func (s *Service) List(ctx context.Context) (*ListResponse, error) {
-	path, err := url.JoinPath(apiVersion, "users")
+func (s *Service) List(ctx context.Context, opts ...iamrequest.Option) (*ListResponse, error) {
+	var users ListResponse
+	err := s.list(ctx, client.DecodeJSON(&users), opts)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		return nil, err
 	}
+	return &users, nil
+}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodGet,
-		Path:   path,
-	})
+// ForEach calls fn for every User of the account without loading the whole list into memory.
+// If fn returns an error, ForEach stops and returns it.
+func (s *Service) ForEach(ctx context.Context, fn func(User) error, opts ...iamrequest.Option) error {
+	return s.list(ctx, client.ForEachJSON("users", fn), opts)
+}
+
+func (s *Service) list(ctx context.Context, decode func(io.Reader) error, opts []iamrequest.Option) error {
+	path, err := url.JoinPath(apiVersion, "users")
 	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
+		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	var users ListResponse
-	err = client.UnmarshalJSON(response, &users)
+	err = s.baseClient.DoRequestDecode(ctx, client.DoRequestInput{
+		Body:    nil,
+		Method:  http.MethodGet,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "List",
+			PathTemplate: "iam/v1/users",
+		},
+	}, decode)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestDecode already wraps the error.
+		return err
 	}
-	return &users, nil
+
+	return nil
 }
 
 // Get returns an info of User with the selectel userID.
-func (s *Service) Get(ctx context.Context, userID string) (*GetResponse, error) {
+func (s *Service) Get(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) (*GetResponse, error) {
 	if userID == "" {
 		return nil, iamerrors.Error{Err: iamerrors.ErrUserIDRequired, Desc: "No userID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "users", userID)
+	path, err := url.JoinPath(apiVersion, "users", string(userID))
 	if err != nil {
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodGet,
-		Path:   path,
-	})
-	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
-	}
-
 	var user GetResponse
-	err = client.UnmarshalJSON(response, &user)
+	err = s.baseClient.DoRequestJSON(ctx, client.DoRequestInput{
+		Body:    nil,
+		Method:  http.MethodGet,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Get",
+			ResourceIDs:  map[string]string{"user_id": string(userID)},
+			PathTemplate: "iam/v1/users/{user_id}",
+		},
+	}, &user)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestJSON already wraps the error.
+		return nil, err
 	}
 	return &user, nil
 }
 
 // Create creates a new User.
-func (s *Service) Create(ctx context.Context, input CreateRequest) (*CreateResponse, error) {
+func (s *Service) Create(ctx context.Context, input CreateRequest, opts ...iamrequest.Option) (*CreateResponse, error) {
 	if input.Email == "" {
 		return nil, iamerrors.Error{Err: iamerrors.ErrUserEmailRequired, Desc: "No email for User was provided."}
 	}
@@ -104,39 +130,47 @@ func (s *Service) Create(ctx context.Context, input CreateRequest) (*CreateRespo
 		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
-	response, err := s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: http.MethodPost,
-		Path:   path,
-	})
-	if err != nil {
-		//nolint:wrapcheck // DoRequest already wraps the error.
-		return nil, err
-	}
-
 	var createdUser CreateResponse
-	err = client.UnmarshalJSON(response, &createdUser)
+	err = s.baseClient.DoRequestJSON(ctx, client.DoRequestInput{
+		Body:    bytes.NewReader(body),
+		Method:  http.MethodPost,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Create",
+			PathTemplate: "iam/v1/users",
+		},
+	}, &createdUser)
 	if err != nil {
-		return nil, iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
+		//nolint:wrapcheck // DoRequestJSON already wraps the error.
+		return nil, err
 	}
 	return &createdUser, nil
 }
 
 // Delete deletes a User from the account.
-func (s *Service) Delete(ctx context.Context, userID string) error {
+func (s *Service) Delete(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) error {
 	if userID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrUserIDRequired, Desc: "No userID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "users", userID)
+	path, err := url.JoinPath(apiVersion, "users", string(userID))
 	if err != nil {
 		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodDelete,
-		Path:   path,
+		Body:    nil,
+		Method:  http.MethodDelete,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "Delete",
+			ResourceIDs:  map[string]string{"user_id": string(userID)},
+			PathTemplate: "iam/v1/users/{user_id}",
+		},
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -147,20 +181,27 @@ func (s *Service) Delete(ctx context.Context, userID string) error {
 }
 
 // ResendInvite sends a confirmation email again.
-func (s *Service) ResendInvite(ctx context.Context, userID string) error {
+func (s *Service) ResendInvite(ctx context.Context, userID iamid.UserID, opts ...iamrequest.Option) error {
 	if userID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrUserIDRequired, Desc: "No userID was provided."}
 	}
 
-	path, err := url.JoinPath(apiVersion, "users", userID, "resend_invite")
+	path, err := url.JoinPath(apiVersion, "users", string(userID), "resend_invite")
 	if err != nil {
 		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   nil,
-		Method: http.MethodPatch,
-		Path:   path,
+		Body:    nil,
+		Method:  http.MethodPatch,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         "ResendInvite",
+			ResourceIDs:  map[string]string{"user_id": string(userID)},
+			PathTemplate: "iam/v1/users/{user_id}/resend_invite",
+		},
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -171,7 +212,9 @@ func (s *Service) ResendInvite(ctx context.Context, userID string) error {
 }
 
 // AssignRoles adds new roles for a User with the given userID.
-func (s *Service) AssignRoles(ctx context.Context, userID string, roles []roles.Role) error {
+func (s *Service) AssignRoles(
+	ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option,
+) error {
 	if userID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrUserIDRequired, Desc: "No userID was provided."}
 	}
@@ -180,11 +223,13 @@ func (s *Service) AssignRoles(ctx context.Context, userID string, roles []roles.
 		return iamerrors.Error{Err: iamerrors.ErrUserRolesRequired, Desc: "No roles for User was provided."}
 	}
 
-	return s.manageRoles(ctx, http.MethodPut, userID, roles)
+	return s.manageRoles(ctx, "AssignRoles", http.MethodPut, userID, roles, opts)
 }
 
 // UnassignRoles removes roles from a User with the given userID.
-func (s *Service) UnassignRoles(ctx context.Context, userID string, roles []roles.Role) error {
+func (s *Service) UnassignRoles(
+	ctx context.Context, userID iamid.UserID, roles []roles.Role, opts ...iamrequest.Option,
+) error {
 	if userID == "" {
 		return iamerrors.Error{Err: iamerrors.ErrUserIDRequired, Desc: "No userID was provided."}
 	}
@@ -192,11 +237,45 @@ func (s *Service) UnassignRoles(ctx context.Context, userID string, roles []role
 		return iamerrors.Error{Err: iamerrors.ErrUserRolesRequired, Desc: "No roles for User was provided."}
 	}
 
-	return s.manageRoles(ctx, http.MethodDelete, userID, roles)
+	return s.manageRoles(ctx, "UnassignRoles", http.MethodDelete, userID, roles, opts)
+}
+
+// SetRoles makes the User with the given userID have exactly the given roles. It fetches the current roles,
+// assigns the missing ones first and then unassigns the extra ones. Roles are compared by role name, scope and project.
+// If assigning or unassigning fails, the error is returned together with the Change holding the roles
+// which were already assigned.
+func (s *Service) SetRoles(
+	ctx context.Context, userID iamid.UserID, desired []roles.Role, opts ...iamrequest.Option,
+) (*roles.Change, error) {
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	current, err := s.Get(ctx, userID, calls.Read()...)
+	if err != nil {
+		return nil, err
+	}
+
+	var change roles.Change
+	assigned, unassigned := roles.Diff(current.Roles, desired)
+	if len(assigned) > 0 {
+		if err := s.AssignRoles(ctx, userID, assigned, calls.Write("assign")...); err != nil {
+			return &change, err
+		}
+		change.Assigned = assigned
+	}
+	if len(unassigned) > 0 {
+		if err := s.UnassignRoles(ctx, userID, unassigned, calls.Write("unassign")...); err != nil {
+			return &change, err
+		}
+		change.Unassigned = unassigned
+	}
+	return &change, nil
 }
 
-func (s *Service) manageRoles(ctx context.Context, method string, userID string, roles []roles.Role) error {
-	path, err := url.JoinPath(apiVersion, "users", userID, "roles")
+func (s *Service) manageRoles(
+	ctx context.Context, operation, method string, userID iamid.UserID, roles []roles.Role, opts []iamrequest.Option,
+) error {
+	path, err := url.JoinPath(apiVersion, "users", string(userID), "roles")
 	if err != nil {
 		return iamerrors.Error{Err: iamerrors.ErrInternalAppError, Desc: err.Error()}
 	}
@@ -208,9 +287,16 @@ func (s *Service) manageRoles(ctx context.Context, method string, userID string,
 	}
 
 	_, err = s.baseClient.DoRequest(ctx, client.DoRequestInput{
-		Body:   bytes.NewReader(body),
-		Method: method,
-		Path:   path,
+		Body:    bytes.NewReader(body),
+		Method:  method,
+		Path:    path,
+		Options: opts,
+		Operation: iammiddleware.Operation{
+			Service:      serviceName,
+			Name:         operation,
+			ResourceIDs:  map[string]string{"user_id": string(userID)},
+			PathTemplate: "iam/v1/users/{user_id}/roles",
+		},
 	})
 	if err != nil {
 		//nolint:wrapcheck // DoRequest already wraps the error.
@@ -219,3 +305,57 @@ func (s *Service) manageRoles(ctx context.Context, method string, userID string,
 
 	return nil
 }
+
+// Ensure makes sure that a User exists and has all the given roles. Roles which are not in the input
+// are not unassigned, and GroupIDs are used only to create a User.
+//
+// A federated User is looked up by its Federation ID and external ID, and the missing roles are assigned to it.
+// The IAM API doesn't return emails of Users, so a local User can't be looked up: Ensure creates it
+// and returns an error wrapping iamerrors.ErrUserAlreadyExists, if a User with the email already exists.
+func (s *Service) Ensure(ctx context.Context, input CreateRequest, opts ...iamrequest.Option) (*EnsureResponse, error) {
+	if input.Email == "" {
+		return nil, iamerrors.Error{Err: iamerrors.ErrUserEmailRequired, Desc: "No email for User was provided."}
+	}
+
+	ctx, cancel, calls := client.NewComposite(ctx, opts)
+	defer cancel()
+
+	if input.Federation != nil {
+		current, err := s.List(ctx, calls.Read()...)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range current.Users {
+			if user.Federation != nil && *user.Federation == *input.Federation {
+				return s.ensureRoles(ctx, user, input.Roles, calls)
+			}
+		}
+	}
+
+	created, err := s.Create(ctx, input, calls.Write("create")...)
+	var iamErr iamerrors.Error
+	if input.Federation == nil && errors.Is(err, iamerrors.ErrUserAlreadyExists) && errors.As(err, &iamErr) {
+		// Details of the response, e.g. StatusCode and RequestID, are kept.
+		iamErr.Desc = "Local User with the email already exists and can't be looked up, since emails aren't returned."
+		return nil, iamErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &EnsureResponse{User: created.User, Created: true}, nil
+}
+
+func (s *Service) ensureRoles(
+	ctx context.Context, user User, desired []roles.Role, calls client.Composite,
+) (*EnsureResponse, error) {
+	missing, _ := roles.Diff(user.Roles, desired)
+	if len(missing) == 0 {
+		return &EnsureResponse{User: user}, nil
+	}
+
+	if err := s.AssignRoles(ctx, user.ID, missing, calls.Write("assign")...); err != nil {
+		return nil, err
+	}
+	user.Roles = append(append([]roles.Role{}, user.Roles...), missing...)
+	return &EnsureResponse{User: user, Updated: true}, nil
+}
@@ -2,6 +2,7 @@ package users
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"testing"
 
@@ -9,10 +10,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/selectel/iam-go/iamerrors"
-	"github.com/selectel/iam-go/internal/client"
-	"github.com/selectel/iam-go/service/roles"
-	"github.com/selectel/iam-go/service/users/testdata"
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/internal/client"
+	"github.com/selectel/iam-go/v2/service/roles"
+	"github.com/selectel/iam-go/v2/service/users/testdata"
 )
 
 const (
@@ -96,9 +98,81 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestForEach(t *testing.T) {
+	tests := []struct {
+		name          string
+		prepare       func()
+		expectedItems []User
+		expectedError error
+	}{
+		{
+			name: "Test ForEach return output",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+usersURL, func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusOK, testdata.TestListUsersResponse)
+						return resp, nil
+					})
+			},
+			expectedItems: []User{
+				{
+					AuthType:   "local",
+					KeystoneID: "123",
+					ID:         "123",
+					Roles: []roles.Role{
+						{Scope: AccountScope, RoleName: Member},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Test ForEach return error",
+			prepare: func() {
+				httpmock.RegisterResponder(
+					http.MethodGet, testdata.TestURL+usersURL, func(r *http.Request) (*http.Response, error) {
+						resp := httpmock.NewStringResponse(http.StatusForbidden, testdata.TestDoRequestErr)
+						return resp, nil
+					})
+			},
+			expectedItems: nil,
+			expectedError: iamerrors.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			usersAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(usersAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			tt.prepare()
+
+			ctx := context.Background()
+			var actual []User
+			err := usersAPI.ForEach(ctx, func(item User) error {
+				actual = append(actual, item)
+				return nil
+			})
+
+			require.ErrorIs(err, tt.expectedError)
+
+			assert.Equal(tt.expectedItems, actual)
+		})
+	}
+}
+
 func TestGet(t *testing.T) {
 	type args struct {
-		userID string
+		userID iamid.UserID
 	}
 	tests := []struct {
 		name             string
@@ -179,7 +253,7 @@ func TestGet(t *testing.T) {
 
 func TestDelete(t *testing.T) {
 	type args struct {
-		userID string
+		userID iamid.UserID
 	}
 	tests := []struct {
 		name          string
@@ -345,7 +419,7 @@ func TestCreate(t *testing.T) {
 
 func TestResendInvite(t *testing.T) {
 	type args struct {
-		userID string
+		userID iamid.UserID
 	}
 	tests := []struct {
 		name          string
@@ -408,7 +482,7 @@ func TestResendInvite(t *testing.T) {
 
 func TestAssignRoles(t *testing.T) {
 	type args struct {
-		userID string
+		userID iamid.UserID
 		roles  []roles.Role
 	}
 	tests := []struct {
@@ -478,7 +552,7 @@ func TestAssignRoles(t *testing.T) {
 
 func TestUnassignRoles(t *testing.T) {
 	type args struct {
-		userID string
+		userID iamid.UserID
 		roles  []roles.Role
 	}
 	tests := []struct {
@@ -545,3 +619,180 @@ func TestUnassignRoles(t *testing.T) {
 		})
 	}
 }
+
+//nolint:funlen // This is a test function.
+func TestEnsure(t *testing.T) {
+	member := roles.Role{Scope: AccountScope, RoleName: Member}
+	federation := &Federation{ID: "456", ExternalID: "test"}
+
+	tests := []struct {
+		name             string
+		input            CreateRequest
+		createStatus     int
+		createResponse   string
+		expectedRequests []string
+		expectedCreated  bool
+		expectedUpdated  bool
+		expectedError    error
+	}{
+		{
+			name: "Test Ensure assigns missing roles to federated User",
+			input: CreateRequest{
+				AuthType: Federated, Email: "test@example.org", Federation: federation, Roles: []roles.Role{member},
+			},
+			expectedRequests: []string{`PUT {"roles":[{"role_name":"member","scope":"account"}]}`},
+			expectedUpdated:  true,
+		},
+		{
+			name:  "Test Ensure finds existing federated User",
+			input: CreateRequest{AuthType: Federated, Email: "test@example.org", Federation: federation},
+		},
+		{
+			name: "Test Ensure creates missing federated User",
+			input: CreateRequest{
+				AuthType: Federated, Email: "test@example.org", Federation: &Federation{ID: "456", ExternalID: "new"},
+			},
+			createStatus:     http.StatusOK,
+			createResponse:   testdata.TestCreateUserResponse,
+			expectedRequests: []string{"POST"},
+			expectedCreated:  true,
+		},
+		{
+			name:             "Test Ensure reports existing local User",
+			input:            CreateRequest{AuthType: Local, Email: "test@example.org"},
+			createStatus:     http.StatusConflict,
+			createResponse:   testdata.TestUserAlreadyExistsErr,
+			expectedRequests: []string{"POST"},
+			expectedError:    iamerrors.ErrUserAlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			usersAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(usersAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+usersURL,
+				httpmock.NewJsonResponderOrPanic(http.StatusOK, ListResponse{Users: []User{
+					{AuthType: Federated, Federation: federation, ID: "123", KeystoneID: "123"},
+				}}))
+
+			var requests []string
+			httpmock.RegisterResponder(http.MethodPost, testdata.TestURL+usersURL,
+				func(r *http.Request) (*http.Response, error) {
+					requests = append(requests, r.Method)
+					response := httpmock.NewStringResponse(tt.createStatus, tt.createResponse)
+					response.Header.Set("X-Request-Id", "test-request-id")
+					return response, nil
+				})
+			httpmock.RegisterResponder(http.MethodPut, testdata.TestURL+rolesURL,
+				func(r *http.Request) (*http.Response, error) {
+					body, _ := io.ReadAll(r.Body)
+					requests = append(requests, r.Method+" "+string(body))
+					return httpmock.NewStringResponse(http.StatusOK, ""), nil
+				})
+
+			actual, err := usersAPI.Ensure(context.Background(), tt.input)
+
+			assert.Equal(tt.expectedRequests, requests)
+			if tt.expectedError != nil {
+				require.ErrorIs(err, tt.expectedError)
+				assert.Nil(actual)
+				assert.True(iamerrors.IsConflict(err))
+				var iamErr iamerrors.Error
+				require.ErrorAs(err, &iamErr)
+				assert.Equal("test-request-id", iamErr.RequestID)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(tt.expectedCreated, actual.Created)
+			assert.Equal(tt.expectedUpdated, actual.Updated)
+			assert.Equal(tt.expectedCreated || tt.expectedUpdated, actual.Changed())
+		})
+	}
+}
+
+//nolint:funlen // This is a test function.
+func TestSetRoles(t *testing.T) {
+	member := roles.Role{Scope: AccountScope, RoleName: Member}
+	reader := roles.Role{Scope: AccountScope, RoleName: "reader"}
+
+	tests := []struct {
+		name             string
+		desired          []roles.Role
+		getStatus        int
+		expectedRequests []string
+		expectedChange   *roles.Change
+		expectedError    error
+	}{
+		{
+			name:           "Test SetRoles without changes",
+			desired:        []roles.Role{member},
+			getStatus:      http.StatusOK,
+			expectedChange: &roles.Change{},
+		},
+		{
+			name:      "Test SetRoles assigns and unassigns roles",
+			desired:   []roles.Role{reader},
+			getStatus: http.StatusOK,
+			expectedRequests: []string{
+				`PUT {"roles":[{"role_name":"reader","scope":"account"}]}`,
+				`DELETE {"roles":[{"role_name":"member","scope":"account"}]}`,
+			},
+			expectedChange: &roles.Change{Assigned: []roles.Role{reader}, Unassigned: []roles.Role{member}},
+		},
+		{
+			name:          "Test SetRoles returns Get error",
+			desired:       []roles.Role{reader},
+			getStatus:     http.StatusForbidden,
+			expectedError: iamerrors.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			usersAPI := New(&client.BaseClient{
+				HTTPClient: &http.Client{},
+				APIUrl:     testdata.TestURL,
+				AuthMethod: &client.KeystoneTokenAuth{KeystoneToken: testdata.TestToken},
+			})
+
+			httpmock.ActivateNonDefault(usersAPI.baseClient.HTTPClient)
+			defer httpmock.DeactivateAndReset()
+
+			getResponse := testdata.TestGetUserResponse
+			if tt.getStatus != http.StatusOK {
+				getResponse = testdata.TestDoRequestErr
+			}
+			httpmock.RegisterResponder(http.MethodGet, testdata.TestURL+usersIDURL,
+				httpmock.NewStringResponder(tt.getStatus, getResponse))
+
+			var requests []string
+			responder := func(r *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(r.Body)
+				requests = append(requests, r.Method+" "+string(body))
+				return httpmock.NewStringResponse(http.StatusOK, ""), nil
+			}
+			httpmock.RegisterResponder(http.MethodPut, testdata.TestURL+rolesURL, responder)
+			httpmock.RegisterResponder(http.MethodDelete, testdata.TestURL+rolesURL, responder)
+
+			actual, err := usersAPI.SetRoles(context.Background(), "123", tt.desired)
+
+			require.ErrorIs(err, tt.expectedError)
+			assert.Equal(tt.expectedRequests, requests)
+			assert.Equal(tt.expectedChange, actual)
+		})
+	}
+}
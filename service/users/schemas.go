@@ -1,7 +1,8 @@
 package users
 
 import (
-	"github.com/selectel/iam-go/service/roles"
+	"github.com/selectel/iam-go/v2/iamid"
+	"github.com/selectel/iam-go/v2/service/roles"
 )
 
 // AuthType represents a type of authentication of a User.
@@ -22,11 +23,11 @@ type ListResponse struct {
 
 // User represents basic information about a Selectel User.
 type User struct {
-	AuthType   AuthType     `json:"auth_type"`
-	Federation *Federation  `json:"federation,omitempty"`
-	Roles      []roles.Role `json:"roles"`
-	ID         string       `json:"id"`
-	KeystoneID string       `json:"keystone_id"`
+	AuthType   AuthType         `json:"auth_type"`
+	Federation *Federation      `json:"federation,omitempty"`
+	Roles      []roles.Role     `json:"roles"`
+	ID         iamid.UserID     `json:"id"`
+	KeystoneID iamid.KeystoneID `json:"keystone_id"`
 }
 
 // GetResponse represents a Selectel Panel User.
@@ -35,20 +36,36 @@ type GetResponse struct {
 	Groups []Group `json:"groups"`
 }
 
+// EnsureResponse represents a Selectel Panel User after Ensure method.
+type EnsureResponse struct {
+	User
+
+	// Created is true, if the User didn't exist and was created.
+	Created bool
+
+	// Updated is true, if the User existed and roles were assigned to it.
+	Updated bool
+}
+
+// Changed reports whether Ensure has created or updated the User.
+func (r EnsureResponse) Changed() bool {
+	return r.Created || r.Updated
+}
+
 // Federation contains info about federation for users with Federated AuthType.
 type Federation struct {
 	// ExternalID is user id that will be sent by the identity provider.
 	ExternalID string `json:"external_id"`
 	// ID represents identifier of federation in Selectel
-	ID string `json:"id"`
+	ID iamid.FederationID `json:"id"`
 }
 
 // Group represents information about the Group the user is a member of.
 type Group struct {
-	ID          string       `json:"id"`
-	Name        string       `json:"name"`
-	Description string       `json:"description"`
-	Roles       []roles.Role `json:"roles"`
+	ID          iamid.GroupID `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Roles       []roles.Role  `json:"roles"`
 }
 
 // CreateRequest is used to set options for Create method.
@@ -57,17 +74,17 @@ type CreateRequest struct {
 	Email      string
 	Federation *Federation
 	Roles      []roles.Role
-	GroupIDs   []string
+	GroupIDs   []iamid.GroupID
 }
 
 type createRequest struct {
-	AuthType          AuthType     `json:"auth_type,omitempty"`
-	Email             string       `json:"email,omitempty"`
-	Federation        *Federation  `json:"federation,omitempty"`
-	Roles             []roles.Role `json:"roles,omitempty"`
-	GroupIds          []string     `json:"group_ids,omitempty"`
-	SubscriptionsOnly bool         `json:"subscriptions_only"` // Issue, should be hardcoded to `false`
-	Subscriptions     []string     `json:"subscriptions"`      // Issue, should be hardcoded to `[]`
+	AuthType          AuthType        `json:"auth_type,omitempty"`
+	Email             string          `json:"email,omitempty"`
+	Federation        *Federation     `json:"federation,omitempty"`
+	Roles             []roles.Role    `json:"roles,omitempty"`
+	GroupIds          []iamid.GroupID `json:"group_ids,omitempty"`
+	SubscriptionsOnly bool            `json:"subscriptions_only"` // Issue, should be hardcoded to `false`
+	Subscriptions     []string        `json:"subscriptions"`      // Issue, should be hardcoded to `[]`
 }
 
 // CreateResponse represents a Selectel Panel User.
@@ -61,3 +61,8 @@ const TestDoRequestErr = `{
 	"code": "REQUEST_FORBIDDEN",
 	"message": "You don't have permission to do this"
 }`
+
+const TestUserAlreadyExistsErr = `{
+	"code": "USER_ALREADY_EXISTS",
+	"message": "User with email test@example.org already exists"
+}`
@@ -0,0 +1,145 @@
+package iam
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/selectel/iam-go/v2/iamerrors"
+	"github.com/selectel/iam-go/v2/service/roles"
+)
+
+const testUserNotFoundResponse = `{
+	"code": "USER_NOT_FOUND",
+	"message": "User not found"
+}`
+
+//nolint:funlen // This is a test function.
+func TestWithTracerProvider(t *testing.T) {
+	tests := []struct {
+		name               string
+		statusCode         int
+		body               string
+		expectedAttributes []attribute.KeyValue
+		expectedStatus     codes.Code
+		expectedError      error
+	}{
+		{
+			name:       "Test span of successful call",
+			statusCode: http.StatusOK,
+			body:       "",
+			expectedAttributes: []attribute.KeyValue{
+				attribute.String("iam.operation", "users.AssignRoles"),
+				attribute.String("iam.service", "users"),
+				attribute.String("http.request.method", http.MethodPut),
+				attribute.String("url.template", "iam/v1/users/{user_id}/roles"),
+				attribute.String("iam.resource.user_id", testUserID),
+				attribute.Int("http.response.status_code", http.StatusOK),
+				attribute.Int("iam.attempts", 1),
+			},
+			expectedStatus: codes.Unset,
+		},
+		{
+			name:       "Test span of failed call",
+			statusCode: http.StatusNotFound,
+			body:       testUserNotFoundResponse,
+			expectedAttributes: []attribute.KeyValue{
+				attribute.String("iam.operation", "users.AssignRoles"),
+				attribute.String("iam.service", "users"),
+				attribute.String("http.request.method", http.MethodPut),
+				attribute.String("url.template", "iam/v1/users/{user_id}/roles"),
+				attribute.String("iam.resource.user_id", testUserID),
+				attribute.Int("http.response.status_code", http.StatusNotFound),
+				attribute.Int("iam.attempts", 1),
+				attribute.String("iam.error_code", "USER_NOT_FOUND"),
+			},
+			expectedStatus: codes.Error,
+			expectedError:  iamerrors.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			exporter := tracetest.NewInMemoryExporter()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+			httpClient := &http.Client{}
+			httpmock.ActivateNonDefault(httpClient)
+			defer httpmock.DeactivateAndReset()
+
+			var traceParent string
+			httpmock.RegisterResponder(
+				http.MethodPut, testURL+"iam/v1/users/"+testUserID+"/roles",
+				func(r *http.Request) (*http.Response, error) {
+					traceParent = r.Header.Get("traceparent")
+					return httpmock.NewStringResponse(tt.statusCode, tt.body), nil
+				})
+
+			client, err := New(
+				WithAPIUrl(testURL),
+				WithAuthOpts(&AuthOpts{KeystoneToken: testToken}),
+				WithCustomHTTPClient(httpClient),
+				WithTracerProvider(provider),
+			)
+			require.NoError(err)
+
+			err = client.Users.AssignRoles(
+				context.Background(), testUserID, []roles.Role{{RoleName: "member", Scope: "account"}},
+			)
+
+			require.ErrorIs(err, tt.expectedError)
+
+			spans := exporter.GetSpans()
+			require.Len(spans, 1)
+			span := spans[0]
+			assert.Equal("users.AssignRoles", span.Name)
+			assert.Equal(trace.SpanKindClient, span.SpanKind)
+			assert.ElementsMatch(tt.expectedAttributes, span.Attributes)
+			assert.Equal(tt.expectedStatus, span.Status.Code)
+			assert.Equal(
+				"00-"+span.SpanContext.TraceID().String()+"-"+span.SpanContext.SpanID().String()+"-01",
+				traceParent,
+			)
+		})
+	}
+}
+
+func TestWithoutTracerProvider(t *testing.T) {
+	require := require.New(t)
+
+	httpClient := &http.Client{}
+	httpmock.ActivateNonDefault(httpClient)
+	defer httpmock.DeactivateAndReset()
+
+	var header http.Header
+	httpmock.RegisterResponder(
+		http.MethodGet, testURL+"iam/v1/users/"+testUserID,
+		func(r *http.Request) (*http.Response, error) {
+			header = r.Header
+			return httpmock.NewStringResponse(http.StatusOK, "{}"), nil
+		})
+
+	client, err := New(
+		WithAPIUrl(testURL),
+		WithAuthOpts(&AuthOpts{KeystoneToken: testToken}),
+		WithCustomHTTPClient(httpClient),
+	)
+	require.NoError(err)
+
+	_, err = client.Users.Get(context.Background(), testUserID)
+
+	require.NoError(err)
+	require.Empty(header.Get("traceparent"))
+}